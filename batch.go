@@ -0,0 +1,155 @@
+package syncmapt
+
+// StoreAll sets the values for all the keys of entries. It takes the map's
+// internal lock once for the whole batch rather than once per new key, which
+// makes it much faster than a sequence of Stores for filling a map.
+//
+// The entries are not stored atomically as a group: concurrent operations
+// may observe some of them stored and others not yet.
+func (m *Map[K, V]) StoreAll(entries map[K]V) {
+	if m.Closed() {
+		return
+	}
+	if len(entries) == 0 {
+		return
+	}
+	pairs := make([]Pair[K, V], 0, len(entries))
+	for k, v := range entries {
+		pairs = append(pairs, Pair[K, V]{m.key(k), v})
+	}
+
+	m.mu.Lock()
+	read, _ := m.read.Load().(readOnly[K, V])
+	for _, p := range pairs {
+		if e, ok := read.m[p.Key]; ok {
+			if e.unexpungeLocked() {
+				m.dirty[p.Key] = e
+			}
+			e.storeLocked(&m.ver, p.Value)
+		} else if e, ok := m.dirty[p.Key]; ok {
+			e.storeLocked(&m.ver, p.Value)
+		} else {
+			if !read.amended {
+				m.dirtySizedLocked(len(pairs))
+				read = readOnly[K, V]{m: read.m, amended: true}
+				m.read.Store(read)
+			}
+			m.dirty[p.Key] = m.newEntryLocked(p.Value)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, p := range pairs {
+		m.stored(p.Key, p.Value)
+	}
+}
+
+// LoadAll returns the values stored in the map for keys, by key as given; keys
+// with no value are left out. Keys missing from the lock-free part of the map
+// are looked up under a single acquisition of the internal lock.
+//
+// Like Range, LoadAll does not observe a consistent snapshot of the map: each
+// key is loaded independently of the others.
+func (m *Map[K, V]) LoadAll(keys []K) map[K]V {
+	values := make(map[K]V, len(keys))
+	read, _ := m.read.Load().(readOnly[K, V])
+	var misses []K
+	for _, k := range keys {
+		e, ok := read.m[m.key(k)]
+		if !ok {
+			if read.amended {
+				misses = append(misses, k)
+			} else {
+				m.countLoad(false)
+			}
+			continue
+		}
+		v, ok := e.load(&m.ver)
+		if ok {
+			values[k] = v
+		}
+		m.countLoad(ok)
+	}
+	if len(misses) == 0 {
+		return values
+	}
+
+	m.mu.Lock()
+	read, _ = m.read.Load().(readOnly[K, V])
+	entries := make([]*entry[V], len(misses))
+	for i, k := range misses {
+		key := m.key(k)
+		e, ok := read.m[key]
+		if !ok && read.amended {
+			e = m.dirty[key]
+			m.missLocked()
+			// missLocked may have promoted the dirty map.
+			read, _ = m.read.Load().(readOnly[K, V])
+		}
+		entries[i] = e
+	}
+	m.mu.Unlock()
+
+	for i, e := range entries {
+		var v V
+		ok := false
+		if e != nil {
+			v, ok = e.load(&m.ver)
+		}
+		if ok {
+			values[misses[i]] = v
+		}
+		m.countLoad(ok)
+	}
+	return values
+}
+
+// DeleteAll deletes the values for keys. Keys missing from the lock-free part
+// of the map are deleted under a single acquisition of the internal lock.
+func (m *Map[K, V]) DeleteAll(keys []K) {
+	if m.Closed() {
+		return
+	}
+	read, _ := m.read.Load().(readOnly[K, V])
+	var deleted []Pair[K, V]
+	var misses []K
+	for _, k := range keys {
+		key := m.key(k)
+		if e, ok := read.m[key]; ok {
+			if v, ok := e.delete(&m.ver); ok {
+				deleted = append(deleted, Pair[K, V]{key, v})
+			}
+		} else if read.amended {
+			misses = append(misses, key)
+		}
+	}
+
+	if len(misses) > 0 {
+		m.mu.Lock()
+		read, _ = m.read.Load().(readOnly[K, V])
+		entries := make([]*entry[V], len(misses))
+		for i, key := range misses {
+			e, ok := read.m[key]
+			if !ok && read.amended {
+				e = m.dirty[key]
+				delete(m.dirty, key)
+				m.missLocked()
+				read, _ = m.read.Load().(readOnly[K, V])
+			}
+			entries[i] = e
+		}
+		m.mu.Unlock()
+		for i, e := range entries {
+			if e == nil {
+				continue
+			}
+			if v, ok := e.delete(&m.ver); ok {
+				deleted = append(deleted, Pair[K, V]{misses[i], v})
+			}
+		}
+	}
+
+	for _, p := range deleted {
+		m.deleted(p.Key, p.Value)
+	}
+}
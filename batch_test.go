@@ -0,0 +1,59 @@
+package syncmapt_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+	"github.com/holdno/syncmapt/syncmapttest"
+)
+
+func TestBatch(t *testing.T) {
+	m := syncmapt.New(syncmapt.WithKeyTransform[string, int](strings.ToLower))
+	m.Store("a", 1)
+	m.Store("b", 2)
+	m.Load("a") // keep a in the read map and b in the dirty one
+
+	m.StoreAll(map[string]int{"A": 10, "C": 3, "d": 4})
+	syncmapttest.RequireEqual(t, m, map[string]int{"a": 10, "b": 2, "c": 3, "d": 4})
+
+	got := m.LoadAll([]string{"A", "b", "x", "D"})
+	want := map[string]int{"A": 10, "b": 2, "D": 4}
+	if len(got) != len(want) {
+		t.Fatalf("LoadAll = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("LoadAll = %v, want %v", got, want)
+		}
+	}
+
+	m.DeleteAll([]string{"B", "c", "x"})
+	syncmapttest.RequireEqual(t, m, map[string]int{"a": 10, "d": 4})
+	m.StoreAll(nil)
+	m.DeleteAll(nil)
+	if len(m.LoadAll(nil)) != 0 || m.Len() != 2 {
+		t.Fatal("empty batches changed the map")
+	}
+}
+
+func BenchmarkStoreAll(b *testing.B) {
+	entries := make(map[int]int, 100000)
+	for i := range 100000 {
+		entries[i] = i
+	}
+	b.Run("Store", func(b *testing.B) {
+		for range b.N {
+			var m syncmapt.Map[int, int]
+			for k, v := range entries {
+				m.Store(k, v)
+			}
+		}
+	})
+	b.Run("StoreAll", func(b *testing.B) {
+		for range b.N {
+			var m syncmapt.Map[int, int]
+			m.StoreAll(entries)
+		}
+	})
+}
@@ -0,0 +1,184 @@
+package syncmapt
+
+import "sync"
+
+// A BiMap is a concurrent one-to-one map: each key has at most one value and
+// each value at most one key, so entries can be looked up in either
+// direction. Every operation updates both directions under one lock, so no
+// goroutine ever sees a key whose value maps back to another key.
+//
+// Storing a value that is already held by another key moves the value to the
+// new key, deleting the old key. Lookups take a read lock and updates a write
+// lock. Of the Options, WithKeyTransform canonicalizes keys and WithCapacity
+// sizes the map; values are used as they are.
+//
+// The zero BiMap is empty and ready for use. A BiMap must not be copied after
+// first use.
+type BiMap[K, V comparable] struct {
+	mu      sync.RWMutex
+	cfg     *config[K, V]
+	forward map[K]V
+	inverse map[V]K
+	closed  bool // set by Close
+}
+
+var _ Interface[string, int] = (*BiMap[string, int])(nil)
+
+// NewBiMap returns an empty BiMap configured by opts.
+func NewBiMap[K, V comparable](opts ...Option[K, V]) *BiMap[K, V] {
+	m := &BiMap[K, V]{cfg: newConfig(opts)}
+	m.initLocked()
+	return m
+}
+
+// initLocked allocates the maps of a zero BiMap. m.cfg is only set by
+// NewBiMap, as key reads it without the lock.
+func (m *BiMap[K, V]) initLocked() {
+	if m.forward != nil {
+		return
+	}
+	capacity := 0
+	if m.cfg != nil {
+		capacity = m.cfg.capacity
+	}
+	m.forward = make(map[K]V, capacity)
+	m.inverse = make(map[V]K, capacity)
+}
+
+func (m *BiMap[K, V]) key(k K) K {
+	if m.cfg == nil || m.cfg.keyTransform == nil {
+		return k
+	}
+	return m.cfg.keyTransform(k)
+}
+
+// Load returns the value stored in the map for a key, or the zero value if no
+// value is present. The ok result indicates whether value was found in the
+// map.
+func (m *BiMap[K, V]) Load(key K) (value V, ok bool) {
+	key = m.key(key)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	value, ok = m.forward[key]
+	return value, ok
+}
+
+// LoadByValue returns the key that holds value, or the zero value if no key
+// does. The ok result indicates whether the key was found.
+func (m *BiMap[K, V]) LoadByValue(value V) (key K, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	key, ok = m.inverse[value]
+	return key, ok
+}
+
+// Store sets the value for a key. If another key holds value, that key is
+// deleted.
+func (m *BiMap[K, V]) Store(key K, value V) {
+	key = m.key(key)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.initLocked()
+	if !m.closed {
+		m.storeLocked(key, value)
+	}
+}
+
+func (m *BiMap[K, V]) storeLocked(key K, value V) {
+	if old, ok := m.forward[key]; ok {
+		delete(m.inverse, old)
+	}
+	if k, ok := m.inverse[value]; ok {
+		delete(m.forward, k)
+	}
+	m.forward[key] = value
+	m.inverse[value] = key
+}
+
+// LoadOrStore returns the existing value for the key if present. Otherwise,
+// it stores and returns the given value, deleting any other key that holds
+// it. The loaded result is true if the value was loaded, false if stored.
+func (m *BiMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	key = m.key(key)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.initLocked()
+	if actual, loaded = m.forward[key]; loaded || m.closed {
+		return actual, loaded
+	}
+	m.storeLocked(key, value)
+	return value, false
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if
+// any. The loaded result reports whether the key was present.
+func (m *BiMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	key = m.key(key)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return value, false
+	}
+	if value, loaded = m.forward[key]; loaded {
+		delete(m.forward, key)
+		delete(m.inverse, value)
+	}
+	return value, loaded
+}
+
+// Delete deletes the value for a key.
+func (m *BiMap[K, V]) Delete(key K) {
+	m.LoadAndDelete(key)
+}
+
+// DeleteByValue deletes the key that holds value, returning the key if any.
+// The loaded result reports whether value was present.
+func (m *BiMap[K, V]) DeleteByValue(value V) (key K, loaded bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return key, false
+	}
+	if key, loaded = m.inverse[value]; loaded {
+		delete(m.inverse, value)
+		delete(m.forward, key)
+	}
+	return key, loaded
+}
+
+// Range calls f sequentially for each key and value present in the map. If f
+// returns false, range stops the iteration.
+//
+// Range copies the entries under the map's read lock and calls f with no lock
+// held, so f may call any method of m.
+func (m *BiMap[K, V]) Range(f func(key K, value V) bool) {
+	m.mu.RLock()
+	entries := make([]Pair[K, V], 0, len(m.forward))
+	for k, v := range m.forward {
+		entries = append(entries, Pair[K, V]{k, v})
+	}
+	m.mu.RUnlock()
+	for _, e := range entries {
+		if !f(e.Key, e.Value) {
+			return
+		}
+	}
+}
+
+// Len returns the number of entries in the map.
+func (m *BiMap[K, V]) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.forward)
+}
+
+// Close closes the map: afterwards its contents can still be read, but the
+// methods that would modify it do nothing. LoadAndDelete and DeleteByValue
+// report that nothing was deleted, and LoadOrStore returns the current value
+// for the key as Load does. Close is idempotent and always returns nil.
+func (m *BiMap[K, V]) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	return nil
+}
@@ -0,0 +1,70 @@
+package syncmapt_test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+)
+
+// BiMap is not checked with syncmapttest.RunConformance: storing a value that
+// is already present under another key deletes that key, to keep the mapping
+// one-to-one, which the plain map model of the suite does not do.
+func TestBiMap(t *testing.T) {
+	var m syncmapt.BiMap[string, int]
+	m.Store("a", 1)
+	m.Store("b", 2)
+	if k, ok := m.LoadByValue(2); !ok || k != "b" {
+		t.Fatalf("LoadByValue(2) = %q, %v; want b, true", k, ok)
+	}
+
+	m.Store("a", 3) // frees 1
+	if _, ok := m.LoadByValue(1); ok {
+		t.Fatal("LoadByValue(1) found the old value of a")
+	}
+	m.Store("c", 2) // takes 2 from b
+	if _, ok := m.Load("b"); ok {
+		t.Fatal("b still present after its value moved to c")
+	}
+	if v, loaded := m.LoadOrStore("c", 4); !loaded || v != 2 {
+		t.Fatalf("LoadOrStore(c, 4) = %v, %v; want 2, true", v, loaded)
+	}
+
+	if k, loaded := m.DeleteByValue(3); !loaded || k != "a" {
+		t.Fatalf("DeleteByValue(3) = %q, %v; want a, true", k, loaded)
+	}
+	if _, ok := m.Load("a"); ok {
+		t.Fatal("a still present after DeleteByValue")
+	}
+	if v, loaded := m.LoadAndDelete("c"); !loaded || v != 2 {
+		t.Fatalf("LoadAndDelete(c) = %v, %v; want 2, true", v, loaded)
+	}
+	if _, ok := m.LoadByValue(2); ok || m.Len() != 0 {
+		t.Fatalf("map not empty after deleting every key: Len() = %d", m.Len())
+	}
+}
+
+func TestBiMapConsistent(t *testing.T) {
+	m := syncmapt.NewBiMap[string, int]()
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				m.Store(strconv.Itoa((g+i)%16), (g*i)%16)
+				if i%5 == 0 {
+					m.DeleteByValue(i % 16)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	m.Range(func(k string, v int) bool {
+		if back, ok := m.LoadByValue(v); !ok || back != k {
+			t.Errorf("%q maps to %d, which maps back to %q, %v", k, v, back, ok)
+		}
+		return true
+	})
+}
@@ -0,0 +1,219 @@
+package syncmapt
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// The binary encoding of a Map is a version byte, the number of entries, and
+// the entries, each a key followed by its value. All counts are uvarints, and
+// every key and value is preceded by the length of its encoding:
+//
+//	version (1) | entries | { len(key) | key | len(value) | value }...
+//
+// Keys and values are encoded by their own AppendBinary or MarshalBinary
+// method if they have one. Otherwise, strings and byte slices are encoded as
+// their bytes, signed and unsigned integers of platform-dependent size as
+// varints and uvarints, and other fixed-size data as with binary.Append in
+// little-endian byte order.
+const binaryVersion = 1
+
+var errBinaryFormat = errors.New("syncmapt: invalid binary encoding of a Map")
+
+// MarshalBinary implements encoding.BinaryMarshaler. It encodes a copy of the
+// contents of the map taken as by ToMap, and fails if a key or value has a
+// type that cannot be encoded.
+func (m *Map[K, V]) MarshalBinary() ([]byte, error) {
+	return m.AppendBinary(nil)
+}
+
+// AppendBinary implements encoding.BinaryAppender, appending the encoding
+// produced by MarshalBinary to b.
+func (m *Map[K, V]) AppendBinary(b []byte) ([]byte, error) {
+	appendKey := binaryAppender[K]()
+	appendValue := binaryAppender[V]()
+	contents := m.ToMap()
+	b = append(b, binaryVersion)
+	b = binary.AppendUvarint(b, uint64(len(contents)))
+	var scratch []byte
+	var err error
+	for k, v := range contents {
+		if scratch, err = appendKey(scratch[:0], k); err != nil {
+			return nil, fmt.Errorf("syncmapt: encoding key: %w", err)
+		}
+		b = binary.AppendUvarint(b, uint64(len(scratch)))
+		b = append(b, scratch...)
+		if scratch, err = appendValue(scratch[:0], v); err != nil {
+			return nil, fmt.Errorf("syncmapt: encoding value: %w", err)
+		}
+		b = binary.AppendUvarint(b, uint64(len(scratch)))
+		b = append(b, scratch...)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It decodes the
+// encoding produced by MarshalBinary and stores its entries in the map,
+// keeping existing entries with other keys. Nothing is stored if data is not
+// a valid encoding.
+func (m *Map[K, V]) UnmarshalBinary(data []byte) error {
+	entries, err := decodeBinary[K, V](data)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		m.Store(e.Key, e.Value)
+	}
+	return nil
+}
+
+// decodeBinary returns the entries of the encoding of a Map produced by
+// MarshalBinary.
+func decodeBinary[K comparable, V any](data []byte) ([]Pair[K, V], error) {
+	decodeKey := binaryDecoder[K]()
+	decodeValue := binaryDecoder[V]()
+	if len(data) == 0 {
+		return nil, errBinaryFormat
+	}
+	if data[0] != binaryVersion {
+		return nil, fmt.Errorf("syncmapt: unsupported binary encoding version %d", data[0])
+	}
+	data = data[1:]
+	n, data, err := nextUvarint(data)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(data))/2 {
+		// Every entry takes at least two bytes.
+		return nil, errBinaryFormat
+	}
+	entries := make([]Pair[K, V], n)
+	for i := range entries {
+		var field []byte
+		if field, data, err = nextField(data); err != nil {
+			return nil, err
+		}
+		if err := decodeKey(field, &entries[i].Key); err != nil {
+			return nil, fmt.Errorf("syncmapt: decoding key: %w", err)
+		}
+		if field, data, err = nextField(data); err != nil {
+			return nil, err
+		}
+		if err := decodeValue(field, &entries[i].Value); err != nil {
+			return nil, fmt.Errorf("syncmapt: decoding value: %w", err)
+		}
+	}
+	if len(data) != 0 {
+		return nil, errBinaryFormat
+	}
+	return entries, nil
+}
+
+func nextUvarint(data []byte) (uint64, []byte, error) {
+	x, n := binary.Uvarint(data)
+	if n <= 0 {
+		return 0, nil, errBinaryFormat
+	}
+	return x, data[n:], nil
+}
+
+// nextField splits a length-prefixed field from the start of data.
+func nextField(data []byte) (field, rest []byte, err error) {
+	n, data, err := nextUvarint(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if n > uint64(len(data)) {
+		return nil, nil, errBinaryFormat
+	}
+	return data[:n:n], data[n:], nil
+}
+
+// binaryAppender returns the function that appends the binary encoding of a
+// T, chosen once for the type.
+func binaryAppender[T any]() func([]byte, T) ([]byte, error) {
+	var zero T
+	switch any(zero).(type) {
+	case encoding.BinaryAppender:
+		return func(b []byte, v T) ([]byte, error) { return any(v).(encoding.BinaryAppender).AppendBinary(b) }
+	case encoding.BinaryMarshaler:
+		return func(b []byte, v T) ([]byte, error) {
+			data, err := any(v).(encoding.BinaryMarshaler).MarshalBinary()
+			return append(b, data...), err
+		}
+	}
+	switch any(&zero).(type) {
+	case encoding.BinaryAppender:
+		return func(b []byte, v T) ([]byte, error) { return any(&v).(encoding.BinaryAppender).AppendBinary(b) }
+	case encoding.BinaryMarshaler:
+		return func(b []byte, v T) ([]byte, error) {
+			data, err := any(&v).(encoding.BinaryMarshaler).MarshalBinary()
+			return append(b, data...), err
+		}
+	}
+
+	t := reflect.TypeFor[T]()
+	switch {
+	case t.Kind() == reflect.String:
+		return func(b []byte, v T) ([]byte, error) { return append(b, reflect.ValueOf(v).String()...), nil }
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return func(b []byte, v T) ([]byte, error) { return append(b, reflect.ValueOf(v).Bytes()...), nil }
+	case t.Kind() == reflect.Int:
+		return func(b []byte, v T) ([]byte, error) { return binary.AppendVarint(b, reflect.ValueOf(v).Int()), nil }
+	case t.Kind() == reflect.Uint || t.Kind() == reflect.Uintptr:
+		return func(b []byte, v T) ([]byte, error) { return binary.AppendUvarint(b, reflect.ValueOf(v).Uint()), nil }
+	}
+	return func(b []byte, v T) ([]byte, error) { return binary.Append(b, binary.LittleEndian, v) }
+}
+
+// binaryDecoder returns the function that decodes the binary encoding of a T
+// produced by binaryAppender, chosen once for the type.
+func binaryDecoder[T any]() func([]byte, *T) error {
+	var zero T
+	if _, ok := any(&zero).(encoding.BinaryUnmarshaler); ok {
+		return func(data []byte, p *T) error { return any(p).(encoding.BinaryUnmarshaler).UnmarshalBinary(data) }
+	}
+
+	t := reflect.TypeFor[T]()
+	switch {
+	case t.Kind() == reflect.String:
+		return func(data []byte, p *T) error {
+			reflect.ValueOf(p).Elem().SetString(string(data))
+			return nil
+		}
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return func(data []byte, p *T) error {
+			reflect.ValueOf(p).Elem().SetBytes(bytes.Clone(data))
+			return nil
+		}
+	case t.Kind() == reflect.Int:
+		return func(data []byte, p *T) error {
+			x, n := binary.Varint(data)
+			if n != len(data) || reflect.ValueOf(p).Elem().OverflowInt(x) {
+				return errBinaryFormat
+			}
+			reflect.ValueOf(p).Elem().SetInt(x)
+			return nil
+		}
+	case t.Kind() == reflect.Uint || t.Kind() == reflect.Uintptr:
+		return func(data []byte, p *T) error {
+			x, n := binary.Uvarint(data)
+			if n != len(data) || reflect.ValueOf(p).Elem().OverflowUint(x) {
+				return errBinaryFormat
+			}
+			reflect.ValueOf(p).Elem().SetUint(x)
+			return nil
+		}
+	}
+	return func(data []byte, p *T) error {
+		n, err := binary.Decode(data, binary.LittleEndian, p)
+		if err == nil && n != len(data) {
+			err = errBinaryFormat
+		}
+		return err
+	}
+}
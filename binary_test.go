@@ -0,0 +1,93 @@
+package syncmapt_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/holdno/syncmapt"
+	"github.com/holdno/syncmapt/syncmapttest"
+)
+
+func roundTrip[K comparable, V any](t *testing.T, contents map[K]V) {
+	t.Helper()
+	data, err := syncmapttest.New(contents).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m syncmapt.Map[K, V]
+	if err := m.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	syncmapttest.RequireEqual(t, &m, contents)
+}
+
+func TestBinary(t *testing.T) {
+	type id int
+	type point struct{ X, Y int32 }
+	roundTrip(t, map[string]int{"a": 1, "": -1 << 30, strings.Repeat("k", 300): 0})
+	roundTrip(t, map[id]uint{1: 1, -2: 1 << 31})
+	roundTrip(t, map[int64]point{-1: {1, 2}, 1 << 50: {-3, 4}})
+	roundTrip(t, map[string]string{"empty": "", "a": "alpha"})
+	roundTrip(t, map[bool]float64{true: 1.5, false: -0.25})
+	roundTrip(t, map[[2]byte]time.Time{{1, 2}: time.Date(2024, 5, 6, 7, 8, 9, 10, time.UTC)})
+	roundTrip(t, map[int]string{})
+
+	var bytesMap syncmapt.Map[string, []byte]
+	bytesMap.Store("k", []byte{0, 1, 2})
+	data, err := bytesMap.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out syncmapt.Map[string, []byte]
+	if err := out.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := out.Load("k"); string(v) != "\x00\x01\x02" {
+		t.Fatalf("[]byte value = %v", v)
+	}
+}
+
+func TestBinaryErrors(t *testing.T) {
+	var unsupported syncmapt.Map[string, []string]
+	unsupported.Store("a", []string{"x"})
+	if _, err := unsupported.MarshalBinary(); err == nil {
+		t.Error("MarshalBinary of []string values succeeded")
+	}
+
+	data, err := syncmapttest.New(map[string]int{"a": 1, "b": 2}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	future := append([]byte{99}, data[1:]...)
+	for name, data := range map[string][]byte{
+		"empty":     nil,
+		"version":   future,
+		"truncated": data[:len(data)-1],
+		"trailing":  append(data, 0),
+	} {
+		m := syncmapttest.New(map[string]int{"z": 26})
+		if err := m.UnmarshalBinary(data); err == nil {
+			t.Errorf("UnmarshalBinary(%s) succeeded", name)
+		}
+		syncmapttest.RequireEqual(t, m, map[string]int{"z": 26})
+	}
+
+	var wide syncmapt.Map[int64, int]
+	if err := wide.UnmarshalBinary(data); err == nil {
+		t.Error("UnmarshalBinary decoded one-byte string keys as int64")
+	}
+}
+
+func BenchmarkMarshalBinary(b *testing.B) {
+	var m syncmapt.Map[int, int64]
+	for i := 0; i < 100_000; i++ {
+		m.Store(i, int64(i)*3)
+	}
+	b.ReportAllocs()
+	var data []byte
+	for b.Loop() {
+		data, _ = m.AppendBinary(data[:0])
+	}
+	b.ReportMetric(float64(len(data))/100_000, "bytes/entry")
+}
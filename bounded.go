@@ -0,0 +1,344 @@
+package syncmapt
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrFull is returned when a value cannot be stored in a BoundedMap that is
+// full and configured to reject or block instead of evicting, or whose
+// EvictionPolicy offers no key of the map to evict.
+var ErrFull = errors.New("syncmapt: map is full")
+
+// WithMaxEntries returns an Option that caps the number of entries of a
+// BoundedMap at n. A non-positive n means no cap, which is the default.
+func WithMaxEntries[K comparable, V any](n int) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.maxEntries = n
+	}
+}
+
+// OnFull is what a BoundedMap does when a new key is stored while it holds
+// the maximum number of entries.
+type OnFull int
+
+const (
+	// EvictOnFull evicts an entry chosen by the map's EvictionPolicy to
+	// make room, or fails the store with ErrFull if the policy returns no
+	// key of the map. It is the default.
+	EvictOnFull OnFull = iota
+	// RejectOnFull fails the store with ErrFull.
+	RejectOnFull
+	// BlockOnFull waits until an entry is deleted.
+	BlockOnFull
+)
+
+// WithOnFull returns an Option that sets what a BoundedMap does when a new
+// key is stored while it is full.
+func WithOnFull[K comparable, V any](f OnFull) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.onFull = f
+	}
+}
+
+// A BoundedMap is a concurrent map that holds at most a fixed number of
+// entries, set with WithMaxEntries. When a new key is stored in a full map,
+// it evicts an entry, by default the least recently used one, or, as
+// configured by WithOnFull, rejects the new entry or blocks until there is
+// room for it. Load, LoadOrStore and Store count as uses of an entry; Range
+// does not.
+//
+// All operations take an internal lock, since even a Load updates the order
+// of use. Of the Options, WithMaxEntries and WithOnFull set the bound,
+// WithEvictionPolicy chooses the entries to evict, WithKeyTransform
+// canonicalizes keys, and WithCapacity sizes the map.
+//
+// The zero BoundedMap is empty, has no bound, and is ready for use. A
+// BoundedMap must not be copied after first use.
+type BoundedMap[K comparable, V any] struct {
+	mu     sync.Mutex
+	cfg    *config[K, V]
+	m      map[K]V
+	policy EvictionPolicy[K]
+	stats  Stats         // counted with mu held
+	freed  chan struct{} // closed and replaced when an entry is removed
+	closed bool          // set by Close
+
+	// hooks are the callbacks registered with OnStore, OnDelete and OnEvict.
+	// They are copied on write, under mu.
+	hooks atomic.Pointer[hooks[K, V]]
+}
+
+var _ Interface[string, any] = (*BoundedMap[string, any])(nil)
+
+// NewBoundedMap returns an empty BoundedMap configured by opts.
+func NewBoundedMap[K comparable, V any](opts ...Option[K, V]) *BoundedMap[K, V] {
+	m := &BoundedMap[K, V]{cfg: newConfig(opts)}
+	m.initLocked()
+	return m
+}
+
+// initLocked allocates the map and eviction policy of a zero BoundedMap.
+// m.cfg is only set by NewBoundedMap, as key reads it without the lock; a nil
+// m.cfg means no bound.
+func (m *BoundedMap[K, V]) initLocked() {
+	if m.m != nil {
+		return
+	}
+	capacity := 0
+	if m.cfg != nil {
+		capacity = m.cfg.capacity
+		m.policy = m.cfg.eviction
+	}
+	m.m = make(map[K]V, capacity)
+	if m.policy == nil {
+		m.policy = NewLRUPolicy[K]()
+	}
+	m.freed = make(chan struct{})
+}
+
+func (m *BoundedMap[K, V]) key(k K) K {
+	if m.cfg == nil || m.cfg.keyTransform == nil {
+		return k
+	}
+	return m.cfg.keyTransform(k)
+}
+
+// Load returns the value stored in the map for a key, or the zero value if no
+// value is present. The ok result indicates whether value was found in the
+// map.
+func (m *BoundedMap[K, V]) Load(key K) (value V, ok bool) {
+	key = m.key(key)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.initLocked()
+	m.stats.Loads++
+	if value, ok = m.m[key]; ok {
+		m.stats.Hits++
+		m.policy.Accessed(key)
+	}
+	return value, ok
+}
+
+// Store sets the value for a key. It is StoreContext without a deadline, but
+// ignores the error: in a full map created WithOnFull(RejectOnFull), a value
+// for a new key is dropped.
+func (m *BoundedMap[K, V]) Store(key K, value V) {
+	m.StoreContext(context.Background(), key, value)
+}
+
+// StoreContext sets the value for a key. If the key is new and the map is
+// full, it evicts an entry, returns ErrFull, or waits for room until ctx is
+// done and then returns ctx.Err(), depending on the map's OnFull setting. If
+// the map is closed, it stores nothing and returns ErrClosed.
+func (m *BoundedMap[K, V]) StoreContext(ctx context.Context, key K, value V) error {
+	key = m.key(key)
+	_, _, err := m.store(ctx, key, value, true)
+	return err
+}
+
+// LoadOrStore returns the existing value for the key if present. Otherwise,
+// it stores and returns the given value. The loaded result is true if the
+// value was loaded, false if stored. Like Store, it drops a value for a new
+// key that a full map rejects.
+func (m *BoundedMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	actual, loaded, _ = m.store(context.Background(), m.key(key), value, false)
+	return actual, loaded
+}
+
+// store stores value for key, or only loads the current value if overwrite is
+// false and there is one.
+func (m *BoundedMap[K, V]) store(ctx context.Context, key K, value V, overwrite bool) (actual V, loaded bool, err error) {
+	m.mu.Lock()
+	m.initLocked()
+	actual, loaded, evicted, err := m.storeLocked(ctx, key, value, overwrite)
+	m.mu.Unlock()
+
+	if h := m.hooks.Load(); h != nil {
+		for _, p := range evicted {
+			h.run(h.evict, p.Key, p.Value)
+		}
+		if !loaded && err == nil {
+			h.run(h.store, key, value)
+		}
+	}
+	return actual, loaded, err
+}
+
+// storeLocked is store with m.mu held, which it releases while blocking. It
+// also returns the entries it evicted.
+func (m *BoundedMap[K, V]) storeLocked(ctx context.Context, key K, value V, overwrite bool) (actual V, loaded bool, evicted []Pair[K, V], err error) {
+	for {
+		if m.closed {
+			actual, loaded = m.m[key]
+			return actual, loaded, evicted, ErrClosed
+		}
+		if old, ok := m.m[key]; ok {
+			m.policy.Accessed(key)
+			if !overwrite {
+				return old, true, evicted, nil
+			}
+			m.m[key] = value
+			m.stats.Stores++
+			return value, false, evicted, nil
+		}
+		if m.cfg == nil || m.cfg.maxEntries <= 0 || len(m.m) < m.cfg.maxEntries {
+			break
+		}
+		switch m.cfg.onFull {
+		case RejectOnFull:
+			return actual, false, evicted, ErrFull
+		case BlockOnFull:
+			freed := m.freed
+			m.mu.Unlock()
+			select {
+			case <-freed:
+				m.mu.Lock()
+				continue
+			case <-ctx.Done():
+				m.mu.Lock()
+				return actual, false, evicted, ctx.Err()
+			}
+		}
+		candidates := m.policy.Candidates(1)
+		if len(candidates) == 0 {
+			return actual, false, evicted, ErrFull
+		}
+		victim := candidates[0]
+		if _, ok := m.m[victim]; !ok {
+			// Evicting a key that is not in the map would make no room.
+			return actual, false, evicted, ErrFull
+		}
+		evicted = append(evicted, Pair[K, V]{victim, m.m[victim]})
+		delete(m.m, victim)
+		m.policy.Removed(victim)
+		m.stats.Evictions++
+	}
+	m.m[key] = value
+	m.policy.Added(key)
+	m.stats.Stores++
+	return value, false, evicted, nil
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if
+// any. The loaded result reports whether the key was present.
+func (m *BoundedMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	key = m.key(key)
+	m.mu.Lock()
+	m.initLocked()
+	if m.closed {
+		m.mu.Unlock()
+		return value, false
+	}
+	if value, loaded = m.m[key]; loaded {
+		delete(m.m, key)
+		m.policy.Removed(key)
+		m.stats.Deletes++
+		close(m.freed)
+		m.freed = make(chan struct{})
+	}
+	m.mu.Unlock()
+	if h := m.hooks.Load(); h != nil && loaded {
+		h.run(h.delete, key, value)
+	}
+	return value, loaded
+}
+
+// Delete deletes the value for a key.
+func (m *BoundedMap[K, V]) Delete(key K) {
+	m.LoadAndDelete(key)
+}
+
+// Range calls f sequentially for each key and value present in the map. If f
+// returns false, range stops the iteration.
+//
+// Range copies the entries with the lock held and calls f without it, so f
+// may call any method of m. The entries are those of a single point in time.
+func (m *BoundedMap[K, V]) Range(f func(key K, value V) bool) {
+	m.mu.Lock()
+	entries := make([]Pair[K, V], 0, len(m.m))
+	for k, v := range m.m {
+		entries = append(entries, Pair[K, V]{k, v})
+	}
+	m.mu.Unlock()
+	for _, e := range entries {
+		if !f(e.Key, e.Value) {
+			return
+		}
+	}
+}
+
+// Len returns the number of entries in the map.
+func (m *BoundedMap[K, V]) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.m)
+}
+
+// Close closes the map and wakes the stores blocked waiting for room, which
+// then return ErrClosed. Afterwards the contents of the map can still be
+// read, but stores and deletions do nothing: StoreContext returns ErrClosed,
+// LoadOrStore returns the current value for the key as Load does, and
+// LoadAndDelete reports that nothing was deleted. Close is idempotent and
+// always returns nil.
+func (m *BoundedMap[K, V]) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.initLocked()
+	if !m.closed {
+		m.closed = true
+		close(m.freed)
+	}
+	return nil
+}
+
+// Evicted returns the number of entries the map has evicted to make room for
+// new ones.
+func (m *BoundedMap[K, V]) Evicted() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stats.Evictions
+}
+
+// Stats returns the operation counts of the map since it was created. A
+// BoundedMap always counts its operations, with or without WithStats.
+func (m *BoundedMap[K, V]) Stats() Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stats
+}
+
+// EvictionCandidates returns up to n keys in the order the map would evict
+// them, the key to evict next first, without evicting them. It returns nil if
+// the map is empty or n is not positive.
+func (m *BoundedMap[K, V]) EvictionCandidates(n int) []K {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if n <= 0 || len(m.m) == 0 {
+		return nil
+	}
+	return m.policy.Candidates(n)
+}
+
+// OnStore registers f to be called after every store of a value for a key by
+// Store, StoreContext or LoadOrStore. f is called with the stored value,
+// without the map's lock held, by the goroutine that stored it, and possibly
+// concurrently with other hooks; it may call methods of m.
+func (m *BoundedMap[K, V]) OnStore(f func(key K, value V)) {
+	addHook(&m.hooks, &m.mu, storeHooks[K, V], f)
+}
+
+// OnDelete registers f to be called after every deletion of a value by Delete
+// or LoadAndDelete, in the same way as OnStore. A value replaced by a store
+// is not reported, nor is an evicted one.
+func (m *BoundedMap[K, V]) OnDelete(f func(key K, value V)) {
+	addHook(&m.hooks, &m.mu, deleteHooks[K, V], f)
+}
+
+// OnEvict registers f to be called after every eviction of a value to make
+// room for a new one, in the same way as OnStore.
+func (m *BoundedMap[K, V]) OnEvict(f func(key K, value V)) {
+	addHook(&m.hooks, &m.mu, evictHooks[K, V], f)
+}
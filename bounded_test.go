@@ -0,0 +1,111 @@
+package syncmapt_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/holdno/syncmapt"
+	"github.com/holdno/syncmapt/syncmapttest"
+)
+
+func TestBoundedMap(t *testing.T) {
+	t.Run("Conformance", func(t *testing.T) {
+		syncmapttest.RunConformance(t, func() syncmapt.Interface[string, string] {
+			return syncmapt.NewBoundedMap[string, string]()
+		})
+	})
+
+	m := syncmapt.NewBoundedMap(syncmapt.WithMaxEntries[string, int](3))
+	m.Store("a", 1)
+	m.Store("b", 2)
+	m.Store("c", 3)
+
+	// Using a and c leaves b as the least recently used entry.
+	m.Load("a")
+	m.LoadOrStore("c", 30)
+	m.Store("d", 4)
+	syncmapttest.RequireEqual(t, m, map[string]int{"a": 1, "c": 3, "d": 4})
+
+	// Overwriting does not evict.
+	m.Store("a", 10)
+	m.Store("e", 5)
+	syncmapttest.RequireEqual(t, m, map[string]int{"a": 10, "d": 4, "e": 5})
+	if n := m.Evicted(); n != 2 {
+		t.Fatalf("Evicted() = %d, want 2", n)
+	}
+
+	m.Delete("d")
+	m.Store("f", 6)
+	syncmapttest.RequireEqual(t, m, map[string]int{"a": 10, "e": 5, "f": 6})
+	if n := m.Evicted(); n != 2 {
+		t.Fatalf("Evicted() after a Delete = %d, want 2", n)
+	}
+	want := syncmapt.Stats{Loads: 1, Hits: 1, Stores: 7, Deletes: 1, Evictions: 2}
+	if s := m.Stats(); s != want {
+		t.Fatalf("Stats() = %+v, want %+v", s, want)
+	}
+}
+
+func TestBoundedMapUnbounded(t *testing.T) {
+	var m syncmapt.BoundedMap[int, int]
+	for i := 0; i < 100; i++ {
+		m.Store(i, i)
+	}
+	if m.Len() != 100 || m.Evicted() != 0 {
+		t.Fatalf("zero BoundedMap: Len() = %d, Evicted() = %d; want 100, 0", m.Len(), m.Evicted())
+	}
+}
+
+func TestBoundedMapOnFull(t *testing.T) {
+	t.Run("reject", func(t *testing.T) {
+		m := syncmapt.NewBoundedMap(
+			syncmapt.WithMaxEntries[string, int](1),
+			syncmapt.WithOnFull[string, int](syncmapt.RejectOnFull),
+		)
+		m.Store("a", 1)
+		if err := m.StoreContext(context.Background(), "b", 2); !errors.Is(err, syncmapt.ErrFull) {
+			t.Fatalf("StoreContext into a full map = %v, want ErrFull", err)
+		}
+		if err := m.StoreContext(context.Background(), "a", 10); err != nil {
+			t.Fatalf("StoreContext of an existing key = %v", err)
+		}
+		m.Store("c", 3)
+		syncmapttest.RequireEqual(t, m, map[string]int{"a": 10})
+	})
+
+	t.Run("block", func(t *testing.T) {
+		m := syncmapt.NewBoundedMap(
+			syncmapt.WithMaxEntries[string, int](1),
+			syncmapt.WithOnFull[string, int](syncmapt.BlockOnFull),
+		)
+		m.Store("a", 1)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if err := m.StoreContext(ctx, "b", 2); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("StoreContext into a full map = %v, want DeadlineExceeded", err)
+		}
+
+		done := make(chan error)
+		go func() { done <- m.StoreContext(context.Background(), "b", 2) }()
+		select {
+		case err := <-done:
+			t.Fatalf("StoreContext returned %v before there was room", err)
+		case <-time.After(10 * time.Millisecond):
+		}
+		m.Delete("a")
+		if err := <-done; err != nil {
+			t.Fatalf("StoreContext after a Delete = %v", err)
+		}
+		syncmapttest.RequireEqual(t, m, map[string]int{"b": 2})
+
+		go func() { done <- m.StoreContext(context.Background(), "c", 3) }()
+		time.Sleep(10 * time.Millisecond)
+		m.Close()
+		if err := <-done; !errors.Is(err, syncmapt.ErrClosed) {
+			t.Fatalf("blocked StoreContext after Close = %v, want ErrClosed", err)
+		}
+	})
+}
@@ -0,0 +1,216 @@
+package syncmapt
+
+import "sync/atomic"
+
+// A BytesMap is a concurrent map of byte slices that can keep its values
+// compressed in memory, see WithValueCompression, and spill the coldest of
+// them to disk, see WithSpill. Otherwise it behaves like a Map[K, []byte]: it
+// stores the slices it is given, so they must not be modified afterwards, and
+// the slices it returns must not be modified either.
+//
+// Of the Options, WithValueCompression enables compression, WithSpill enables
+// spilling, WithKeyTransform canonicalizes keys, WithCapacity sizes the map,
+// and WithClock sets the time source used to measure compression costs.
+//
+// The zero BytesMap is empty, stores values as they are, and is ready for
+// use. A BytesMap must not be copied after first use.
+type BytesMap[K comparable] struct {
+	m     Map[K, packed]
+	cfg   *config[K, []byte]
+	spill *spiller[K] // nil unless the map was created WithSpill
+
+	compressed, uncompressed, decompressed atomic.Int64
+	in, out                                atomic.Int64
+	compressNanos, decompressNanos         atomic.Int64
+}
+
+var _ Interface[string, []byte] = (*BytesMap[string])(nil)
+
+// A packed value is stored in a BytesMap. It holds the value, possibly
+// compressed, or the location of the value in the spill file.
+type packed struct {
+	b          []byte
+	compressed bool
+
+	// In a map created WithSpill, hot records that a resident value was read
+	// since the spiller last considered it, and a spilled value is n bytes
+	// at off in the spill file.
+	hot     *atomic.Bool
+	spilled bool
+	off     int64
+	n       int
+}
+
+// NewBytesMap returns an empty BytesMap configured by opts.
+func NewBytesMap[K comparable](opts ...Option[K, []byte]) *BytesMap[K] {
+	m := &BytesMap[K]{cfg: newConfig(opts)}
+	if m.cfg.capacity > 0 {
+		m.m.dirty = make(map[K]*entry[packed], m.cfg.capacity)
+	}
+	if m.cfg.spillLimit > 0 {
+		m.spill = &spiller[K]{dir: m.cfg.spillDir, limit: m.cfg.spillLimit, queued: make(map[K]struct{})}
+	}
+	return m
+}
+
+func (m *BytesMap[K]) key(k K) K {
+	if m.cfg == nil || m.cfg.keyTransform == nil {
+		return k
+	}
+	return m.cfg.keyTransform(k)
+}
+
+// Load returns the value stored in the map for key, or nil if no value is
+// present. The ok result indicates whether value was found in the map.
+func (m *BytesMap[K]) Load(key K) (value []byte, ok bool) {
+	key = m.key(key)
+	p, ok := m.m.Load(key)
+	if !ok {
+		return nil, false
+	}
+	if p.spilled {
+		if p, ok = m.spill.promote(m, key); !ok {
+			return nil, false
+		}
+	} else if p.hot != nil && !p.hot.Load() {
+		p.hot.Store(true)
+	}
+	return m.unpack(p), true
+}
+
+// Store sets the value for a key, compressing it if it is large enough.
+func (m *BytesMap[K]) Store(key K, value []byte) {
+	if m.m.Closed() {
+		return
+	}
+	key = m.key(key)
+	p := m.pack(value)
+	if m.spill == nil {
+		m.m.Store(key, p)
+		return
+	}
+	m.spill.mu.Lock()
+	m.spill.store(m, key, p)
+	m.spill.mu.Unlock()
+}
+
+// LoadOrStore returns the existing value for the key if present. Otherwise,
+// it stores and returns the given value. The loaded result is true if the
+// value was loaded, false if stored.
+func (m *BytesMap[K]) LoadOrStore(key K, value []byte) (actual []byte, loaded bool) {
+	if v, ok := m.Load(key); ok || m.m.Closed() {
+		return v, ok
+	}
+	key = m.key(key)
+	p := m.pack(value)
+	if m.spill == nil {
+		if p, loaded := m.m.LoadOrStore(key, p); loaded {
+			return m.unpack(p), true
+		}
+		return value, false
+	}
+
+	s := m.spill
+	s.mu.Lock()
+	if _, ok := m.m.Load(key); ok {
+		p, _ := s.promoteLocked(m, key)
+		s.mu.Unlock()
+		return m.unpack(p), true
+	}
+	s.store(m, key, p)
+	s.mu.Unlock()
+	return value, false
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if
+// any. The loaded result reports whether the key was present.
+func (m *BytesMap[K]) LoadAndDelete(key K) (value []byte, loaded bool) {
+	if m.m.Closed() {
+		return nil, false
+	}
+	key = m.key(key)
+	if m.spill == nil {
+		p, loaded := m.m.LoadAndDelete(key)
+		if !loaded {
+			return nil, false
+		}
+		return m.unpack(p), true
+	}
+
+	s := m.spill
+	s.mu.Lock()
+	p, loaded := m.m.LoadAndDelete(key)
+	if loaded {
+		s.forget(p)
+		if p.spilled {
+			p = s.read(p)
+		}
+		s.compact(m)
+	}
+	s.mu.Unlock()
+	if !loaded {
+		return nil, false
+	}
+	return m.unpack(p), true
+}
+
+// Delete deletes the value for a key.
+func (m *BytesMap[K]) Delete(key K) {
+	if m.m.Closed() {
+		return
+	}
+	key = m.key(key)
+	if m.spill == nil {
+		m.m.Delete(key)
+		return
+	}
+	m.spill.mu.Lock()
+	if p, loaded := m.m.LoadAndDelete(key); loaded {
+		m.spill.forget(p)
+		m.spill.compact(m)
+	}
+	m.spill.mu.Unlock()
+}
+
+// Range calls f sequentially for each key and value present in the map, with
+// the same consistency as Map.Range. If f returns false, range stops the
+// iteration. Spilled values are read from disk without being brought back
+// into memory.
+func (m *BytesMap[K]) Range(f func(key K, value []byte) bool) {
+	m.m.Range(func(k K, p packed) bool {
+		if p.spilled {
+			var ok bool
+			if p, ok = m.spill.load(m, k); !ok {
+				return true
+			}
+		}
+		return f(k, m.unpack(p))
+	})
+}
+
+// Len returns the number of entries in the map.
+func (m *BytesMap[K]) Len() int {
+	return m.m.Len()
+}
+
+// Close closes the map as Map.Close does: afterwards its contents can still
+// be read, but the methods that would modify it do nothing, and LoadAndDelete
+// reports that nothing was deleted. For a map created WithSpill, Close also
+// deletes the spill file, together with the values in it, which are deleted
+// from the map. Close is idempotent and returns any error deleting the spill
+// file.
+func (m *BytesMap[K]) Close() error {
+	if m.spill == nil {
+		return m.m.Close()
+	}
+	m.spill.mu.Lock()
+	defer m.spill.mu.Unlock()
+	m.m.Range(func(k K, p packed) bool {
+		if p.spilled {
+			m.m.Delete(k)
+		}
+		return true
+	})
+	m.m.Close()
+	return m.spill.close()
+}
@@ -0,0 +1,44 @@
+package syncmapt_test
+
+import (
+	"testing"
+
+	"github.com/holdno/syncmapt"
+	"github.com/holdno/syncmapt/syncmapttest"
+)
+
+// stringBytesMap adapts a BytesMap to the string values RunConformance uses.
+type stringBytesMap struct {
+	m *syncmapt.BytesMap[string]
+}
+
+func (s stringBytesMap) Load(key string) (string, bool) {
+	v, ok := s.m.Load(key)
+	return string(v), ok
+}
+
+func (s stringBytesMap) Store(key, value string) { s.m.Store(key, []byte(value)) }
+
+func (s stringBytesMap) LoadOrStore(key, value string) (string, bool) {
+	v, loaded := s.m.LoadOrStore(key, []byte(value))
+	return string(v), loaded
+}
+
+func (s stringBytesMap) LoadAndDelete(key string) (string, bool) {
+	v, loaded := s.m.LoadAndDelete(key)
+	return string(v), loaded
+}
+
+func (s stringBytesMap) Delete(key string) { s.m.Delete(key) }
+
+func (s stringBytesMap) Range(f func(key, value string) bool) {
+	s.m.Range(func(k string, v []byte) bool { return f(k, string(v)) })
+}
+
+func (s stringBytesMap) Len() int { return s.m.Len() }
+
+func TestBytesMapConformance(t *testing.T) {
+	syncmapttest.RunConformance(t, func() syncmapt.Interface[string, string] {
+		return stringBytesMap{syncmapt.NewBytesMap[string]()}
+	})
+}
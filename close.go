@@ -0,0 +1,59 @@
+package syncmapt
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrClosed is returned by the methods of a Map that has been closed that
+// block or report errors.
+var ErrClosed = errors.New("syncmapt: map is closed")
+
+// Every variant of Map can be closed in the same way.
+var (
+	_ io.Closer = (*Map[string, any])(nil)
+	_ io.Closer = (*ShardedMap[string, any])(nil)
+	_ io.Closer = (*BoundedMap[string, any])(nil)
+	_ io.Closer = (*OrderedMap[string, any])(nil)
+	_ io.Closer = (*BiMap[string, int])(nil)
+	_ io.Closer = (*COWMap[string, any])(nil)
+	_ io.Closer = (*HashMap[string, any])(nil)
+	_ io.Closer = (*ExpiringMap[string, any])(nil)
+	_ io.Closer = (*WALMap[string, any])(nil)
+	_ io.Closer = (*BytesMap[string])(nil)
+)
+
+// Close releases the resources held by the Map and wakes every goroutine
+// blocked in WaitForKey or WaitForValue, which then return ErrClosed.
+//
+// After Close, the contents of the Map can still be read, but they no longer
+// change: operations that would modify the Map do nothing instead. Those that
+// report a change, such as Swap, LoadAndDelete, CompareAndSwap, Pop, Move and
+// DeleteFunc, report that nothing was swapped, deleted or moved. Those that
+// also load, namely LoadOrStore, LoadOrStoreFunc, LoadOrCompute, Compute and
+// WithLock, return the value currently stored for the key as Load does,
+// without calling any function they are given; LoadOrCompute returns
+// ErrClosed if there is none. Txn returns ErrClosed. Key locks can still be
+// taken with LockKey.
+//
+// Close is idempotent and always returns nil; the error result allows a Map
+// to be used as an io.Closer.
+func (m *Map[K, V]) Close() error {
+	if !m.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+	m.waitMu.Lock()
+	for key, w := range m.waiters {
+		close(w.ch)
+		delete(m.waiters, key)
+	}
+	m.nwaiters.Store(0)
+	m.waitMu.Unlock()
+	return nil
+}
+
+// Closed reports whether Close has been called, for example to report an
+// error for a write that, once the Map is closed, does nothing.
+func (m *Map[K, V]) Closed() bool {
+	return m.closed.Load()
+}
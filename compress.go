@@ -0,0 +1,137 @@
+package syncmapt
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"sync"
+	"time"
+)
+
+// A Codec compresses and decompresses values for WithValueCompression.
+// Both methods append their output to dst and return the extended slice, and
+// must be safe for concurrent use.
+type Codec interface {
+	Compress(dst, src []byte) ([]byte, error)
+	Decompress(dst, src []byte) ([]byte, error)
+}
+
+// NewFlateCodec returns a Codec that compresses with DEFLATE at the given
+// level, which is one of the levels accepted by flate.NewWriter.
+func NewFlateCodec(level int) (Codec, error) {
+	if _, err := flate.NewWriter(io.Discard, level); err != nil {
+		return nil, err
+	}
+	return &flateCodec{level: level}, nil
+}
+
+type flateCodec struct {
+	level   int
+	writers sync.Pool // of *flate.Writer
+}
+
+func (c *flateCodec) Compress(dst, src []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+	w, _ := c.writers.Get().(*flate.Writer)
+	if w == nil {
+		w, _ = flate.NewWriter(buf, c.level)
+	} else {
+		w.Reset(buf)
+	}
+	defer c.writers.Put(w)
+	if _, err := w.Write(src); err != nil {
+		return dst, err
+	}
+	if err := w.Close(); err != nil {
+		return dst, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *flateCodec) Decompress(dst, src []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+	r := flate.NewReader(bytes.NewReader(src))
+	defer r.Close()
+	if _, err := buf.ReadFrom(r); err != nil {
+		return dst, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WithValueCompression returns an Option that makes a BytesMap store values
+// of at least threshold bytes compressed by codec, and decompress them when
+// they are read. Values that do not shrink are stored as they are.
+func WithValueCompression[K comparable](codec Codec, threshold int) Option[K, []byte] {
+	return func(c *config[K, []byte]) {
+		c.codec = codec
+		c.compressAbove = threshold
+	}
+}
+
+// CompressionStats reports the work done by a BytesMap to compress its
+// values.
+type CompressionStats struct {
+	Compressed   int64 // values stored compressed
+	Uncompressed int64 // values stored as they are, because they were small or incompressible
+	Decompressed int64 // reads that decompressed a value
+
+	// In and Out are the total sizes of the values stored compressed, before
+	// and after compression.
+	In, Out int64
+
+	CompressTime, DecompressTime time.Duration
+}
+
+// Ratio returns In/Out, the factor by which compression shrank the values
+// stored compressed, or 0 if there were none.
+func (s CompressionStats) Ratio() float64 {
+	if s.Out == 0 {
+		return 0
+	}
+	return float64(s.In) / float64(s.Out)
+}
+
+// Stats returns the compression statistics accumulated since m was created.
+func (m *BytesMap[K]) Stats() CompressionStats {
+	return CompressionStats{
+		Compressed:     m.compressed.Load(),
+		Uncompressed:   m.uncompressed.Load(),
+		Decompressed:   m.decompressed.Load(),
+		In:             m.in.Load(),
+		Out:            m.out.Load(),
+		CompressTime:   time.Duration(m.compressNanos.Load()),
+		DecompressTime: time.Duration(m.decompressNanos.Load()),
+	}
+}
+
+func (m *BytesMap[K]) pack(v []byte) packed {
+	if m.cfg == nil || m.cfg.codec == nil || len(v) < m.cfg.compressAbove {
+		return packed{b: v}
+	}
+	start := m.cfg.clock.Now()
+	c, err := m.cfg.codec.Compress(nil, v)
+	m.compressNanos.Add(int64(m.cfg.clock.Now().Sub(start)))
+	if err != nil || len(c) >= len(v) {
+		m.uncompressed.Add(1)
+		return packed{b: v}
+	}
+	m.compressed.Add(1)
+	m.in.Add(int64(len(v)))
+	m.out.Add(int64(len(c)))
+	return packed{b: c, compressed: true}
+}
+
+func (m *BytesMap[K]) unpack(p packed) []byte {
+	if !p.compressed {
+		return p.b
+	}
+	start := m.cfg.clock.Now()
+	v, err := m.cfg.codec.Decompress(nil, p.b)
+	m.decompressNanos.Add(int64(m.cfg.clock.Now().Sub(start)))
+	if err != nil {
+		// The map only holds data produced by the codec's own Compress.
+		panic("syncmapt: decompressing stored value: " + err.Error())
+	}
+	m.decompressed.Add(1)
+	return v
+}
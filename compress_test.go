@@ -0,0 +1,79 @@
+package syncmapt_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+)
+
+func TestBytesMapCompression(t *testing.T) {
+	codec, err := syncmapt.NewFlateCodec(flate.BestSpeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := syncmapt.NewBytesMap(syncmapt.WithValueCompression[string](codec, 64))
+
+	blob := bytes.Repeat([]byte(`{"name":"value"},`), 100)
+	small := []byte("tiny")
+	m.Store("blob", blob)
+	m.Store("small", small)
+	for k, want := range map[string][]byte{"blob": blob, "small": small} {
+		if got, ok := m.Load(k); !ok || !bytes.Equal(got, want) {
+			t.Errorf("Load(%q) = %q, %v; want %q, true", k, got, ok, want)
+		}
+	}
+	if got, loaded := m.LoadOrStore("blob", nil); !loaded || !bytes.Equal(got, blob) {
+		t.Errorf("LoadOrStore(blob) = %d bytes, %v; want the stored blob, true", len(got), loaded)
+	}
+	m.Range(func(k string, v []byte) bool {
+		if k == "blob" && !bytes.Equal(v, blob) {
+			t.Errorf("Range passed %d bytes for blob, want %d", len(v), len(blob))
+		}
+		return true
+	})
+
+	s := m.Stats()
+	if s.Compressed != 1 || s.Uncompressed != 0 || s.In != int64(len(blob)) {
+		t.Errorf("Stats() = %+v; want 1 compressed value of %d bytes", s, len(blob))
+	}
+	if s.Ratio() < 5 {
+		t.Errorf("Ratio() = %.1f, want at least 5 for repetitive JSON", s.Ratio())
+	}
+	if s.Decompressed != 3 {
+		t.Errorf("Decompressed = %d, want 3", s.Decompressed)
+	}
+
+	if v, loaded := m.LoadAndDelete("blob"); !loaded || !bytes.Equal(v, blob) {
+		t.Errorf("LoadAndDelete(blob) = %d bytes, %v", len(v), loaded)
+	}
+	if m.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", m.Len())
+	}
+}
+
+func TestBytesMapIncompressible(t *testing.T) {
+	codec, _ := syncmapt.NewFlateCodec(flate.DefaultCompression)
+	m := syncmapt.NewBytesMap(syncmapt.WithValueCompression[int](codec, 1))
+	random := []byte{0x8f, 0x12, 0xe4, 0x51, 0x09, 0xbb, 0x77, 0xc3}
+	m.Store(1, random)
+	if got, _ := m.Load(1); !bytes.Equal(got, random) {
+		t.Errorf("Load(1) = %x, want %x", got, random)
+	}
+	if s := m.Stats(); s.Compressed != 0 || s.Uncompressed != 1 {
+		t.Errorf("Stats() = %+v; want the value stored uncompressed", s)
+	}
+
+	var zero syncmapt.BytesMap[int]
+	zero.Store(1, random)
+	if got, ok := zero.Load(1); !ok || &got[0] != &random[0] {
+		t.Error("zero BytesMap did not store the value as given")
+	}
+}
+
+func TestNewFlateCodecInvalidLevel(t *testing.T) {
+	if _, err := syncmapt.NewFlateCodec(42); err == nil {
+		t.Error("NewFlateCodec(42) succeeded")
+	}
+}
@@ -0,0 +1,100 @@
+package syncmapt
+
+import "sync/atomic"
+
+// ToMap returns a plain Go map holding the contents of the map.
+//
+// For a map created WithSnapshots, ToMap copies a Snapshot, so the result is
+// the contents of a single point in time. Otherwise ToMap has the consistency
+// of Range: no key is copied twice, but a key stored or deleted by another
+// goroutine during the call may or may not be reflected, so the result may be
+// a state the map was never in.
+func (m *Map[K, V]) ToMap() map[K]V {
+	contents := make(map[K]V, m.Len())
+	m.rangeSnapshot(func(k K, v V) bool {
+		contents[k] = v
+		return true
+	})
+	return contents
+}
+
+// pairs returns the entries of m, copied with the consistency of ToMap.
+func (m *Map[K, V]) pairs() []Pair[K, V] {
+	pairs := make([]Pair[K, V], 0, m.Len())
+	m.rangeSnapshot(func(k K, v V) bool {
+		pairs = append(pairs, Pair[K, V]{k, v})
+		return true
+	})
+	return pairs
+}
+
+// rangeSnapshot calls f sequentially for each entry of a Snapshot of m if it
+// was created WithSnapshots, and as Range does otherwise. If f returns
+// false, rangeSnapshot stops the iteration.
+func (m *Map[K, V]) rangeSnapshot(f func(key K, value V) bool) {
+	if m.ver.on {
+		m.Snapshot().Range(f)
+		return
+	}
+	m.Range(f)
+}
+
+// adopt puts pairs, whose keys must have distinct transformed forms, in the
+// storage of m, which must be a new Map.
+func (m *Map[K, V]) adopt(pairs []Pair[K, V]) {
+	m.dirty = nil
+	fresh := make(map[K]*entry[V], len(pairs))
+	n := new(atomic.Int64)
+	for _, p := range pairs {
+		fresh[p.Key] = newEntry(&m.ver, n, p.Value)
+	}
+	n.Store(int64(len(fresh)))
+	m.count.Store(n)
+	m.read.Store(readOnly[K, V]{m: fresh})
+}
+
+// NewFromMap returns a Map configured by opts and holding a copy of contents.
+// The entries go straight into the internal storage that lock-free lookups
+// use, sized for len(contents), instead of being stored one by one.
+//
+// If a key transform maps several keys of contents to the same key, which
+// of their values is kept is unspecified.
+func NewFromMap[K comparable, V any](contents map[K]V, opts ...Option[K, V]) *Map[K, V] {
+	m := New(opts...)
+	m.fill(contents)
+	return m
+}
+
+// fill puts a copy of contents in the storage of m, which must be a new Map.
+func (m *Map[K, V]) fill(contents map[K]V) {
+	m.dirty = nil // New may have sized it for the first stores
+	fresh, n := m.storage(contents)
+	m.count.Store(n)
+	m.read.Store(readOnly[K, V]{m: fresh})
+}
+
+// storage returns new read-only storage for m holding a copy of contents,
+// and the counter of its entries.
+func (m *Map[K, V]) storage(contents map[K]V) (map[K]*entry[V], *atomic.Int64) {
+	fresh := make(map[K]*entry[V], len(contents))
+	n := new(atomic.Int64)
+	for k, v := range contents {
+		fresh[m.key(k)] = newEntry(&m.ver, n, v)
+	}
+	n.Store(int64(len(fresh)))
+	return fresh, n
+}
+
+// Clone returns a new Map with the same configuration and contents as m. The
+// values are copied as with an assignment, so values that contain pointers
+// are shared between the two maps.
+//
+// Clone has the consistency of ToMap, and puts every entry of the clone in
+// the internal storage that lock-free lookups use. To read a consistent
+// state of a map that keeps changing without copying it, see Snapshot.
+func (m *Map[K, V]) Clone() *Map[K, V] {
+	c := &Map[K, V]{cfg: m.cfg}
+	c.ver.on = m.ver.on
+	c.adopt(m.pairs())
+	return c
+}
@@ -0,0 +1,76 @@
+package syncmapt_test
+
+import (
+	"maps"
+	"strings"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+)
+
+func TestToMap(t *testing.T) {
+	var m syncmapt.Map[string, int]
+	if got := m.ToMap(); got == nil || len(got) != 0 {
+		t.Fatalf("ToMap of the zero Map = %#v, want an empty map", got)
+	}
+
+	m.Store("a", 1)
+	m.Load("a") // promote a to the read map
+	m.Store("b", 2)
+	m.Store("c", 3)
+	m.Delete("c")
+	want := map[string]int{"a": 1, "b": 2}
+	got := m.ToMap()
+	if !maps.Equal(got, want) {
+		t.Fatalf("ToMap = %v, want %v", got, want)
+	}
+	got["z"] = 26
+	if _, ok := m.Load("z"); ok {
+		t.Fatal("modifying the result of ToMap changed the Map")
+	}
+}
+
+func TestNewFromMap(t *testing.T) {
+	contents := map[string]int{"a": 1, "B": 2}
+	m := syncmapt.NewFromMap(contents, syncmapt.WithKeyTransform[string, int](strings.ToLower))
+	contents["c"] = 3
+	if got, want := m.ToMap(), map[string]int{"a": 1, "b": 2}; !maps.Equal(got, want) {
+		t.Fatalf("NewFromMap contents = %v, want %v", got, want)
+	}
+	if v, ok := m.Load("A"); !ok || v != 1 {
+		t.Fatalf("Load(A) = %v, %v; want the key transform applied", v, ok)
+	}
+	m.Store("d", 4)
+	if m.Len() != 3 {
+		t.Fatalf("Len = %d after a Store, want 3", m.Len())
+	}
+	if m := syncmapt.NewFromMap[string, int](nil); m.Len() != 0 {
+		t.Fatal("NewFromMap(nil) is not empty")
+	}
+}
+
+func TestClone(t *testing.T) {
+	m := syncmapt.New(syncmapt.WithKeyTransform[string, int](strings.ToLower))
+	m.Store("a", 1)
+	m.Store("b", 2)
+
+	c := m.Clone()
+	m.Store("a", 10)
+	m.Delete("b")
+	c.Store("c", 3)
+	if got, want := c.ToMap(), map[string]int{"a": 1, "b": 2, "c": 3}; !maps.Equal(got, want) {
+		t.Fatalf("clone = %v, want %v", got, want)
+	}
+	if got, want := m.ToMap(), map[string]int{"a": 10}; !maps.Equal(got, want) {
+		t.Fatalf("original = %v, want %v", got, want)
+	}
+	if v, ok := c.Load("C"); !ok || v != 3 {
+		t.Fatalf("clone Load(C) = %v, %v; want the key transform kept", v, ok)
+	}
+
+	s := syncmapt.New(syncmapt.WithSnapshots[string, int]())
+	s.Store("a", 1)
+	if snap := s.Clone().Snapshot(); snap.Len() != 1 {
+		t.Fatal("clone of a map WithSnapshots does not support Snapshot")
+	}
+}
@@ -0,0 +1,157 @@
+package syncmapt
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// Number is the set of the numeric types a CounterMap can count in: the
+// integer and floating-point types, and types derived from them.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// A CounterMap is a concurrent map of numeric counters. Add and Inc update a
+// counter with a single atomic operation on its value, without replacing the
+// entry of its key, so counting into keys that exist takes no lock and
+// allocates nothing. Integer counters wrap around on overflow.
+//
+// A counter that is being deleted may miss increments made concurrently with
+// the Delete: they go to the deleted counter.
+//
+// The zero CounterMap is empty and ready for use. A CounterMap must not be
+// copied after first use.
+type CounterMap[K comparable, V Number] struct {
+	m Map[K, *counter]
+}
+
+// counter holds the bits of a counter value: the value itself, converted to
+// uint64, for integers, and the bits of its float64 conversion for floats.
+type counter struct {
+	bits atomic.Uint64
+}
+
+// NewCounterMap returns an empty CounterMap configured by opts. Of the
+// Options, WithKeyTransform canonicalizes keys and WithCapacity sizes the
+// map.
+func NewCounterMap[K comparable, V Number](opts ...Option[K, V]) *CounterMap[K, V] {
+	cfg := newConfig(opts)
+	var inner []Option[K, *counter]
+	if cfg.keyTransform != nil {
+		inner = append(inner, WithKeyTransform[K, *counter](cfg.keyTransform))
+	}
+	if cfg.capacity > 0 {
+		inner = append(inner, WithCapacity[K, *counter](cfg.capacity))
+	}
+	c := new(CounterMap[K, V])
+	c.m.configure(inner)
+	return c
+}
+
+// isFloat reports whether V is a floating-point type.
+func isFloat[V Number]() bool {
+	var one V = 1
+	return one/2 != 0
+}
+
+func toBits[V Number](v V) uint64 {
+	if isFloat[V]() {
+		return math.Float64bits(float64(v))
+	}
+	return uint64(v)
+}
+
+func fromBits[V Number](b uint64) V {
+	if isFloat[V]() {
+		return V(math.Float64frombits(b))
+	}
+	return V(b)
+}
+
+// counter returns the counter for key, adding one at zero if there is none.
+func (c *CounterMap[K, V]) counter(key K) *counter {
+	if n, ok := c.m.Load(key); ok {
+		return n
+	}
+	n, _ := c.m.LoadOrStore(key, new(counter))
+	return n
+}
+
+// Add adds delta to the counter for key, starting it at zero if there is
+// none, and returns the new value.
+func (c *CounterMap[K, V]) Add(key K, delta V) V {
+	n := c.counter(key)
+	if !isFloat[V]() {
+		// Two's complement addition gives the same low bits for every
+		// integer type, signed or not.
+		return V(n.bits.Add(uint64(delta)))
+	}
+	for {
+		old := n.bits.Load()
+		v := fromBits[V](old) + delta
+		if n.bits.CompareAndSwap(old, toBits(v)) {
+			return v
+		}
+	}
+}
+
+// Inc adds 1 to the counter for key and returns the new value.
+func (c *CounterMap[K, V]) Inc(key K) V {
+	return c.Add(key, 1)
+}
+
+// Load returns the value of the counter for key. The ok result reports
+// whether there is a counter for key.
+func (c *CounterMap[K, V]) Load(key K) (value V, ok bool) {
+	n, ok := c.m.Load(key)
+	if !ok {
+		return value, false
+	}
+	return fromBits[V](n.bits.Load()), true
+}
+
+// Store sets the counter for key to value.
+func (c *CounterMap[K, V]) Store(key K, value V) {
+	c.counter(key).bits.Store(toBits(value))
+}
+
+// LoadAndDelete deletes the counter for key, returning its value if any. The
+// loaded result reports whether there was a counter for key.
+func (c *CounterMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	n, loaded := c.m.LoadAndDelete(key)
+	if !loaded {
+		return value, false
+	}
+	return fromBits[V](n.bits.Load()), true
+}
+
+// Delete deletes the counter for key.
+func (c *CounterMap[K, V]) Delete(key K) {
+	c.m.Delete(key)
+}
+
+// Range calls f sequentially for each key and the current value of its
+// counter. If f returns false, range stops the iteration. Range has the
+// semantics of Map.Range.
+func (c *CounterMap[K, V]) Range(f func(key K, value V) bool) {
+	c.m.Range(func(k K, n *counter) bool {
+		return f(k, fromBits[V](n.bits.Load()))
+	})
+}
+
+// Len returns the number of counters in the map.
+func (c *CounterMap[K, V]) Len() int {
+	return c.m.Len()
+}
+
+// Sum returns the sum of all the counters, read in a single Range pass.
+func (c *CounterMap[K, V]) Sum() V {
+	var sum V
+	c.Range(func(_ K, v V) bool {
+		sum += v
+		return true
+	})
+	return sum
+}
@@ -0,0 +1,69 @@
+package syncmapt_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+)
+
+func TestCounterMap(t *testing.T) {
+	var c syncmapt.CounterMap[string, int]
+	if v := c.Inc("a"); v != 1 {
+		t.Fatalf("Inc(a) = %d, want 1", v)
+	}
+	if v := c.Add("a", -3); v != -2 {
+		t.Fatalf("Add(a, -3) = %d, want -2", v)
+	}
+	c.Store("b", 10)
+	if v, ok := c.Load("b"); !ok || v != 10 {
+		t.Fatalf("Load(b) = %d, %v; want 10, true", v, ok)
+	}
+	if c.Len() != 2 || c.Sum() != 8 {
+		t.Fatalf("Len() = %d, Sum() = %d; want 2, 8", c.Len(), c.Sum())
+	}
+	if v, loaded := c.LoadAndDelete("a"); !loaded || v != -2 {
+		t.Fatalf("LoadAndDelete(a) = %d, %v; want -2, true", v, loaded)
+	}
+	if _, ok := c.Load("a"); ok {
+		t.Fatal("Load(a) found a deleted counter")
+	}
+
+	var u syncmapt.CounterMap[string, uint8]
+	u.Add("x", 250)
+	if v := u.Add("x", 10); v != 4 {
+		t.Fatalf("uint8 counter at 250 + 10 = %d, want 4", v)
+	}
+
+	f := syncmapt.NewCounterMap(syncmapt.WithKeyTransform[string, float64](strings.ToLower))
+	f.Add("Pi", 3)
+	if v := f.Add("PI", 0.14); v != 3.14 {
+		t.Fatalf("float counter = %v, want 3.14", v)
+	}
+}
+
+func TestCounterMapConcurrent(t *testing.T) {
+	var ints syncmapt.CounterMap[int, int64]
+	var floats syncmapt.CounterMap[int, float32]
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				ints.Inc(i % 4)
+				floats.Add(i%4, 0.5)
+			}
+		}()
+	}
+	wg.Wait()
+	for k := 0; k < 4; k++ {
+		if v, _ := ints.Load(k); v != 2000 {
+			t.Errorf("int counter %d = %d, want 2000", k, v)
+		}
+		if v, _ := floats.Load(k); v != 1000 {
+			t.Errorf("float counter %d = %v, want 1000", k, v)
+		}
+	}
+}
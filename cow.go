@@ -0,0 +1,173 @@
+package syncmapt
+
+import (
+	"maps"
+	"sync"
+	"sync/atomic"
+)
+
+// A COWMap is a copy-on-write concurrent map for data that is read far more
+// often than it changes, such as configuration. It publishes its contents as
+// an immutable Go map: a Load is a single atomic load and a map lookup,
+// taking no lock and allocating nothing, and Range sees a consistent state of
+// the map. Every write copies the whole map, so it takes time proportional to
+// the number of entries; writes are serialized by an internal lock.
+//
+// To make several changes with a single copy, use Update. Of the Options,
+// WithKeyTransform canonicalizes keys.
+//
+// The zero COWMap is empty and ready for use. A COWMap must not be copied
+// after first use.
+type COWMap[K comparable, V any] struct {
+	mu       sync.Mutex // serializes writers
+	contents atomic.Pointer[map[K]V]
+	cfg      *config[K, V]
+	closed   bool // set by Close, with mu held
+}
+
+var _ Interface[string, any] = (*COWMap[string, any])(nil)
+
+// NewCOWMap returns an empty COWMap configured by opts.
+func NewCOWMap[K comparable, V any](opts ...Option[K, V]) *COWMap[K, V] {
+	return &COWMap[K, V]{cfg: newConfig(opts)}
+}
+
+func (m *COWMap[K, V]) key(k K) K {
+	if m.cfg == nil || m.cfg.keyTransform == nil {
+		return k
+	}
+	return m.cfg.keyTransform(k)
+}
+
+// load returns the current contents, which must not be modified.
+func (m *COWMap[K, V]) load() map[K]V {
+	if p := m.contents.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// Load returns the value stored in the map for a key, or the zero value if no
+// value is present. The ok result indicates whether value was found in the
+// map.
+func (m *COWMap[K, V]) Load(key K) (value V, ok bool) {
+	value, ok = m.load()[m.key(key)]
+	return value, ok
+}
+
+// Update calls f with a copy of the contents of the map, which f may modify,
+// and then publishes it as the new contents, so that readers observe all the
+// changes of f at once. Keys stored by f are not transformed. f must not call
+// the write methods of m. If the map is closed, Update does not call f.
+func (m *COWMap[K, V]) Update(f func(contents map[K]V)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return
+	}
+	contents := maps.Clone(m.load())
+	if contents == nil {
+		contents = make(map[K]V)
+	}
+	f(contents)
+	m.contents.Store(&contents)
+}
+
+// Store sets the value for a key.
+func (m *COWMap[K, V]) Store(key K, value V) {
+	m.Swap(key, value)
+}
+
+// Swap swaps the value for a key and returns the previous value if any.
+// The loaded result reports whether the key was present.
+func (m *COWMap[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	key = m.key(key)
+	m.Update(func(contents map[K]V) {
+		previous, loaded = contents[key]
+		contents[key] = value
+	})
+	return previous, loaded
+}
+
+// LoadOrStore returns the existing value for the key if present. Otherwise,
+// it stores and returns the given value. The loaded result is true if the
+// value was loaded, false if stored. Only a store copies the map.
+func (m *COWMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	key = m.key(key)
+	if actual, loaded = m.load()[key]; loaded {
+		return actual, true
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if actual, loaded = m.load()[key]; loaded || m.closed {
+		return actual, loaded
+	}
+	contents := maps.Clone(m.load())
+	if contents == nil {
+		contents = make(map[K]V, 1)
+	}
+	contents[key] = value
+	m.contents.Store(&contents)
+	return value, false
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if
+// any. The loaded result reports whether the key was present. Deleting a key
+// that is not present does not copy the map.
+func (m *COWMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	key = m.key(key)
+	if _, ok := m.load()[key]; !ok {
+		return value, false
+	}
+	m.Update(func(contents map[K]V) {
+		value, loaded = contents[key]
+		delete(contents, key)
+	})
+	return value, loaded
+}
+
+// Delete deletes the value for a key.
+func (m *COWMap[K, V]) Delete(key K) {
+	m.LoadAndDelete(key)
+}
+
+// Replace replaces the contents of the map with a copy of contents.
+func (m *COWMap[K, V]) Replace(contents map[K]V) {
+	fresh := make(map[K]V, len(contents))
+	for k, v := range contents {
+		fresh[m.key(k)] = v
+	}
+	m.mu.Lock()
+	if !m.closed {
+		m.contents.Store(&fresh)
+	}
+	m.mu.Unlock()
+}
+
+// Range calls f sequentially for each key and value present in the map. If f
+// returns false, range stops the iteration. Range iterates over the contents
+// of the map at the time it is called, unaffected by concurrent writes, and f
+// may call any method of m.
+func (m *COWMap[K, V]) Range(f func(key K, value V) bool) {
+	for k, v := range m.load() {
+		if !f(k, v) {
+			return
+		}
+	}
+}
+
+// Len returns the number of entries in the map.
+func (m *COWMap[K, V]) Len() int {
+	return len(m.load())
+}
+
+// Close closes the map: afterwards its contents can still be read, but the
+// methods that would modify it do nothing. Swap and LoadAndDelete report that
+// nothing was swapped or deleted, and LoadOrStore returns the current value
+// for the key as Load does. Close is idempotent and always returns nil.
+func (m *COWMap[K, V]) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	return nil
+}
@@ -0,0 +1,85 @@
+package syncmapt_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+	"github.com/holdno/syncmapt/syncmapttest"
+)
+
+func TestCOWMap(t *testing.T) {
+	var m syncmapt.COWMap[string, int]
+	if _, ok := m.Load("a"); ok || m.Len() != 0 {
+		t.Fatal("zero COWMap is not empty")
+	}
+	m.Store("a", 1)
+	if v, loaded := m.LoadOrStore("a", 2); !loaded || v != 1 {
+		t.Fatalf("LoadOrStore(a, 2) = %v, %v; want 1, true", v, loaded)
+	}
+	if v, loaded := m.Swap("b", 2); loaded || v != 0 {
+		t.Fatalf("Swap(b, 2) = %v, %v; want 0, false", v, loaded)
+	}
+
+	// Range sees the contents at the time it is called.
+	n := 0
+	m.Range(func(k string, _ int) bool {
+		m.Delete(k)
+		m.Store(k+k, 0)
+		n++
+		return true
+	})
+	if n != 2 || m.Len() != 2 {
+		t.Fatalf("Range visited %d entries, Len() = %d; want 2, 2", n, m.Len())
+	}
+
+	m.Update(func(contents map[string]int) {
+		clear(contents)
+		contents["x"], contents["y"] = 1, 2
+	})
+	if v, _ := m.Load("y"); v != 2 || m.Len() != 2 {
+		t.Fatalf("after Update, y = %d, Len() = %d", v, m.Len())
+	}
+	if v, loaded := m.LoadAndDelete("x"); !loaded || v != 1 {
+		t.Fatalf("LoadAndDelete(x) = %v, %v; want 1, true", v, loaded)
+	}
+}
+
+func TestCOWMapConformance(t *testing.T) {
+	syncmapttest.RunConformance(t, func() syncmapt.Interface[string, string] {
+		return new(syncmapt.COWMap[string, string])
+	})
+}
+
+func TestCOWMapKeyTransform(t *testing.T) {
+	m := syncmapt.NewCOWMap(syncmapt.WithKeyTransform[string, string](strings.ToLower))
+	m.Replace(map[string]string{"Host": "example.com"})
+	if v, ok := m.Load("HOST"); !ok || v != "example.com" {
+		t.Fatalf("Load(HOST) = %q, %v", v, ok)
+	}
+}
+
+func TestCOWMapConsistentReads(t *testing.T) {
+	m := new(syncmapt.COWMap[int, int])
+	m.Replace(map[int]int{0: 0, 1: 0})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= 1000; i++ {
+			m.Update(func(contents map[int]int) { contents[0], contents[1] = i, i })
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		var seen []int
+		m.Range(func(_, v int) bool { seen = append(seen, v); return true })
+		if len(seen) != 2 || seen[0] != seen[1] {
+			t.Fatalf("Range saw %v, want two equal values", seen)
+		}
+	}
+	wg.Wait()
+	if testing.AllocsPerRun(100, func() { m.Load(1) }) != 0 {
+		t.Fatal("Load allocates")
+	}
+}
@@ -0,0 +1,90 @@
+package syncmapt
+
+// DeleteFunc deletes every entry for which del returns true, and returns the
+// number of entries it deleted.
+//
+// DeleteFunc makes a single pass over the map. Each entry is deleted
+// atomically with respect to the value del approved: if the value changes
+// concurrently, del is called again with the new one, and the entry is only
+// deleted if del approves that too. Keys stored concurrently with DeleteFunc
+// may or may not be visited. del is called without any internal lock held,
+// so it may call methods of m.
+func (m *Map[K, V]) DeleteFunc(del func(key K, value V) bool) int {
+	return m.deleteEach(del, nil)
+}
+
+// Drain deletes every entry of the map and calls f with each one it deleted,
+// right after deleting it. Every entry is delivered to exactly one caller:
+// concurrent Drains, Deletes and LoadAndDeletes never obtain the same value,
+// and a value stored concurrently is either delivered or left in the map,
+// never lost.
+//
+// Drain makes a single pass over the map, so keys stored concurrently may or
+// may not be drained. f is called without any internal lock held, so it may
+// call methods of m.
+func (m *Map[K, V]) Drain(f func(key K, value V)) {
+	m.deleteEach(func(K, V) bool { return true }, f)
+}
+
+// Pop deletes an arbitrary entry of the map and returns it. The ok result
+// reports whether there was an entry to delete. Concurrent Pops never return
+// the same value, which makes the map usable as a concurrent work set:
+// producers Store items and consumers Pop them.
+//
+// Which entry is popped is unspecified, and Pop is not fair: an entry may stay
+// in the map while newer ones are popped.
+func (m *Map[K, V]) Pop() (key K, value V, ok bool) {
+	if m.Closed() {
+		return key, value, false
+	}
+	for k, e := range m.promoted().m {
+		if v, ok := e.deleteIf(&m.ver, func(V) bool { return true }); ok {
+			m.deleted(k, v)
+			return k, v, true
+		}
+	}
+	return key, value, false
+}
+
+// promoted returns the read map, after promoting the dirty map if it holds
+// keys the read map does not, as Range does.
+func (m *Map[K, V]) promoted() readOnly[K, V] {
+	read, _ := m.read.Load().(readOnly[K, V])
+	if read.amended {
+		m.mu.Lock()
+		read = m.promotedLocked()
+		m.mu.Unlock()
+	}
+	return read
+}
+
+// promotedLocked is promoted with m.mu held.
+func (m *Map[K, V]) promotedLocked() readOnly[K, V] {
+	read, _ := m.read.Load().(readOnly[K, V])
+	if read.amended {
+		read = readOnly[K, V]{m: m.dirty}
+		m.read.Store(read)
+		m.dirty = nil
+		m.misses = 0
+	}
+	return read
+}
+
+// deleteEach deletes the entries approved by del, calling f, if not nil, with
+// each one it deleted, and returns how many it deleted.
+func (m *Map[K, V]) deleteEach(del func(K, V) bool, f func(K, V)) int {
+	if m.Closed() {
+		return 0
+	}
+	n := 0
+	for k, e := range m.promoted().m {
+		if v, ok := e.deleteIf(&m.ver, func(v V) bool { return del(k, v) }); ok {
+			m.deleted(k, v)
+			n++
+			if f != nil {
+				f(k, v)
+			}
+		}
+	}
+	return n
+}
@@ -0,0 +1,127 @@
+package syncmapt_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+	"github.com/holdno/syncmapt/syncmapttest"
+)
+
+func TestDeleteFunc(t *testing.T) {
+	m := new(syncmapt.Map[int, int])
+	for i := 0; i < 10; i++ {
+		m.Store(i, i*i)
+	}
+	n := m.DeleteFunc(func(k, v int) bool { return k%2 == 0 || v > 50 })
+	if n != 6 {
+		t.Fatalf("DeleteFunc deleted %d entries, want 6", n)
+	}
+	syncmapttest.RequireEqual(t, m, map[int]int{1: 1, 3: 9, 5: 25, 7: 49})
+
+	// Entries updated concurrently are judged by their latest value.
+	const keys = 1000
+	for i := 0; i < keys; i++ {
+		m.Store(i, 0)
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < keys; i++ {
+			m.Store(i, 1)
+		}
+	}()
+	m.DeleteFunc(func(_, v int) bool { return v == 0 })
+	wg.Wait()
+	m.Range(func(k, v int) bool {
+		if v != 1 {
+			t.Fatalf("key %d kept value %d", k, v)
+		}
+		return true
+	})
+}
+
+func TestDrain(t *testing.T) {
+	m := new(syncmapt.Map[int, int])
+	const keys = 10000
+	for i := 0; i < keys; i++ {
+		m.Store(i, i)
+	}
+
+	// Concurrent Drains deliver each entry exactly once.
+	const drains = 4
+	var wg sync.WaitGroup
+	got := make([]map[int]int, drains)
+	for d := range got {
+		got[d] = make(map[int]int)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.Drain(func(k, v int) { got[d][k] = v })
+		}()
+	}
+	wg.Wait()
+
+	if m.Len() != 0 {
+		t.Fatalf("Len() after Drain = %d", m.Len())
+	}
+	seen := make(map[int]bool)
+	for _, g := range got {
+		for k, v := range g {
+			if seen[k] || k != v {
+				t.Fatalf("entry %d: %d delivered twice or wrongly", k, v)
+			}
+			seen[k] = true
+		}
+	}
+	if len(seen) != keys {
+		t.Fatalf("Drain delivered %d entries, want %d", len(seen), keys)
+	}
+}
+
+func TestPop(t *testing.T) {
+	m := new(syncmapt.Map[int, int])
+	if _, _, ok := m.Pop(); ok {
+		t.Fatal("Pop of an empty map succeeded")
+	}
+
+	const items = 1000
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < items; i++ {
+			m.Store(i, i)
+		}
+	}()
+
+	popped := make(chan int, items)
+	for c := 0; c < 4; c++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for len(popped) < cap(popped) {
+				if k, v, ok := m.Pop(); ok {
+					if k != v {
+						t.Errorf("Pop() = %d, %d", k, v)
+					}
+					popped <- k
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(popped)
+
+	seen := make(map[int]bool)
+	for k := range popped {
+		if seen[k] {
+			t.Fatalf("%d popped twice", k)
+		}
+		seen[k] = true
+	}
+	if len(seen) != items || m.Len() != 0 {
+		t.Fatalf("popped %d items, %d left; want %d, 0", len(seen), m.Len(), items)
+	}
+}
@@ -0,0 +1,57 @@
+package syncmapt
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// MarshalJSON implements json.Marshaler. The map is encoded as a JSON object,
+// exactly like a map[K]V, from a copy of its contents taken as by ToMap.
+//
+// The method has a pointer receiver, as a Map must not be copied: a struct
+// that holds a Map by value is only encoded this way when it is marshaled
+// through a pointer.
+func (m *Map[K, V]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.ToMap())
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It decodes a JSON object as
+// encoding/json decodes it into a map[K]V, including keys of integer types,
+// and stores its entries in the map, keeping existing entries with other
+// keys. Nothing is stored if data is not a valid encoding of a map[K]V.
+func (m *Map[K, V]) UnmarshalJSON(data []byte) error {
+	var contents map[K]V
+	if err := json.Unmarshal(data, &contents); err != nil {
+		return err
+	}
+	for k, v := range contents {
+		m.Store(k, v)
+	}
+	return nil
+}
+
+// GobEncode implements gob.GobEncoder. The map is encoded as gob encodes a
+// map[K]V, from a copy of its contents taken as by ToMap, so concrete types
+// held in interface keys or values must be registered with gob.Register.
+func (m *Map[K, V]) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(m.ToMap()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements gob.GobDecoder. It decodes data as a map[K]V and
+// stores its entries in the map, keeping existing entries with other keys.
+// Nothing is stored if data is not a valid encoding of a map[K]V.
+func (m *Map[K, V]) GobDecode(data []byte) error {
+	var contents map[K]V
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&contents); err != nil {
+		return err
+	}
+	for k, v := range contents {
+		m.Store(k, v)
+	}
+	return nil
+}
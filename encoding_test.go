@@ -0,0 +1,68 @@
+package syncmapt_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+	"github.com/holdno/syncmapt/syncmapttest"
+)
+
+func TestJSON(t *testing.T) {
+	type config struct {
+		Name  string
+		Ports *syncmapt.Map[int, string]
+	}
+	in := config{Name: "svc", Ports: syncmapttest.New(map[int]string{80: "http", 443: "https"})}
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"Name":"svc","Ports":{"443":"https","80":"http"}}`; string(data) != want {
+		t.Fatalf("Marshal = %s, want %s", data, want)
+	}
+
+	var out config
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	syncmapttest.RequireEqual(t, out.Ports, map[int]string{80: "http", 443: "https"})
+
+	// Decoding stores into the existing contents, like decoding into a map.
+	if err := json.Unmarshal([]byte(`{"22":"ssh","80":"www"}`), out.Ports); err != nil {
+		t.Fatal(err)
+	}
+	syncmapttest.RequireEqual(t, out.Ports, map[int]string{22: "ssh", 80: "www", 443: "https"})
+
+	if err := json.Unmarshal([]byte(`{"x":"bad key"}`), out.Ports); err == nil {
+		t.Fatal("Unmarshal accepted a non-integer key")
+	}
+	if out.Ports.Len() != 3 {
+		t.Fatalf("failed Unmarshal changed the map: %v", out.Ports.ToMap())
+	}
+}
+
+func TestGob(t *testing.T) {
+	type point struct{ X, Y int }
+	type message struct {
+		ID     int
+		Points *syncmapt.Map[string, point]
+	}
+	in := message{ID: 7, Points: syncmapttest.New(map[string]point{"a": {1, 2}, "b": {3, 4}})}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(in); err != nil {
+		t.Fatal(err)
+	}
+	var out message
+	if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.ID != 7 {
+		t.Fatalf("ID = %d, want 7", out.ID)
+	}
+	syncmapttest.RequireEqual(t, out.Points, map[string]point{"a": {1, 2}, "b": {3, 4}})
+
+}
@@ -0,0 +1,265 @@
+package syncmapt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// A KeyProvider supplies the AES keys used to encrypt and decrypt persisted
+// map data. Implementations can wrap a key management service: the key ID
+// returned with an encryption key is stored in the clear in the encrypted
+// stream and passed back to DecryptionKey.
+//
+// Keys must be 16, 24 or 32 bytes long, selecting AES-128, AES-192 or AES-256.
+type KeyProvider interface {
+	EncryptionKey() (id string, key []byte, err error)
+	DecryptionKey(id string) ([]byte, error)
+}
+
+// StaticKey returns a KeyProvider that always uses key.
+func StaticKey(key []byte) KeyProvider {
+	return staticKey(key)
+}
+
+type staticKey []byte
+
+func (k staticKey) EncryptionKey() (string, []byte, error) { return "", k, nil }
+func (k staticKey) DecryptionKey(string) ([]byte, error)   { return k, nil }
+
+// ErrDecrypt is returned when encrypted data fails authentication: it was
+// truncated, modified, or encrypted with a different key.
+var ErrDecrypt = errors.New("syncmapt: encrypted data failed authentication")
+
+// The encrypted stream is a header followed by AES-GCM sealed chunks of up to
+// sealChunkSize plaintext bytes, each preceded by its sealed length as a
+// big-endian uint32. Following the STREAM construction, the nonce of each
+// chunk is a random prefix from the header, a chunk counter and a flag that
+// is set only for the last chunk, so that dropped, reordered and truncated
+// chunks all fail authentication. The header is authenticated with every
+// chunk.
+//
+//	header: "SMTE" | version (1) | len(key ID) (1) | key ID | nonce prefix (7)
+const (
+	sealMagic      = "SMTE"
+	sealVersion    = 1
+	sealChunkSize  = 64 << 10
+	sealPrefixSize = 7
+)
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func sealNonce(prefix []byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[sealPrefixSize:], counter)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+type encryptingWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	header  []byte
+	prefix  []byte
+	counter uint32
+	buf     []byte
+	err     error
+}
+
+// NewEncryptingWriter returns a writer that encrypts everything written to it
+// with a key from keys and writes the result to w. The caller must call Close
+// to write the final chunk; Close does not close w.
+func NewEncryptingWriter(w io.Writer, keys KeyProvider) (io.WriteCloser, error) {
+	aead, header, prefix, err := newSealHeader(keys)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &encryptingWriter{w: w, aead: aead, header: header, prefix: prefix}, nil
+}
+
+// newSealHeader returns the cipher and the header of a new encrypted stream,
+// with a key from keys and a random nonce prefix, which is a suffix of the
+// header.
+func newSealHeader(keys KeyProvider) (aead cipher.AEAD, header, prefix []byte, err error) {
+	id, key, err := keys.EncryptionKey()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(id) > 255 {
+		return nil, nil, nil, fmt.Errorf("syncmapt: key ID longer than 255 bytes")
+	}
+	if aead, err = newGCM(key); err != nil {
+		return nil, nil, nil, err
+	}
+	header = append([]byte(sealMagic), sealVersion, byte(len(id)))
+	header = append(header, id...)
+	prefix = make([]byte, sealPrefixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, nil, nil, err
+	}
+	header = append(header, prefix...)
+	return aead, header, header[len(header)-sealPrefixSize:], nil
+}
+
+func (e *encryptingWriter) Write(p []byte) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	e.buf = append(e.buf, p...)
+	// Keep at least one byte buffered, so that the last chunk is only sealed
+	// by Close.
+	for len(e.buf) > sealChunkSize {
+		if err := e.seal(e.buf[:sealChunkSize], false); err != nil {
+			return 0, err
+		}
+		e.buf = e.buf[:copy(e.buf, e.buf[sealChunkSize:])]
+	}
+	return len(p), nil
+}
+
+func (e *encryptingWriter) Close() error {
+	if e.err != nil {
+		return e.err
+	}
+	err := e.seal(e.buf, true)
+	if err == nil {
+		e.err = errors.New("syncmapt: write to closed encrypting writer")
+	}
+	return err
+}
+
+func (e *encryptingWriter) seal(chunk []byte, last bool) error {
+	if e.counter == 1<<32-1 {
+		e.err = errors.New("syncmapt: too much data for one encrypted stream")
+		return e.err
+	}
+	sealed := e.aead.Seal(make([]byte, 4, 4+len(chunk)+e.aead.Overhead()),
+		sealNonce(e.prefix, e.counter, last), chunk, e.header)
+	binary.BigEndian.PutUint32(sealed, uint32(len(sealed)-4))
+	if _, err := e.w.Write(sealed); err != nil {
+		e.err = err
+		return err
+	}
+	e.counter++
+	return nil
+}
+
+type decryptingReader struct {
+	r       io.Reader
+	aead    cipher.AEAD
+	header  []byte
+	prefix  []byte
+	counter uint32
+	buf     []byte // decrypted bytes not yet returned
+	done    bool   // the last chunk has been decrypted
+	err     error
+}
+
+// NewDecryptingReader returns a reader that decrypts data written by a writer
+// from NewEncryptingWriter, reading it from r and asking keys for the key it
+// was encrypted with. Reads return ErrDecrypt if the data fails
+// authentication, including when it is truncated.
+func NewDecryptingReader(r io.Reader, keys KeyProvider) (io.Reader, error) {
+	aead, header, prefix, err := readSealHeader(r, keys)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptingReader{r: r, aead: aead, header: header, prefix: prefix}, nil
+}
+
+// readSealHeader reads the header of an encrypted stream from r and returns
+// it with the cipher for the key it names and its nonce prefix.
+func readSealHeader(r io.Reader, keys KeyProvider) (aead cipher.AEAD, header, prefix []byte, err error) {
+	fixed := make([]byte, len(sealMagic)+2)
+	if _, err := io.ReadFull(r, fixed); err != nil {
+		return nil, nil, nil, err
+	}
+	if string(fixed[:len(sealMagic)]) != sealMagic {
+		return nil, nil, nil, errors.New("syncmapt: not an encrypted stream")
+	}
+	if v := fixed[len(sealMagic)]; v != sealVersion {
+		return nil, nil, nil, fmt.Errorf("syncmapt: unsupported encrypted stream version %d", v)
+	}
+	rest := make([]byte, int(fixed[len(sealMagic)+1])+sealPrefixSize)
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return nil, nil, nil, err
+	}
+	id := rest[:len(rest)-sealPrefixSize]
+	key, err := keys.DecryptionKey(string(id))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if aead, err = newGCM(key); err != nil {
+		return nil, nil, nil, err
+	}
+	return aead, append(fixed, rest...), rest[len(id):], nil
+}
+
+func (d *decryptingReader) Read(p []byte) (int, error) {
+	for len(d.buf) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		if d.done {
+			return 0, io.EOF
+		}
+		d.err = d.next()
+	}
+	n := copy(p, d.buf)
+	d.buf = d.buf[n:]
+	return n, nil
+}
+
+// next decrypts the next chunk into d.buf.
+func (d *decryptingReader) next() error {
+	var size [4]byte
+	if _, err := io.ReadFull(d.r, size[:]); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return ErrDecrypt
+		}
+		return err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > sealChunkSize+uint32(d.aead.Overhead()) {
+		return ErrDecrypt
+	}
+	sealed := make([]byte, n)
+	if _, err := io.ReadFull(d.r, sealed); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return ErrDecrypt
+		}
+		return err
+	}
+	for _, last := range []bool{false, true} {
+		if plain, err := d.aead.Open(nil, sealNonce(d.prefix, d.counter, last), sealed, d.header); err == nil {
+			d.buf, d.done = plain, last
+			d.counter++
+			if last {
+				// Anything after the last chunk is an error.
+				var extra [1]byte
+				if n, _ := d.r.Read(extra[:]); n > 0 {
+					return ErrDecrypt
+				}
+			}
+			return nil
+		}
+	}
+	return ErrDecrypt
+}
+
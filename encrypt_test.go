@@ -0,0 +1,119 @@
+package syncmapt_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+)
+
+func encrypt(t *testing.T, keys syncmapt.KeyProvider, plain []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := syncmapt.NewEncryptingWriter(&buf, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(plain); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func decrypt(keys syncmapt.KeyProvider, sealed []byte) ([]byte, error) {
+	r, err := syncmapt.NewDecryptingReader(bytes.NewReader(sealed), keys)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	key := make([]byte, 32)
+	rand.Read(key)
+	keys := syncmapt.StaticKey(key)
+
+	for _, size := range []int{0, 1, 64 << 10, 200 << 10} {
+		plain := make([]byte, size)
+		rand.Read(plain)
+
+		sealed := encrypt(t, keys, plain)
+		if size > 16 && bytes.Contains(sealed, plain[:16]) {
+			t.Fatal("plaintext visible in encrypted output")
+		}
+		got, err := decrypt(keys, sealed)
+		if err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		if !bytes.Equal(got, plain) {
+			t.Fatalf("size %d: round trip mismatch", size)
+		}
+	}
+}
+
+func TestDecryptRejectsTampering(t *testing.T) {
+	key := make([]byte, 16)
+	keys := syncmapt.StaticKey(key)
+	plain := bytes.Repeat([]byte("secret token "), 10000) // several chunks
+	sealed := encrypt(t, keys, plain)
+
+	tests := map[string][]byte{
+		"truncated":     sealed[:len(sealed)-10],
+		"chunk dropped": sealed[:len(sealed)/2],
+		"trailing data": append(append([]byte(nil), sealed...), 0),
+	}
+	flipped := append([]byte(nil), sealed...)
+	flipped[len(flipped)/2] ^= 1
+	tests["bit flipped"] = flipped
+
+	for name, data := range tests {
+		if _, err := decrypt(keys, data); !errors.Is(err, syncmapt.ErrDecrypt) {
+			t.Errorf("%s: want ErrDecrypt, got %v", name, err)
+		}
+	}
+
+	other := make([]byte, 16)
+	other[0] = 1
+	if _, err := decrypt(syncmapt.StaticKey(other), sealed); !errors.Is(err, syncmapt.ErrDecrypt) {
+		t.Error("wrong key: want ErrDecrypt, got", err)
+	}
+}
+
+// rotatingKeys is a KeyProvider that encrypts with its current key and can
+// still decrypt with older ones, like a KMS.
+type rotatingKeys struct {
+	current string
+	keys    map[string][]byte
+}
+
+func (r *rotatingKeys) EncryptionKey() (string, []byte, error) {
+	return r.current, r.keys[r.current], nil
+}
+
+func (r *rotatingKeys) DecryptionKey(id string) ([]byte, error) {
+	key, ok := r.keys[id]
+	if !ok {
+		return nil, errors.New("unknown key " + id)
+	}
+	return key, nil
+}
+
+func TestEncryptKeyID(t *testing.T) {
+	keys := &rotatingKeys{current: "v1", keys: map[string][]byte{
+		"v1": bytes.Repeat([]byte{1}, 32),
+		"v2": bytes.Repeat([]byte{2}, 32),
+	}}
+	old := encrypt(t, keys, []byte("written with v1"))
+
+	keys.current = "v2"
+	got, err := decrypt(keys, old)
+	if err != nil || string(got) != "written with v1" {
+		t.Fatalf("decrypt after rotation = %q, %v", got, err)
+	}
+}
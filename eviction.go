@@ -0,0 +1,193 @@
+package syncmapt
+
+import (
+	"container/list"
+	"math/rand/v2"
+)
+
+// An EvictionPolicy chooses the entries a BoundedMap evicts when it is full.
+// The map reports each change to its set of keys, and each use of a key, to
+// its policy, and asks it for the keys to evict.
+//
+// A BoundedMap calls the methods of its policy one at a time with its lock
+// held, so they need not be safe for concurrent use, but must not call
+// methods of the map. A policy must not be shared by several maps.
+type EvictionPolicy[K comparable] interface {
+	// Added reports that key was stored in the map.
+	Added(key K)
+	// Accessed reports that the value for key was loaded or overwritten.
+	Accessed(key K)
+	// Removed reports that key was deleted or evicted from the map.
+	Removed(key K)
+	// Candidates returns up to n keys of the map in the order the policy
+	// would evict them, the key to evict next first. The map is not empty
+	// and n is positive.
+	Candidates(n int) []K
+}
+
+// WithEvictionPolicy returns an Option that makes a BoundedMap evict entries
+// chosen by p instead of the least recently used ones.
+func WithEvictionPolicy[K comparable, V any](p EvictionPolicy[K]) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.eviction = p
+	}
+}
+
+// NewLRUPolicy returns an EvictionPolicy that evicts the least recently used
+// key. It is the default policy of a BoundedMap.
+func NewLRUPolicy[K comparable]() EvictionPolicy[K] {
+	return &queuePolicy[K]{order: list.New(), elems: make(map[K]*list.Element), recency: true}
+}
+
+// NewFIFOPolicy returns an EvictionPolicy that evicts the least recently
+// added key, regardless of use.
+func NewFIFOPolicy[K comparable]() EvictionPolicy[K] {
+	return &queuePolicy[K]{order: list.New(), elems: make(map[K]*list.Element)}
+}
+
+// A queuePolicy evicts keys from the back of a queue they enter at the front.
+// If recency is set, a used key moves back to the front.
+type queuePolicy[K comparable] struct {
+	order   *list.List // of K
+	elems   map[K]*list.Element
+	recency bool
+}
+
+func (p *queuePolicy[K]) Added(key K) {
+	p.elems[key] = p.order.PushFront(key)
+}
+
+func (p *queuePolicy[K]) Accessed(key K) {
+	if p.recency {
+		p.order.MoveToFront(p.elems[key])
+	}
+}
+
+func (p *queuePolicy[K]) Removed(key K) {
+	p.order.Remove(p.elems[key])
+	delete(p.elems, key)
+}
+
+func (p *queuePolicy[K]) Candidates(n int) []K {
+	keys := make([]K, 0, min(n, p.order.Len()))
+	for e := p.order.Back(); e != nil && len(keys) < n; e = e.Prev() {
+		keys = append(keys, e.Value.(K))
+	}
+	return keys
+}
+
+// NewLFUPolicy returns an EvictionPolicy that evicts the least frequently
+// used key, counting the store that added it as a use. Of keys used equally
+// often, it evicts the least recently used.
+func NewLFUPolicy[K comparable]() EvictionPolicy[K] {
+	return &lfuPolicy[K]{buckets: list.New(), elems: make(map[K]lfuElem)}
+}
+
+// An lfuPolicy keeps a list of buckets in increasing order of use count, each
+// holding the keys used that many times, most recently used first. Every
+// operation takes constant time.
+type lfuPolicy[K comparable] struct {
+	buckets *list.List // of *lfuBucket
+	elems   map[K]lfuElem
+}
+
+type lfuBucket struct {
+	count int
+	keys  *list.List
+}
+
+type lfuElem struct {
+	bucket *list.Element // in lfuPolicy.buckets
+	key    *list.Element // in lfuBucket.keys
+}
+
+func (p *lfuPolicy[K]) Added(key K) {
+	front := p.buckets.Front()
+	if front == nil || front.Value.(*lfuBucket).count != 1 {
+		front = p.buckets.PushFront(&lfuBucket{count: 1, keys: list.New()})
+	}
+	p.elems[key] = lfuElem{front, front.Value.(*lfuBucket).keys.PushFront(key)}
+}
+
+func (p *lfuPolicy[K]) Accessed(key K) {
+	e := p.elems[key]
+	b := e.bucket.Value.(*lfuBucket)
+	next := e.bucket.Next()
+	if next == nil || next.Value.(*lfuBucket).count != b.count+1 {
+		next = p.buckets.InsertAfter(&lfuBucket{count: b.count + 1, keys: list.New()}, e.bucket)
+	}
+	p.remove(e)
+	p.elems[key] = lfuElem{next, next.Value.(*lfuBucket).keys.PushFront(key)}
+}
+
+func (p *lfuPolicy[K]) Removed(key K) {
+	p.remove(p.elems[key])
+	delete(p.elems, key)
+}
+
+func (p *lfuPolicy[K]) remove(e lfuElem) {
+	b := e.bucket.Value.(*lfuBucket)
+	b.keys.Remove(e.key)
+	if b.keys.Len() == 0 {
+		p.buckets.Remove(e.bucket)
+	}
+}
+
+func (p *lfuPolicy[K]) Candidates(n int) []K {
+	keys := make([]K, 0, min(n, len(p.elems)))
+	for b := p.buckets.Front(); b != nil && len(keys) < n; b = b.Next() {
+		for e := b.Value.(*lfuBucket).keys.Back(); e != nil && len(keys) < n; e = e.Prev() {
+			keys = append(keys, e.Value.(K))
+		}
+	}
+	return keys
+}
+
+// NewRandomPolicy returns an EvictionPolicy that evicts keys chosen
+// uniformly at random. Its candidates are a fresh random sample each time, so
+// they predict which keys may be evicted, not which will.
+func NewRandomPolicy[K comparable]() EvictionPolicy[K] {
+	return &randomPolicy[K]{index: make(map[K]int)}
+}
+
+type randomPolicy[K comparable] struct {
+	keys  []K
+	index map[K]int // position of each key in keys
+}
+
+func (p *randomPolicy[K]) Added(key K) {
+	p.index[key] = len(p.keys)
+	p.keys = append(p.keys, key)
+}
+
+func (p *randomPolicy[K]) Accessed(key K) {}
+
+func (p *randomPolicy[K]) Removed(key K) {
+	i, last := p.index[key], len(p.keys)-1
+	p.keys[i] = p.keys[last]
+	p.index[p.keys[i]] = i
+	var zero K
+	p.keys[last] = zero
+	p.keys = p.keys[:last]
+	delete(p.index, key)
+}
+
+func (p *randomPolicy[K]) Candidates(n int) []K {
+	// Draw without replacement with a partial Fisher-Yates shuffle of the
+	// positions, kept sparse in swapped so that keys itself is not changed.
+	n = min(n, len(p.keys))
+	keys := make([]K, n)
+	swapped := make(map[int]int, n)
+	at := func(i int) int {
+		if j, ok := swapped[i]; ok {
+			return j
+		}
+		return i
+	}
+	for i := range n {
+		j := i + rand.IntN(len(p.keys)-i)
+		keys[i] = p.keys[at(j)]
+		swapped[j] = at(i)
+	}
+	return keys
+}
@@ -0,0 +1,130 @@
+package syncmapt_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+	"github.com/holdno/syncmapt/syncmapttest"
+)
+
+func TestEvictionPolicy(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		policy syncmapt.EvictionPolicy[string]
+		want   map[string]int
+	}{
+		// a and b are used after being added, a twice, so LRU and LFU
+		// evict c, and FIFO evicts a, the first added.
+		{"LRU", syncmapt.NewLRUPolicy[string](), map[string]int{"a": 1, "b": 2, "d": 4}},
+		{"FIFO", syncmapt.NewFIFOPolicy[string](), map[string]int{"b": 2, "c": 3, "d": 4}},
+		{"LFU", syncmapt.NewLFUPolicy[string](), map[string]int{"a": 1, "b": 2, "d": 4}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			m := syncmapt.NewBoundedMap(
+				syncmapt.WithMaxEntries[string, int](3),
+				syncmapt.WithEvictionPolicy[string, int](tt.policy),
+			)
+			m.Store("a", 1)
+			m.Store("b", 2)
+			m.Store("c", 3)
+			m.Load("a")
+			m.Load("b")
+			m.Load("a")
+			m.Store("d", 4)
+			syncmapttest.RequireEqual(t, m, tt.want)
+		})
+	}
+}
+
+func TestLFUPolicy(t *testing.T) {
+	p := syncmapt.NewLFUPolicy[string]()
+	for _, k := range []string{"a", "b", "c", "d"} {
+		p.Added(k)
+	}
+	for _, k := range []string{"c", "c", "a", "b", "c"} {
+		p.Accessed(k)
+	}
+	// d was used once; a and b twice, a less recently; c four times.
+	if got, want := p.Candidates(10), []string{"d", "a", "b", "c"}; !slices.Equal(got, want) {
+		t.Fatalf("Candidates(10) = %v, want %v", got, want)
+	}
+	p.Removed("a")
+	p.Removed("d")
+	if got, want := p.Candidates(1), []string{"b"}; !slices.Equal(got, want) {
+		t.Fatalf("Candidates(1) = %v, want %v", got, want)
+	}
+}
+
+func TestRandomPolicy(t *testing.T) {
+	m := syncmapt.NewBoundedMap(
+		syncmapt.WithMaxEntries[int, int](10),
+		syncmapt.WithEvictionPolicy[int, int](syncmapt.NewRandomPolicy[int]()),
+	)
+	for i := 0; i < 100; i++ {
+		m.Store(i, i)
+		if i%3 == 0 {
+			m.Delete(i)
+		}
+	}
+	if m.Len() > 10 {
+		t.Fatalf("Len() = %d, want at most 10", m.Len())
+	}
+	candidates := m.EvictionCandidates(20)
+	if len(candidates) != m.Len() {
+		t.Fatalf("EvictionCandidates(20) = %v, want all %d keys", candidates, m.Len())
+	}
+	slices.Sort(candidates)
+	if len(slices.Compact(candidates)) != m.Len() {
+		t.Fatalf("EvictionCandidates(20) has duplicates")
+	}
+	for _, k := range candidates {
+		if _, ok := m.Load(k); !ok {
+			t.Fatalf("candidate %d is not in the map", k)
+		}
+	}
+}
+
+func TestEvictionCandidates(t *testing.T) {
+	m := syncmapt.NewBoundedMap(syncmapt.WithMaxEntries[string, int](3))
+	if c := m.EvictionCandidates(1); c != nil {
+		t.Fatalf("EvictionCandidates of an empty map = %v", c)
+	}
+	m.Store("a", 1)
+	m.Store("b", 2)
+	m.Store("c", 3)
+	m.Load("a")
+	if got, want := m.EvictionCandidates(2), []string{"b", "c"}; !slices.Equal(got, want) {
+		t.Fatalf("EvictionCandidates(2) = %v, want %v", got, want)
+	}
+	// A dry run evicts nothing.
+	if m.Len() != 3 || m.Evicted() != 0 {
+		t.Fatalf("after EvictionCandidates: Len() = %d, Evicted() = %d", m.Len(), m.Evicted())
+	}
+	m.Store("d", 4)
+	if _, ok := m.Load("b"); ok {
+		t.Fatal("the first candidate was not evicted")
+	}
+}
+
+// noCandidates is an EvictionPolicy that never offers a key to evict.
+type noCandidates struct{}
+
+func (noCandidates) Added(string)            {}
+func (noCandidates) Accessed(string)         {}
+func (noCandidates) Removed(string)          {}
+func (noCandidates) Candidates(int) []string { return nil }
+
+func TestEvictionPolicyNoCandidates(t *testing.T) {
+	m := syncmapt.NewBoundedMap(
+		syncmapt.WithMaxEntries[string, int](1),
+		syncmapt.WithEvictionPolicy[string, int](noCandidates{}),
+	)
+	m.Store("a", 1)
+	if err := m.StoreContext(context.Background(), "b", 2); !errors.Is(err, syncmapt.ErrFull) {
+		t.Fatalf("StoreContext with no eviction candidate = %v, want ErrFull", err)
+	}
+	syncmapttest.RequireEqual(t, m, map[string]int{"a": 1})
+}
@@ -0,0 +1,302 @@
+package syncmapt
+
+import (
+	"context"
+	"iter"
+	"sync"
+	"time"
+)
+
+// WithJanitor returns an Option that makes an ExpiringMap delete its expired
+// entries every interval from a background goroutine, which runs until the
+// map is closed. Without a janitor, expired entries are invisible but keep
+// their memory until they are next written, deleted or reaped with
+// DeleteExpired.
+func WithJanitor[K comparable, V any](interval time.Duration) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.janitor = interval
+	}
+}
+
+// An ExpiringMap is a concurrent map whose entries expire: once an entry's
+// deadline has passed, lookups treat it as missing. It is built on a Map and
+// has the same methods for basic operations.
+//
+// Of the Options, WithTTL sets how long entries stored by Store and
+// LoadOrStore live, WithClock sets the time source for deadlines,
+// WithJanitor starts a background goroutine that reaps expired entries,
+// WithStats enables operation counts, WithKeyTransform canonicalizes keys,
+// and WithCapacity sizes the map.
+//
+// The zero ExpiringMap is empty, keeps entries stored by Store forever, and
+// is ready for use. An ExpiringMap must not be copied after first use.
+type ExpiringMap[K comparable, V any] struct {
+	m    Map[K, expiring[V]]
+	cfg  *config[K, V]
+	stop chan struct{} // closed by Close to stop the janitor
+	once sync.Once
+}
+
+var _ Interface[string, any] = (*ExpiringMap[string, any])(nil)
+
+// An expiring value is stored in an ExpiringMap.
+type expiring[V any] struct {
+	v        V
+	deadline int64 // in Unix nanoseconds; 0 if the value never expires
+}
+
+// NewExpiringMap returns an empty ExpiringMap configured by opts.
+func NewExpiringMap[K comparable, V any](opts ...Option[K, V]) *ExpiringMap[K, V] {
+	m := &ExpiringMap[K, V]{cfg: newConfig(opts)}
+	if m.cfg.capacity > 0 {
+		m.m.dirty = make(map[K]*entry[expiring[V]], m.cfg.capacity)
+	}
+	if m.cfg.stats {
+		// The map applies the other options itself.
+		m.m.cfg = &config[K, expiring[V]]{clock: m.cfg.clock, stats: true}
+	}
+	if m.cfg.janitor > 0 {
+		m.stop = make(chan struct{})
+		go m.janitor(m.cfg.janitor)
+	}
+	return m
+}
+
+func (m *ExpiringMap[K, V]) janitor(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			m.DeleteExpired()
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+func (m *ExpiringMap[K, V]) key(k K) K {
+	if m.cfg == nil || m.cfg.keyTransform == nil {
+		return k
+	}
+	return m.cfg.keyTransform(k)
+}
+
+func (m *ExpiringMap[K, V]) now() int64 {
+	if m.cfg == nil {
+		return time.Now().UnixNano()
+	}
+	return m.cfg.clock.Now().UnixNano()
+}
+
+// deadline returns the deadline of a value stored now for ttl.
+func (m *ExpiringMap[K, V]) deadline(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	return m.now() + int64(ttl)
+}
+
+func (e expiring[V]) expired(now int64) bool {
+	return e.deadline != 0 && e.deadline <= now
+}
+
+// Load returns the value stored in the map for a key, or the zero value if no
+// value is present or it has expired. The ok result indicates whether value
+// was found in the map.
+func (m *ExpiringMap[K, V]) Load(key K) (value V, ok bool) {
+	e, ok := m.m.load(m.key(key))
+	ok = ok && !e.expired(m.now())
+	m.m.countLoad(ok)
+	if !ok {
+		return value, false
+	}
+	return e.v, true
+}
+
+// Store sets the value for a key, to expire after the map's TTL.
+func (m *ExpiringMap[K, V]) Store(key K, value V) {
+	var ttl time.Duration
+	if m.cfg != nil {
+		ttl = m.cfg.ttl
+	}
+	m.StoreWithTTL(key, value, ttl)
+}
+
+// StoreWithTTL sets the value for a key, to expire after ttl. A non-positive
+// ttl means the value never expires.
+func (m *ExpiringMap[K, V]) StoreWithTTL(key K, value V, ttl time.Duration) {
+	m.m.Store(m.key(key), expiring[V]{value, m.deadline(ttl)})
+}
+
+// LoadOrStore returns the existing value for the key if present and not
+// expired. Otherwise, it stores the given value, to expire after the map's
+// TTL, and returns it. The loaded result is true if the value was loaded,
+// false if stored.
+func (m *ExpiringMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	if m.m.Closed() {
+		return m.Load(key)
+	}
+	key = m.key(key)
+	var ttl time.Duration
+	if m.cfg != nil {
+		ttl = m.cfg.ttl
+	}
+	for {
+		e, loaded := m.m.LoadOrStore(key, expiring[V]{value, m.deadline(ttl)})
+		if !loaded {
+			return value, false
+		}
+		now := m.now()
+		if !e.expired(now) {
+			return e.v, true
+		}
+		// Replace the expired value, unless another goroutine already did.
+		m.m.expireIf(key, func(e expiring[V]) bool { return e.expired(now) })
+	}
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if
+// any and not expired. The loaded result reports whether such a value was
+// present.
+func (m *ExpiringMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	e, loaded := m.m.LoadAndDelete(m.key(key))
+	if !loaded || e.expired(m.now()) {
+		return value, false
+	}
+	return e.v, true
+}
+
+// Delete deletes the value for a key.
+func (m *ExpiringMap[K, V]) Delete(key K) {
+	m.m.Delete(m.key(key))
+}
+
+// Range calls f sequentially for each key and value present in the map and
+// not expired, with the same consistency as Map.Range. If f returns false,
+// range stops the iteration.
+func (m *ExpiringMap[K, V]) Range(f func(key K, value V) bool) {
+	now := m.now()
+	m.m.Range(func(k K, e expiring[V]) bool {
+		if e.expired(now) {
+			return true
+		}
+		return f(k, e.v)
+	})
+}
+
+// Len returns the number of entries in the map that have not expired.
+func (m *ExpiringMap[K, V]) Len() int {
+	n := 0
+	m.Range(func(K, V) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+// ExpiringWithin returns an iterator over the entries that have not expired
+// but will within d, for example to renew them before they lapse. Like
+// Range, it does not necessarily observe a consistent snapshot of the map.
+func (m *ExpiringMap[K, V]) ExpiringWithin(d time.Duration) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		now := m.now()
+		horizon := now + int64(d)
+		m.m.Range(func(k K, e expiring[V]) bool {
+			if e.deadline == 0 || e.expired(now) || e.deadline > horizon {
+				return true
+			}
+			return yield(k, e.v)
+		})
+	}
+}
+
+// Watch returns a channel that delivers the changes to the value stored for
+// key, as Map.Watch does. When an expired value is deleted by the janitor,
+// DeleteExpired or a store replacing it, the channel delivers an
+// EventExpire; an entry that expires without being deleted sends no event.
+func (m *ExpiringMap[K, V]) Watch(ctx context.Context, key K) <-chan Event[K, V] {
+	key = m.key(key)
+	return watch(ctx, &m.m, &key, m.watchLoad)
+}
+
+// WatchAll is like Watch, but delivers the changes to every key of the map.
+func (m *ExpiringMap[K, V]) WatchAll(ctx context.Context) <-chan Event[K, V] {
+	return watch(ctx, &m.m, nil, m.watchLoad)
+}
+
+func (m *ExpiringMap[K, V]) watchLoad(key K) (value V, ok bool) {
+	e, ok := m.m.load(key)
+	if !ok || e.expired(m.now()) {
+		return value, false
+	}
+	return e.v, true
+}
+
+// OnStore registers f to be called after every store of a value for a key,
+// as Map.OnStore does.
+func (m *ExpiringMap[K, V]) OnStore(f func(key K, value V)) {
+	m.m.OnStore(func(k K, e expiring[V]) { f(k, e.v) })
+}
+
+// OnDelete registers f to be called after every deletion of a value that has
+// not expired, as Map.OnDelete does.
+func (m *ExpiringMap[K, V]) OnDelete(f func(key K, value V)) {
+	m.m.OnDelete(func(k K, e expiring[V]) {
+		if !e.expired(m.now()) {
+			f(k, e.v)
+		}
+	})
+}
+
+// OnEvict registers f to be called after every deletion of an expired value,
+// whether by the janitor, DeleteExpired, a store replacing it or an explicit
+// deletion, in the same way as Map.OnDelete.
+func (m *ExpiringMap[K, V]) OnEvict(f func(key K, value V)) {
+	g := func(k K, e expiring[V]) { f(k, e.v) }
+	addHook(&m.m.hooks, &m.m.obsMu, evictHooks[K, expiring[V]], g)
+	m.m.OnDelete(func(k K, e expiring[V]) {
+		if e.expired(m.now()) {
+			g(k, e)
+		}
+	})
+}
+
+// Stats returns the operation counts of a map created WithStats, as
+// Map.Stats does. Loads of expired values count as misses, and expired
+// values deleted by the janitor, DeleteExpired or a store replacing them
+// count as Expirations.
+func (m *ExpiringMap[K, V]) Stats() Stats {
+	return m.m.Stats()
+}
+
+// DeleteExpired deletes the expired entries of the map and returns how many
+// it deleted. An entry updated concurrently is only deleted if its new value
+// has expired too.
+func (m *ExpiringMap[K, V]) DeleteExpired() int {
+	if m.m.Closed() {
+		return 0
+	}
+	now := m.now()
+	n := 0
+	m.m.Range(func(k K, e expiring[V]) bool {
+		if e.expired(now) && m.m.expireIf(k, func(e expiring[V]) bool { return e.expired(now) }) {
+			n++
+		}
+		return true
+	})
+	return n
+}
+
+// Close stops the janitor, if any, and closes the map as Map.Close does:
+// afterwards its contents can still be read, but stores and deletions,
+// including those of DeleteExpired, do nothing. Close is idempotent and
+// always returns nil.
+func (m *ExpiringMap[K, V]) Close() error {
+	m.once.Do(func() {
+		if m.stop != nil {
+			close(m.stop)
+		}
+	})
+	return m.m.Close()
+}
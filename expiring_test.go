@@ -0,0 +1,143 @@
+package syncmapt_test
+
+import (
+	"maps"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/holdno/syncmapt"
+	"github.com/holdno/syncmapt/syncmapttest"
+)
+
+func TestExpiringMap(t *testing.T) {
+	clock := newFakeClock()
+	m := syncmapt.NewExpiringMap(
+		syncmapt.WithTTL[string, int](time.Minute),
+		syncmapt.WithClock[string, int](clock),
+	)
+	defer m.Close()
+
+	m.Store("default", 1)
+	m.StoreWithTTL("short", 2, time.Second)
+	m.StoreWithTTL("forever", 3, 0)
+	syncmapttest.RequireEqual(t, m, map[string]int{"default": 1, "short": 2, "forever": 3})
+
+	clock.Advance(time.Second)
+	if v, ok := m.Load("short"); ok {
+		t.Fatalf("Load of an expired entry = %v, true", v)
+	}
+	if m.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", m.Len())
+	}
+	if actual, loaded := m.LoadOrStore("short", 20); loaded || actual != 20 {
+		t.Fatalf("LoadOrStore over an expired entry = %v, %v; want 20, false", actual, loaded)
+	}
+
+	clock.Advance(time.Minute)
+	syncmapttest.RequireEqual(t, m, map[string]int{"forever": 3})
+	if v, loaded := m.LoadAndDelete("default"); loaded {
+		t.Fatalf("LoadAndDelete of an expired entry = %v, true", v)
+	}
+}
+
+func TestExpiringMapConformance(t *testing.T) {
+	syncmapttest.RunConformance(t, func() syncmapt.Interface[string, string] {
+		return syncmapt.NewExpiringMap[string, string]()
+	})
+}
+
+func TestExpiringWithin(t *testing.T) {
+	clock := newFakeClock()
+	m := syncmapt.NewExpiringMap(syncmapt.WithClock[string, int](clock))
+	m.StoreWithTTL("soon", 1, time.Second)
+	m.StoreWithTTL("later", 2, time.Hour)
+	m.Store("never", 3)
+
+	if got, want := maps.Collect(m.ExpiringWithin(time.Minute)), map[string]int{"soon": 1}; !maps.Equal(got, want) {
+		t.Fatalf("ExpiringWithin(1m) = %v, want %v", got, want)
+	}
+	clock.Advance(2 * time.Second)
+	if got := maps.Collect(m.ExpiringWithin(time.Minute)); len(got) != 0 {
+		t.Fatalf("ExpiringWithin(1m) included expired entries: %v", got)
+	}
+	if got, want := maps.Collect(m.ExpiringWithin(2*time.Hour)), map[string]int{"later": 2}; !maps.Equal(got, want) {
+		t.Fatalf("ExpiringWithin(2h) = %v, want %v", got, want)
+	}
+}
+
+func TestExpiringMapDeleteExpired(t *testing.T) {
+	clock := newFakeClock()
+	m := syncmapt.NewExpiringMap(syncmapt.WithClock[int, int](clock), syncmapt.WithTTL[int, int](time.Second))
+	for i := 0; i < 10; i++ {
+		m.Store(i, i)
+	}
+	m.StoreWithTTL(10, 10, time.Hour)
+	clock.Advance(time.Second)
+	if n := m.DeleteExpired(); n != 10 {
+		t.Fatalf("DeleteExpired() = %d, want 10", n)
+	}
+	syncmapttest.RequireEqual(t, m, map[int]int{10: 10})
+}
+
+// countingClock counts the calls of its Now method.
+type countingClock struct {
+	*fakeClock
+	calls atomic.Int64
+}
+
+func (c *countingClock) Now() time.Time {
+	c.calls.Add(1)
+	return c.fakeClock.Now()
+}
+
+func TestExpiringMapJanitor(t *testing.T) {
+	clock := &countingClock{fakeClock: newFakeClock()}
+	m := syncmapt.NewExpiringMap(
+		syncmapt.WithClock[string, int](clock),
+		syncmapt.WithTTL[string, int](time.Second),
+		syncmapt.WithJanitor[string, int](time.Millisecond),
+	)
+	m.Store("a", 1)
+	clock.Advance(time.Second)
+
+	// Wait for the janitor to run at least once more after the entry
+	// expired; it reads the clock each time.
+	for start := clock.calls.Load(); clock.calls.Load() < start+2; {
+		time.Sleep(time.Millisecond)
+	}
+	if n := m.DeleteExpired(); n != 0 {
+		t.Fatalf("DeleteExpired() = %d after the janitor ran, want 0", n)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var zero syncmapt.ExpiringMap[string, int]
+	zero.Store("a", 1)
+	if v, ok := zero.Load("a"); !ok || v != 1 {
+		t.Fatalf("zero ExpiringMap Load = %v, %v", v, ok)
+	}
+}
+
+func TestExpiringMapStats(t *testing.T) {
+	clock := newFakeClock()
+	m := syncmapt.NewExpiringMap(
+		syncmapt.WithStats[string, int](),
+		syncmapt.WithClock[string, int](clock),
+	)
+	defer m.Close()
+	m.StoreWithTTL("a", 1, time.Second)
+	m.Load("a")
+	clock.Advance(time.Second)
+	m.Load("a")
+	m.DeleteExpired()
+
+	want := syncmapt.Stats{Loads: 2, Hits: 1, Stores: 1, Expirations: 1}
+	if s := m.Stats(); s != want {
+		t.Fatalf("Stats() = %+v, want %+v", s, want)
+	}
+}
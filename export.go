@@ -0,0 +1,41 @@
+package syncmapt
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// ExportCSV writes the entries of the map to w as CSV records, one per entry,
+// with the fields returned by format. If header is non-nil, it is written as
+// the first record.
+//
+// Entries are written as they are visited by Range, without first copying the
+// map. ExportCSV stops at the first write error and returns it.
+func (m *Map[K, V]) ExportCSV(w io.Writer, header []string, format func(K, V) []string) error {
+	return m.export(w, ',', header, format)
+}
+
+// ExportTSV is like ExportCSV, but separates fields with tabs.
+func (m *Map[K, V]) ExportTSV(w io.Writer, header []string, format func(K, V) []string) error {
+	return m.export(w, '\t', header, format)
+}
+
+func (m *Map[K, V]) export(w io.Writer, comma rune, header []string, format func(K, V) []string) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	if header != nil {
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+	}
+	var err error
+	m.Range(func(key K, value V) bool {
+		err = cw.Write(format(key, value))
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
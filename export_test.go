@@ -0,0 +1,52 @@
+package syncmapt_test
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/holdno/syncmapt/syncmapttest"
+)
+
+func TestExportCSV(t *testing.T) {
+	m := syncmapttest.New(map[string]int{"a,b": 1, "c": 2})
+	format := func(k string, v int) []string { return []string{k, strconv.Itoa(v)} }
+
+	var b strings.Builder
+	if err := m.ExportCSV(&b, []string{"key", "count"}, format); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if lines[0] != "key,count" {
+		t.Fatal("unexpected header", lines[0])
+	}
+	rows := lines[1:]
+	slices.Sort(rows)
+	if want := []string{`"a,b",1`, "c,2"}; !slices.Equal(rows, want) {
+		t.Fatalf("rows = %q, want %q", rows, want)
+	}
+
+	b.Reset()
+	if err := m.ExportTSV(&b, nil, format); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "a,b\t1\n") || strings.Contains(b.String(), "key") {
+		t.Fatalf("unexpected TSV output %q", b.String())
+	}
+}
+
+type failingWriter struct{}
+
+var errWrite = errors.New("write failed")
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errWrite }
+
+func TestExportCSVError(t *testing.T) {
+	m := syncmapttest.New(map[int]int{1: 1})
+	err := m.ExportCSV(failingWriter{}, nil, func(k, v int) []string { return []string{strconv.Itoa(k)} })
+	if !errors.Is(err, errWrite) {
+		t.Fatal("want write error, got", err)
+	}
+}
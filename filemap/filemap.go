@@ -0,0 +1,327 @@
+// Package filemap provides a read-only map of string keys to byte values
+// stored in a file and memory-mapped on access, for lookup tables too large
+// to hold comfortably on the Go heap.
+//
+// A file is written once by Write and then opened, possibly by many
+// processes, with Open. Values returned by a Map point into the mapping: they
+// cost no heap memory, are paged in by the operating system as they are used,
+// and remain valid until the Map is closed. On platforms without mmap, such
+// as js/wasm, Open reads the whole file into memory instead.
+package filemap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"math/bits"
+	"os"
+	"path/filepath"
+
+	"github.com/holdno/syncmapt"
+)
+
+// The file is a header, the records, and a hash table of fixed-size slots
+// pointing at the records. All integers are little-endian.
+//
+//	header: "SMTF" | version (uint32) | entries (uint64) | table offset (uint64) | slots (uint64)
+//	record: len(key) (uvarint) | len(value) (uvarint) | key | value
+//	slot:   hash of key (uint64, 0 if empty) | record offset (uint64)
+//
+// The number of slots is a power of two at least twice the number of
+// entries, and collisions are resolved by linear probing.
+const (
+	magic      = "SMTF"
+	version    = 1
+	headerSize = 32
+	slotSize   = 16
+)
+
+// ErrFormat is returned by Open for files that were not written by Write or
+// are truncated.
+var ErrFormat = errors.New("filemap: invalid file format")
+
+// hash returns the FNV-1a hash of key, which is never 0.
+func hash(key string) uint64 {
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= 1099511628211
+	}
+	if h == 0 {
+		h = 1
+	}
+	return h
+}
+
+// Write writes the entries of seq to a file at path, replacing any file
+// there. If seq yields a key more than once, the last value is kept.
+//
+// Values are streamed to the file as they are yielded; only 16 bytes per
+// entry are held in memory to build the hash table. The file is written
+// under a temporary name and renamed into place, so a Map opened on the old
+// file keeps working.
+func Write(path string, seq iter.Seq2[string, []byte]) (err error) {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	w := bufio.NewWriterSize(f, 1<<20)
+	if _, err := w.Write(make([]byte, headerSize)); err != nil {
+		return err
+	}
+	type indexed struct{ hash, off uint64 }
+	var index []indexed
+	off := uint64(headerSize)
+	var buf [2 * binary.MaxVarintLen64]byte
+	for k, v := range seq {
+		n := binary.PutUvarint(buf[:], uint64(len(k)))
+		n += binary.PutUvarint(buf[n:], uint64(len(v)))
+		if _, err := w.Write(buf[:n]); err != nil {
+			return err
+		}
+		if _, err := w.WriteString(k); err != nil {
+			return err
+		}
+		if _, err := w.Write(v); err != nil {
+			return err
+		}
+		index = append(index, indexed{hash(k), off})
+		off += uint64(n + len(k) + len(v))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	slots := uint64(2)
+	for slots < 2*uint64(len(index)) {
+		slots <<= 1
+	}
+	table := make([]byte, slots*slotSize)
+	entries := uint64(0)
+	for _, e := range index {
+		for i := e.hash & (slots - 1); ; i = (i + 1) & (slots - 1) {
+			slot := table[i*slotSize:]
+			h := binary.LittleEndian.Uint64(slot)
+			if h == 0 {
+				binary.LittleEndian.PutUint64(slot, e.hash)
+				binary.LittleEndian.PutUint64(slot[8:], e.off)
+				entries++
+				break
+			}
+			if h != e.hash {
+				continue
+			}
+			same, err := sameKey(f, binary.LittleEndian.Uint64(slot[8:]), e.off)
+			if err != nil {
+				return err
+			}
+			if same {
+				// A later record for the same key replaces the earlier one.
+				binary.LittleEndian.PutUint64(slot[8:], e.off)
+				break
+			}
+		}
+	}
+	if _, err := f.WriteAt(table, int64(off)); err != nil {
+		return err
+	}
+
+	var header [headerSize]byte
+	copy(header[:], magic)
+	binary.LittleEndian.PutUint32(header[4:], version)
+	binary.LittleEndian.PutUint64(header[8:], entries)
+	binary.LittleEndian.PutUint64(header[16:], off)
+	binary.LittleEndian.PutUint64(header[24:], slots)
+	if _, err := f.WriteAt(header[:], 0); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// sameKey reports whether the records at offsets a and b of f have the same
+// key.
+func sameKey(f *os.File, a, b uint64) (bool, error) {
+	ka, err := readKey(f, a)
+	if err != nil {
+		return false, err
+	}
+	kb, err := readKey(f, b)
+	if err != nil {
+		return false, err
+	}
+	return ka == kb, nil
+}
+
+func readKey(f *os.File, off uint64) (string, error) {
+	r := bufio.NewReader(io.NewSectionReader(f, int64(off), 1<<62))
+	klen, err := binary.ReadUvarint(r)
+	if err != nil {
+		return "", err
+	}
+	if _, err := binary.ReadUvarint(r); err != nil {
+		return "", err
+	}
+	key := make([]byte, klen)
+	if _, err := io.ReadFull(r, key); err != nil {
+		return "", err
+	}
+	return string(key), nil
+}
+
+// A Map is a read-only view of a file written by Write. It is safe for
+// concurrent use, except that it must not be used during or after Close.
+type Map struct {
+	data    []byte
+	table   []byte
+	mask    uint64
+	entries int
+	unmap   func([]byte) error
+}
+
+var _ syncmapt.ReadOnlyMap[string, []byte] = (*Map)(nil)
+
+// Open maps the file at path into memory. Only the header is checked: a
+// record damaged after the file was written reads as missing.
+func Open(path string) (*Map, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := fi.Size()
+	if size < headerSize || uint64(size) > uint64(maxInt) {
+		return nil, fmt.Errorf("filemap: %s: %w", path, ErrFormat)
+	}
+	data, unmap, err := mapFile(f, int(size))
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Map{data: data, unmap: unmap}
+	if err := m.init(); err != nil {
+		unmap(data)
+		return nil, fmt.Errorf("filemap: %s: %w", path, err)
+	}
+	return m, nil
+}
+
+const maxInt = int(^uint(0) >> 1)
+
+func (m *Map) init() error {
+	h := m.data[:headerSize]
+	if string(h[:4]) != magic || binary.LittleEndian.Uint32(h[4:]) != version {
+		return ErrFormat
+	}
+	entries := binary.LittleEndian.Uint64(h[8:])
+	off := binary.LittleEndian.Uint64(h[16:])
+	slots := binary.LittleEndian.Uint64(h[24:])
+	if slots == 0 || slots&(slots-1) != 0 || entries > slots ||
+		off < headerSize || off > uint64(len(m.data)) {
+		return ErrFormat
+	}
+	if hi, lo := bits.Mul64(slots, slotSize); hi != 0 || lo != uint64(len(m.data))-off {
+		return ErrFormat
+	}
+	m.table = m.data[off:]
+	m.mask = slots - 1
+	m.entries = int(entries)
+	return nil
+}
+
+// record returns the key and value of the record at off, or ok == false if
+// it is out of bounds.
+func (m *Map) record(off uint64) (key, value []byte, ok bool) {
+	if off >= uint64(len(m.data)) {
+		return nil, nil, false
+	}
+	b := m.data[off:]
+	klen, n := binary.Uvarint(b)
+	if n <= 0 {
+		return nil, nil, false
+	}
+	b = b[n:]
+	vlen, n := binary.Uvarint(b)
+	if n <= 0 {
+		return nil, nil, false
+	}
+	b = b[n:]
+	if klen > uint64(len(b)) || vlen > uint64(len(b))-klen {
+		return nil, nil, false
+	}
+	return b[:klen], b[klen : klen+vlen : klen+vlen], true
+}
+
+// Load returns the value stored in the file for key. The value points into
+// the mapping: it must not be modified, and must not be used after Close.
+func (m *Map) Load(key string) (value []byte, ok bool) {
+	if m.data == nil {
+		return nil, false
+	}
+	h := hash(key)
+	for i := h & m.mask; ; i = (i + 1) & m.mask {
+		slot := m.table[i*slotSize:]
+		switch binary.LittleEndian.Uint64(slot) {
+		case 0:
+			return nil, false
+		case h:
+			k, v, ok := m.record(binary.LittleEndian.Uint64(slot[8:]))
+			if ok && string(k) == key {
+				return v, true
+			}
+		}
+	}
+}
+
+// Range calls f sequentially for each key and value in the file, in an
+// unspecified order, until f returns false. Values point into the mapping
+// as with Load.
+func (m *Map) Range(f func(key string, value []byte) bool) {
+	for i := 0; i+slotSize <= len(m.table); i += slotSize {
+		slot := m.table[i:]
+		if binary.LittleEndian.Uint64(slot) == 0 {
+			continue
+		}
+		k, v, ok := m.record(binary.LittleEndian.Uint64(slot[8:]))
+		if !ok {
+			continue
+		}
+		if !f(string(k), v) {
+			return
+		}
+	}
+}
+
+// Len returns the number of entries in the file.
+func (m *Map) Len() int {
+	return m.entries
+}
+
+// Close unmaps the file. Values returned by m must not be used afterwards.
+func (m *Map) Close() error {
+	if m.data == nil {
+		return nil
+	}
+	data, unmap := m.data, m.unmap
+	*m = Map{}
+	return unmap(data)
+}
@@ -0,0 +1,134 @@
+package filemap_test
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/holdno/syncmapt/filemap"
+)
+
+func TestWriteOpen(t *testing.T) {
+	want := map[string][]byte{"": []byte("empty key"), "empty value": {}}
+	for i := 0; i < 1000; i++ {
+		want[fmt.Sprint("key", i)] = []byte(fmt.Sprint("value", i))
+	}
+	path := filepath.Join(t.TempDir(), "table")
+	seq := func(yield func(string, []byte) bool) {
+		// A stale value for key0 is replaced by the later one from want.
+		if !yield("key0", []byte("stale")) {
+			return
+		}
+		for k, v := range want {
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+	if err := filemap.Write(path, seq); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := filemap.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if m.Len() != len(want) {
+		t.Errorf("Len() = %d, want %d", m.Len(), len(want))
+	}
+	for k, v := range want {
+		if got, ok := m.Load(k); !ok || string(got) != string(v) {
+			t.Errorf("Load(%q) = %q, %v; want %q, true", k, got, ok, v)
+		}
+	}
+	if v, ok := m.Load("missing"); ok {
+		t.Errorf("Load(missing) = %q, true; want false", v)
+	}
+	got := make(map[string][]byte)
+	m.Range(func(k string, v []byte) bool {
+		got[k] = v
+		return true
+	})
+	if !maps.EqualFunc(got, want, func(a, b []byte) bool { return string(a) == string(b) }) {
+		t.Errorf("Range visited %d entries, want the %d written", len(got), len(want))
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.Load("key1"); ok {
+		t.Error("Load after Close reported a value")
+	}
+}
+
+func TestWriteEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "table")
+	var empty iter.Seq2[string, []byte] = func(func(string, []byte) bool) {}
+	if err := filemap.Write(path, empty); err != nil {
+		t.Fatal(err)
+	}
+	m, err := filemap.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if _, ok := m.Load(""); ok || m.Len() != 0 {
+		t.Errorf("empty file: Load reported a value or Len() = %d", m.Len())
+	}
+}
+
+func TestOpenInvalid(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"short":     "SMTF",
+		"magic":     "not a filemap file, just some bytes",
+		"truncated": "",
+	} {
+		path := filepath.Join(dir, name)
+		if name == "truncated" {
+			if err := filemap.Write(path, maps.All(map[string][]byte{"a": []byte("b")})); err != nil {
+				t.Fatal(err)
+			}
+			b, _ := os.ReadFile(path)
+			data = string(b[:len(b)-1])
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := filemap.Open(path); !errors.Is(err, filemap.ErrFormat) {
+			t.Errorf("Open(%s) error = %v, want ErrFormat", name, err)
+		}
+	}
+}
+
+func BenchmarkLoad(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "table")
+	keys := make([]string, 1<<16)
+	for i := range keys {
+		keys[i] = fmt.Sprint("key", i)
+	}
+	err := filemap.Write(path, func(yield func(string, []byte) bool) {
+		for _, k := range keys {
+			if !yield(k, []byte(k)) {
+				return
+			}
+		}
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	m, err := filemap.Open(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer m.Close()
+	b.ReportAllocs()
+	for i := 0; b.Loop(); i++ {
+		m.Load(keys[i&(len(keys)-1)])
+	}
+}
@@ -0,0 +1,114 @@
+package syncmapt
+
+import "errors"
+
+// errFlightPanic is returned to callers that were waiting for a call of
+// loadOrCompute that panicked.
+var errFlightPanic = errors.New("syncmapt: computing function panicked")
+
+// A flightCall is an in-flight or completed call of loadOrCompute.
+type flightCall[V any] struct {
+	done chan struct{} // closed when val and err are set
+	val  V
+	err  error
+}
+
+// LoadOrStoreFunc returns the existing value for the key if present.
+// Otherwise, it stores and returns the value returned by f. The loaded result
+// is true if the value was loaded, false if stored.
+//
+// f is only called if the key is absent, and concurrent calls for the same
+// key are deduplicated: only one of them calls f, and the others wait for it
+// and load the value it returned. f is called without any internal lock
+// held, so it may call methods of m. If a value is stored for the key by
+// other means while f runs, that value is returned with loaded true, and the
+// value returned by f is discarded.
+//
+// If f panics, the panic propagates to the caller that called f, nothing is
+// stored, and the waiting callers try again.
+func (m *Map[K, V]) LoadOrStoreFunc(key K, f func() V) (actual V, loaded bool) {
+	if m.Closed() {
+		return m.Load(key)
+	}
+	actual, loaded, _ = m.loadOrCompute(m.key(key), func() (V, error) { return f(), nil })
+	return actual, loaded
+}
+
+// LoadOrCompute returns the existing value for the key if present. Otherwise,
+// it calls f and, if f succeeds, stores and returns its result. It is meant
+// for filling caches.
+//
+// Concurrent calls for the same key are deduplicated as by LoadOrStoreFunc:
+// only one of them runs f, and the others wait for it and receive the same
+// value or error. Nothing is stored when f fails, so the next call for the key
+// runs f again. If the Map is closed and has no value for the key, it returns
+// ErrClosed.
+func (m *Map[K, V]) LoadOrCompute(key K, f func() (V, error)) (V, error) {
+	if m.Closed() {
+		if v, ok := m.Load(key); ok {
+			return v, nil
+		}
+		var zero V
+		return zero, ErrClosed
+	}
+	v, _, err := m.loadOrCompute(m.key(key), f)
+	return v, err
+}
+
+// loadOrCompute returns the value for key, computing it with f and storing it
+// if there is none, with at most one call of f in progress per key. The key
+// must already have been transformed.
+func (m *Map[K, V]) loadOrCompute(key K, f func() (V, error)) (actual V, loaded bool, err error) {
+	for {
+		if v, ok := m.load(key); ok {
+			return v, true, nil
+		}
+
+		m.flightMu.Lock()
+		if c, ok := m.flights[key]; ok {
+			m.flightMu.Unlock()
+			<-c.done
+			if c.err == errFlightPanic {
+				continue
+			}
+			return c.val, true, c.err
+		}
+		// Check again now that a finished call, which stores its value before
+		// it is removed from flights, cannot be missed.
+		if v, ok := m.load(key); ok {
+			m.flightMu.Unlock()
+			return v, true, nil
+		}
+		c := &flightCall[V]{done: make(chan struct{})}
+		if m.flights == nil {
+			m.flights = make(map[K]*flightCall[V])
+		}
+		m.flights[key] = c
+		m.flightMu.Unlock()
+
+		return m.runFlight(key, c, f)
+	}
+}
+
+// runFlight calls f for key, stores its result unless it fails, and publishes
+// the outcome through c.
+func (m *Map[K, V]) runFlight(key K, c *flightCall[V], f func() (V, error)) (actual V, loaded bool, err error) {
+	normalReturn := false
+	defer func() {
+		if !normalReturn {
+			c.err = errFlightPanic
+		}
+		m.flightMu.Lock()
+		delete(m.flights, key)
+		m.flightMu.Unlock()
+		close(c.done)
+	}()
+
+	v, err := f()
+	if err == nil {
+		v, loaded = m.loadOrStore(key, v)
+	}
+	c.val, c.err = v, err
+	normalReturn = true
+	return v, loaded, err
+}
@@ -0,0 +1,122 @@
+package syncmapt_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/holdno/syncmapt"
+)
+
+func TestLoadOrStoreFunc(t *testing.T) {
+	m := new(syncmapt.Map[string, int])
+	m.Store("present", 1)
+	if v, loaded := m.LoadOrStoreFunc("present", func() int {
+		t.Fatal("f called for a present key")
+		return 0
+	}); !loaded || v != 1 {
+		t.Fatalf("LoadOrStoreFunc(present) = %v, %v; want 1, true", v, loaded)
+	}
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	f := func() int {
+		calls.Add(1)
+		<-release
+		return 2
+	}
+	const callers = 8
+	var wg sync.WaitGroup
+	var stored atomic.Int32
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, loaded := m.LoadOrStoreFunc("absent", f)
+			if v != 2 {
+				t.Errorf("LoadOrStoreFunc(absent) = %v", v)
+			}
+			if !loaded {
+				stored.Add(1)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if calls.Load() != 1 || stored.Load() != 1 {
+		t.Fatalf("f called %d times, value stored by %d callers; want 1, 1", calls.Load(), stored.Load())
+	}
+}
+
+func TestLoadOrStoreFuncPanic(t *testing.T) {
+	m := new(syncmapt.Map[string, int])
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan any)
+	go func() {
+		defer func() { done <- recover() }()
+		m.LoadOrStoreFunc("k", func() int {
+			close(started)
+			<-release
+			panic("boom")
+		})
+	}()
+	<-started
+
+	waiter := make(chan int)
+	go func() {
+		v, _ := m.LoadOrStoreFunc("k", func() int { return 3 })
+		waiter <- v
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	if r := <-done; r != "boom" {
+		t.Fatalf("recovered %v, want the panic of f", r)
+	}
+	if v := <-waiter; v != 3 {
+		t.Fatalf("waiter got %v, want 3 from its own retry", v)
+	}
+}
+
+func TestLoadOrCompute(t *testing.T) {
+	m := new(syncmapt.Map[string, int])
+	errFill := errors.New("fill failed")
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	failing := func() (int, error) {
+		calls.Add(1)
+		<-release
+		return 0, errFill
+	}
+	const callers = 8
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := m.LoadOrCompute("k", failing); err != errFill {
+				t.Errorf("LoadOrCompute = %v, want the error of f", err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if calls.Load() != 1 {
+		t.Fatalf("f called %d times, want 1", calls.Load())
+	}
+	if _, ok := m.Load("k"); ok {
+		t.Fatal("a failed LoadOrCompute stored a value")
+	}
+
+	if v, err := m.LoadOrCompute("k", func() (int, error) { return 5, nil }); err != nil || v != 5 {
+		t.Fatalf("LoadOrCompute = %v, %v; want 5, nil", v, err)
+	}
+	if v, err := m.LoadOrCompute("k", failing); err != nil || v != 5 {
+		t.Fatalf("LoadOrCompute of a filled key = %v, %v; want 5, nil", v, err)
+	}
+}
@@ -1,3 +1,5 @@
 module github.com/holdno/syncmapt
 
-go 1.18
+go 1.24
+
+require golang.org/x/text v0.21.0
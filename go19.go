@@ -5,6 +5,7 @@
 package syncmapt
 
 import (
+	"runtime"
 	"sync"
 	"sync/atomic"
 	"unsafe"
@@ -26,6 +27,10 @@ import (
 //
 // The zero Map is empty and ready for use. A Map must not be copied after first use.
 type Map[K comparable, V any] struct {
+	// Fields accessed atomically use the sync/atomic types, which are
+	// correctly aligned on 32-bit platforms wherever they are placed; plain
+	// 64-bit integers must never be accessed with the sync/atomic functions.
+
 	mu sync.Mutex
 
 	// read contains the portion of the map's contents that are safe for
@@ -58,6 +63,51 @@ type Map[K comparable, V any] struct {
 	// map, the dirty map will be promoted to the read map (in the unamended
 	// state) and the next store to the map will make a new dirty copy.
 	misses int
+
+	// cfg holds the options the Map was created with by New. It is nil for the
+	// zero Map.
+	cfg *config[K, V]
+
+	// nwaiters is the number of keys in waiters. Stores only take waitMu when
+	// it is non-zero.
+	nwaiters atomic.Int32
+
+	waitMu sync.Mutex
+	// waiters holds the goroutines blocked in WaitForValue, by key.
+	waiters map[K]*waiter
+
+	closed atomic.Bool // set by Close
+
+	// observers are told about every change to the map's contents. The slice
+	// is copied on write, under obsMu.
+	observers atomic.Pointer[[]observer[K]]
+	obsMu     sync.Mutex
+
+	// hooks are the callbacks registered with OnStore and OnDelete. They are
+	// copied on write, under obsMu.
+	hooks atomic.Pointer[hooks[K, V]]
+
+	// count is the number of entries holding a value in the current storage,
+	// or nil if there are none. Clear and ReplaceAll replace it along with
+	// the storage, so that operations still finishing on detached entries
+	// cannot skew the count of the new ones.
+	count atomic.Pointer[atomic.Int64]
+
+	// stats counts the operations of a map created WithStats.
+	stats opStats
+
+	flightMu sync.Mutex
+	// flights holds the calls in progress of LoadOrStoreFunc and
+	// LoadOrCompute, by key.
+	flights map[K]*flightCall[V]
+
+	lockMu sync.Mutex
+	// locks holds the key locks of LockKey, WithLock and Txn that are held
+	// or waited for, by hash of their keys under lockSeed.
+	locks map[uint64]*keyLock
+
+	// ver orders stores against the Snapshots of the map.
+	ver versions
 }
 
 // readOnly is an immutable struct stored atomically in the Map.read field.
@@ -70,12 +120,18 @@ type readOnly[K comparable, V any] struct {
 // from the dirty map.
 var expunged = unsafe.Pointer(new(any))
 
+// moving is an arbitrary pointer that marks an entry whose value is being
+// moved to another key by Move. It is only set with mu held, and only for the
+// few atomic operations it takes to publish the value under the new key.
+var moving = unsafe.Pointer(new(any))
+
 // An entry is a slot in the map corresponding to a particular key.
 type entry[V any] struct {
-	// p points to the interface{} value stored for the entry.
+	// p points to the value stored for the entry, or to its current version
+	// in a map created WithSnapshots.
 	//
-	// If p == nil, the entry has been deleted, and either m.dirty == nil or
-	// m.dirty[key] is e.
+	// If p == nil or p is a dead version, the entry has been deleted, and
+	// either m.dirty == nil or m.dirty[key] is e.
 	//
 	// If p == expunged, the entry has been deleted, m.dirty != nil, and the entry
 	// is missing from m.dirty.
@@ -83,34 +139,187 @@ type entry[V any] struct {
 	// Otherwise, the entry is valid and recorded in m.read.m[key] and, if m.dirty
 	// != nil, in m.dirty[key].
 	//
-	// An entry can be deleted by atomic replacement with nil: when m.dirty is
-	// next created, it will atomically replace nil with expunged and leave
-	// m.dirty[key] unset.
+	// An entry can be deleted by atomic replacement with nil, or with a dead
+	// version if the map keeps versions for Snapshots: when m.dirty is next
+	// created, it will atomically replace nil, or a dead version that no
+	// Snapshot can need, with expunged and leave m.dirty[key] unset.
 	//
 	// An entry's associated value can be updated by atomic replacement, provided
 	// p != expunged. If p == expunged, an entry's associated value can be updated
 	// only after first setting m.dirty[key] = e so that lookups using the dirty
 	// map find the entry.
-	p unsafe.Pointer // *interface{}
+	//
+	// If p == moving, the entry's value is being moved to another key, after
+	// which the entry is deleted. Lock-free operations wait for that to
+	// happen.
+	p unsafe.Pointer // *V, or *version[V] in a map created WithSnapshots
+
+	// n counts the entries holding a value in the storage the entry belongs
+	// to. Every change of p between holding a value and not holding one
+	// updates it.
+	n *atomic.Int64
 }
 
-func (m *Map[K, V]) Len() int {
-	var l int
-	m.Range(func(_ K, _ V) bool {
-		l++
+// A version is a value stored in an entry of a map created WithSnapshots, or
+// the deletion of one. Every change to such an entry publishes a new version
+// linked to the one it replaces, so that a Snapshot taken before the change
+// can still find the value it saw. Links that no Snapshot can need are
+// dropped.
+type version[V any] struct {
+	v    V
+	dead bool // the version records a deletion
+
+	// born is one more than the snapshot epoch the version was published in,
+	// or 0 until the operation publishing it, or the first one to observe it,
+	// reads the epoch; see versions.
+	born atomic.Uint64
+
+	// prev points to the version this one replaced, if a Snapshot may need
+	// it. It is set before the version is published and only cleared after.
+	prev unsafe.Pointer // *version[V]
+}
+
+// valueOf returns the value stored in what p points to, if any. In a map
+// created WithSnapshots, it settles the version p points to: a Snapshot taken
+// after a value was observed must see it too.
+func valueOf[V any](vs *versions, p unsafe.Pointer) (*V, bool) {
+	if p == nil || p == expunged {
+		return nil, false
+	}
+	if !vs.on {
+		return (*V)(p), true
+	}
+	v := (*version[V])(p)
+	if v.dead {
+		return nil, false
+	}
+	v.bornIn(vs)
+	return &v.v, true
+}
+
+// newValue returns what an entry points to when i is stored in it.
+func newValue[V any](vs *versions, i V) unsafe.Pointer {
+	if !vs.on {
+		return unsafe.Pointer(&i)
+	}
+	return unsafe.Pointer(&version[V]{v: i})
+}
+
+// tombstone returns what an entry points to when its value is deleted.
+func tombstone[V any](vs *versions) unsafe.Pointer {
+	if !vs.on {
+		return nil
+	}
+	return unsafe.Pointer(&version[V]{dead: true})
+}
+
+// loadPointer atomically loads e.p, waiting out a Move of the entry in
+// progress.
+func (e *entry[V]) loadPointer() unsafe.Pointer {
+	p := atomic.LoadPointer(&e.p)
+	for p == moving {
+		runtime.Gosched()
+		p = atomic.LoadPointer(&e.p)
+	}
+	return p
+}
+
+// publish atomically replaces p, the current value of e, with nv, which must
+// not have been published yet. It reports false, leaving e unchanged, if p is
+// no longer current.
+func (e *entry[V]) publish(vs *versions, p, nv unsafe.Pointer) bool {
+	if !vs.on {
+		if !atomic.CompareAndSwapPointer(&e.p, p, nv) {
+			return false
+		}
+		e.recount(vs, p, nv)
 		return true
-	})
-	return l
+	}
+	if p != nil {
+		// Make sure versions are born in the order they replace each other.
+		(*version[V])(p).bornIn(vs)
+	}
+	v := (*version[V])(nv)
+	v.prev = p
+	if !atomic.CompareAndSwapPointer(&e.p, p, nv) {
+		return false
+	}
+	v.settle(vs)
+	e.recount(vs, p, nv)
+	return true
+}
+
+// recount updates the entry count after e changed from p to nv.
+func (e *entry[V]) recount(vs *versions, p, nv unsafe.Pointer) {
+	switch was, is := holds[V](vs, p), holds[V](vs, nv); {
+	case is && !was:
+		e.n.Add(1)
+	case was && !is:
+		e.n.Add(-1)
+	}
 }
 
-func newEntry[V any](i V) *entry[V] {
-	return &entry[V]{p: unsafe.Pointer(&i)}
+// Len returns the number of entries in the map. It takes constant time: the
+// map keeps a count of its entries, updated by every operation that adds or
+// deletes one. While the map is modified concurrently, the count reflects
+// each of those operations either fully or not at all.
+func (m *Map[K, V]) Len() int {
+	n := m.count.Load()
+	if n == nil {
+		return 0
+	}
+	return int(n.Load())
+}
+
+// countLocked returns the counter of the current storage of m, creating it
+// if the storage is still empty.
+func (m *Map[K, V]) countLocked() *atomic.Int64 {
+	n := m.count.Load()
+	if n == nil {
+		n = new(atomic.Int64)
+		m.count.Store(n)
+	}
+	return n
+}
+
+// newEntry returns an entry holding i, to be counted in n by the caller.
+func newEntry[V any](vs *versions, n *atomic.Int64, i V) *entry[V] {
+	p := newValue(vs, i)
+	if vs.on {
+		// The version is born before the entry can be seen by any Snapshot.
+		(*version[V])(p).born.Store(1)
+	}
+	return &entry[V]{p: p, n: n}
+}
+
+// newEntryLocked returns an entry holding i, counted in the current storage
+// of m, for adding to the dirty map.
+func (m *Map[K, V]) newEntryLocked(i V) *entry[V] {
+	n := m.countLocked()
+	n.Add(1)
+	return newEntry(&m.ver, n, i)
+}
+
+// holds reports whether p, the value of an entry, is a value rather than its
+// absence.
+func holds[V any](vs *versions, p unsafe.Pointer) bool {
+	if p == nil || p == expunged || p == moving {
+		return false
+	}
+	return !vs.on || !(*version[V])(p).dead
 }
 
 // Load returns the value stored in the map for a key, or nil if no
 // value is present.
 // The ok result indicates whether value was found in the map.
 func (m *Map[K, V]) Load(key K) (value V, ok bool) {
+	value, ok = m.load(m.key(key))
+	m.countLoad(ok)
+	return value, ok
+}
+
+// load is Load for a key that has already been transformed.
+func (m *Map[K, V]) load(key K) (value V, ok bool) {
 	read, _ := m.read.Load().(readOnly[K, V])
 	e, ok := read.m[key]
 	if !ok && read.amended {
@@ -132,21 +341,26 @@ func (m *Map[K, V]) Load(key K) (value V, ok bool) {
 	if !ok {
 		return value, false
 	}
-	return e.load()
+	return e.load(&m.ver)
 }
 
-func (e *entry[V]) load() (value V, ok bool) {
-	p := atomic.LoadPointer(&e.p)
-	if p == nil || p == expunged {
+func (e *entry[V]) load(vs *versions) (value V, ok bool) {
+	p, ok := valueOf[V](vs, e.loadPointer())
+	if !ok {
 		return value, false
 	}
-	return *(*V)(p), true
+	return *p, true
 }
 
 // Store sets the value for a key.
 func (m *Map[K, V]) Store(key K, value V) {
+	if m.Closed() {
+		return
+	}
+	key = m.key(key)
 	read, _ := m.read.Load().(readOnly[K, V])
-	if e, ok := read.m[key]; ok && e.tryStore(&value) {
+	if e, ok := read.m[key]; ok && e.tryStore(&m.ver, value) {
+		m.stored(key, value)
 		return
 	}
 
@@ -158,9 +372,9 @@ func (m *Map[K, V]) Store(key K, value V) {
 			// non-nil dirty map and this entry is not in it.
 			m.dirty[key] = e
 		}
-		e.storeLocked(&value)
+		e.storeLocked(&m.ver, value)
 	} else if e, ok := m.dirty[key]; ok {
-		e.storeLocked(&value)
+		e.storeLocked(&m.ver, value)
 	} else {
 		if !read.amended {
 			// We're adding the first new key to the dirty map.
@@ -168,22 +382,24 @@ func (m *Map[K, V]) Store(key K, value V) {
 			m.dirtyLocked()
 			m.read.Store(readOnly[K, V]{m: read.m, amended: true})
 		}
-		m.dirty[key] = newEntry(value)
+		m.dirty[key] = m.newEntryLocked(value)
 	}
 	m.mu.Unlock()
+	m.stored(key, value)
 }
 
 // tryStore stores a value if the entry has not been expunged.
 //
 // If the entry is expunged, tryStore returns false and leaves the entry
 // unchanged.
-func (e *entry[V]) tryStore(i *V) bool {
+func (e *entry[V]) tryStore(vs *versions, i V) bool {
+	nv := newValue(vs, i)
 	for {
-		p := atomic.LoadPointer(&e.p)
+		p := e.loadPointer()
 		if p == expunged {
 			return false
 		}
-		if atomic.CompareAndSwapPointer(&e.p, p, unsafe.Pointer(i)) {
+		if e.publish(vs, p, nv) {
 			return true
 		}
 	}
@@ -200,19 +416,30 @@ func (e *entry[V]) unexpungeLocked() (wasExpunged bool) {
 // storeLocked unconditionally stores a value to the entry.
 //
 // The entry must be known not to be expunged.
-func (e *entry[V]) storeLocked(i *V) {
-	atomic.StorePointer(&e.p, unsafe.Pointer(i))
+func (e *entry[V]) storeLocked(vs *versions, i V) {
+	e.tryStore(vs, i)
 }
 
 // LoadOrStore returns the existing value for the key if present.
 // Otherwise, it stores and returns the given value.
 // The loaded result is true if the value was loaded, false if stored.
 func (m *Map[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	if m.Closed() {
+		return m.Load(key)
+	}
+	return m.loadOrStore(m.key(key), value)
+}
+
+// loadOrStore is LoadOrStore for a key that has already been transformed.
+func (m *Map[K, V]) loadOrStore(key K, value V) (actual V, loaded bool) {
 	// Avoid locking if it's a clean hit.
 	read, _ := m.read.Load().(readOnly[K, V])
 	if e, ok := read.m[key]; ok {
-		actual, loaded, ok := e.tryLoadOrStore(value)
+		actual, loaded, ok := e.tryLoadOrStore(&m.ver, value)
 		if ok {
+			if !loaded {
+				m.stored(key, value)
+			}
 			return actual, loaded
 		}
 	}
@@ -223,9 +450,9 @@ func (m *Map[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
 		if e.unexpungeLocked() {
 			m.dirty[key] = e
 		}
-		actual, loaded, _ = e.tryLoadOrStore(value)
+		actual, loaded, _ = e.tryLoadOrStore(&m.ver, value)
 	} else if e, ok := m.dirty[key]; ok {
-		actual, loaded, _ = e.tryLoadOrStore(value)
+		actual, loaded, _ = e.tryLoadOrStore(&m.ver, value)
 		m.missLocked()
 	} else {
 		if !read.amended {
@@ -234,11 +461,14 @@ func (m *Map[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
 			m.dirtyLocked()
 			m.read.Store(readOnly[K, V]{m: read.m, amended: true})
 		}
-		m.dirty[key] = newEntry(value)
+		m.dirty[key] = m.newEntryLocked(value)
 		actual, loaded = value, false
 	}
 	m.mu.Unlock()
 
+	if !loaded {
+		m.stored(key, value)
+	}
 	return actual, loaded
 }
 
@@ -247,29 +477,29 @@ func (m *Map[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
 //
 // If the entry is expunged, tryLoadOrStore leaves the entry unchanged and
 // returns with ok==false.
-func (e *entry[V]) tryLoadOrStore(i V) (actual V, loaded, ok bool) {
-	p := atomic.LoadPointer(&e.p)
+func (e *entry[V]) tryLoadOrStore(vs *versions, i V) (actual V, loaded, ok bool) {
+	p := e.loadPointer()
 	if p == expunged {
 		return actual, false, false
 	}
-	if p != nil {
-		return *(*V)(p), true, true
+	if v, ok := valueOf[V](vs, p); ok {
+		return *v, true, true
 	}
 
-	// Copy the interface after the first load to make this method more amenable
-	// to escape analysis: if we hit the "load" path or the entry is expunged, we
-	// shouldn't bother heap-allocating.
-	ic := i
+	// Allocate the value after the first load to make this method more
+	// amenable to escape analysis: if we hit the "load" path or the entry is
+	// expunged, we shouldn't bother heap-allocating.
+	nv := newValue(vs, i)
 	for {
-		if atomic.CompareAndSwapPointer(&e.p, nil, unsafe.Pointer(&ic)) {
+		if e.publish(vs, p, nv) {
 			return i, false, true
 		}
-		p = atomic.LoadPointer(&e.p)
+		p = e.loadPointer()
 		if p == expunged {
 			return actual, false, false
 		}
-		if p != nil {
-			return *(*V)(p), true, true
+		if v, ok := valueOf[V](vs, p); ok {
+			return *v, true, true
 		}
 	}
 }
@@ -277,6 +507,10 @@ func (e *entry[V]) tryLoadOrStore(i V) (actual V, loaded, ok bool) {
 // LoadAndDelete deletes the value for a key, returning the previous value if any.
 // The loaded result reports whether the key was present.
 func (m *Map[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	if m.Closed() {
+		return value, false
+	}
+	key = m.key(key)
 	read, _ := m.read.Load().(readOnly[K, V])
 	e, ok := read.m[key]
 	if !ok && read.amended {
@@ -294,24 +528,90 @@ func (m *Map[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
 		m.mu.Unlock()
 	}
 	if ok {
-		return e.delete()
+		if value, loaded = e.delete(&m.ver); loaded {
+			m.deleted(key, value)
+		}
+		return value, loaded
 	}
 	return value, false
 }
 
+// deleteIf deletes the entry for key if its current value satisfies pred,
+// atomically with respect to other operations on key. The key must already
+// have been transformed.
+func (m *Map[K, V]) deleteIf(key K, pred func(V) bool) (deleted bool) {
+	return m.removeIf(key, pred, m.deleted)
+}
+
+// expireIf is like deleteIf, but tells observers that the value expired.
+func (m *Map[K, V]) expireIf(key K, pred func(V) bool) (expired bool) {
+	return m.removeIf(key, pred, m.expired)
+}
+
+func (m *Map[K, V]) removeIf(key K, pred func(V) bool, removed func(K, V)) bool {
+	read, _ := m.read.Load().(readOnly[K, V])
+	e, ok := read.m[key]
+	if !ok && read.amended {
+		m.mu.Lock()
+		read, _ = m.read.Load().(readOnly[K, V])
+		e, ok = read.m[key]
+		if !ok && read.amended {
+			e, ok = m.dirty[key]
+			// Don't delete key from m.dirty: we still need to do the "compare"
+			// part of the operation. The entry will eventually be expunged when
+			// the dirty map is promoted to the read map.
+			//
+			// Regardless of whether the entry was present, record a miss: this key
+			// will take the slow path until the dirty map is promoted to the read
+			// map.
+			m.missLocked()
+		}
+		m.mu.Unlock()
+	}
+	if !ok {
+		return false
+	}
+	v, ok := e.deleteIf(&m.ver, pred)
+	if ok {
+		removed(key, v)
+	}
+	return ok
+}
+
+// deleteIf deletes the value of e if it satisfies pred, and returns it. If the
+// value changes concurrently, pred is called again with the new one.
+func (e *entry[V]) deleteIf(vs *versions, pred func(V) bool) (value V, deleted bool) {
+	tomb := tombstone[V](vs)
+	for {
+		p := e.loadPointer()
+		v, ok := valueOf[V](vs, p)
+		if !ok || !pred(*v) {
+			return value, false
+		}
+		if e.publish(vs, p, tomb) {
+			return *v, true
+		}
+	}
+}
+
 // Delete deletes the value for a key.
 func (m *Map[K, V]) Delete(key K) {
 	m.LoadAndDelete(key)
 }
 
-func (e *entry[V]) delete() (value V, ok bool) {
+func (e *entry[V]) delete(vs *versions) (value V, ok bool) {
+	var tomb unsafe.Pointer
 	for {
-		p := atomic.LoadPointer(&e.p)
-		if p == nil || p == expunged {
+		p := e.loadPointer()
+		v, ok := valueOf[V](vs, p)
+		if !ok {
 			return value, false
 		}
-		if atomic.CompareAndSwapPointer(&e.p, p, nil) {
-			return *(*V)(p), true
+		if tomb == nil {
+			tomb = tombstone[V](vs)
+		}
+		if e.publish(vs, p, tomb) {
+			return *v, true
 		}
 	}
 }
@@ -350,7 +650,7 @@ func (m *Map[K, V]) Range(f func(key K, value V) bool) {
 	}
 
 	for k, e := range read.m {
-		v, ok := e.load()
+		v, ok := e.load(&m.ver)
 		if !ok {
 			continue
 		}
@@ -371,26 +671,52 @@ func (m *Map[K, V]) missLocked() {
 }
 
 func (m *Map[K, V]) dirtyLocked() {
+	m.dirtySizedLocked(0)
+}
+
+// dirtySizedLocked is dirtyLocked, sizing a new dirty map for extra keys
+// beyond those of the read map.
+func (m *Map[K, V]) dirtySizedLocked(extra int) {
 	if m.dirty != nil {
 		return
 	}
 
 	read, _ := m.read.Load().(readOnly[K, V])
-	m.dirty = make(map[K]*entry[V], len(read.m))
+	size := len(read.m) + extra
+	if m.cfg != nil && m.cfg.capacity > size {
+		size = m.cfg.capacity
+	}
+	m.dirty = make(map[K]*entry[V], size)
 	for k, e := range read.m {
-		if !e.tryExpungeLocked() {
+		if !e.tryExpungeLocked(&m.ver) {
 			m.dirty[k] = e
 		}
 	}
 }
 
-func (e *entry[V]) tryExpungeLocked() (isExpunged bool) {
+// tryExpungeLocked expunges the entry if it is deleted and no Snapshot can
+// need its earlier versions. Otherwise it drops the links to earlier
+// versions that no Snapshot can need any more.
+func (e *entry[V]) tryExpungeLocked(vs *versions) (isExpunged bool) {
 	p := atomic.LoadPointer(&e.p)
-	for p == nil {
-		if atomic.CompareAndSwapPointer(&e.p, nil, expunged) {
+	for p != expunged {
+		if p != nil {
+			if !vs.on {
+				return false
+			}
+			v := (*version[V])(p)
+			if !v.dead {
+				v.trim(vs)
+				return false
+			}
+			if !v.obsolete(vs) {
+				return false
+			}
+		}
+		if atomic.CompareAndSwapPointer(&e.p, p, expunged) {
 			return true
 		}
 		p = atomic.LoadPointer(&e.p)
 	}
-	return p == expunged
+	return true
 }
@@ -0,0 +1,175 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package syncmapt
+
+// This file holds the methods that sync.Map gained in Go 1.20.
+
+// Swap swaps the value for a key and returns the previous value if any.
+// The loaded result reports whether the key was present.
+func (m *Map[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	if m.Closed() {
+		return previous, false
+	}
+	key = m.key(key)
+	read, _ := m.read.Load().(readOnly[K, V])
+	if e, ok := read.m[key]; ok {
+		if v, ok := e.trySwap(&m.ver, value); ok {
+			m.stored(key, value)
+			if v == nil {
+				return previous, false
+			}
+			return *v, true
+		}
+	}
+
+	m.mu.Lock()
+	read, _ = m.read.Load().(readOnly[K, V])
+	if e, ok := read.m[key]; ok {
+		if e.unexpungeLocked() {
+			// The entry was previously expunged, which implies that there is a
+			// non-nil dirty map and this entry is not in it.
+			m.dirty[key] = e
+		}
+		if v := e.swapLocked(&m.ver, value); v != nil {
+			loaded = true
+			previous = *v
+		}
+	} else if e, ok := m.dirty[key]; ok {
+		if v := e.swapLocked(&m.ver, value); v != nil {
+			loaded = true
+			previous = *v
+		}
+	} else {
+		if !read.amended {
+			// We're adding the first new key to the dirty map.
+			// Make sure it is allocated and mark the read-only map as incomplete.
+			m.dirtyLocked()
+			m.read.Store(readOnly[K, V]{m: read.m, amended: true})
+		}
+		m.dirty[key] = m.newEntryLocked(value)
+	}
+	m.mu.Unlock()
+	m.stored(key, value)
+	return previous, loaded
+}
+
+// trySwap swaps a value if the entry has not been expunged, and returns the
+// previous value, or nil if there was none.
+//
+// If the entry is expunged, trySwap returns false and leaves the entry
+// unchanged.
+func (e *entry[V]) trySwap(vs *versions, i V) (*V, bool) {
+	nv := newValue(vs, i)
+	for {
+		p := e.loadPointer()
+		if p == expunged {
+			return nil, false
+		}
+		if e.publish(vs, p, nv) {
+			v, _ := valueOf[V](vs, p)
+			return v, true
+		}
+	}
+}
+
+// swapLocked unconditionally swaps a value into the entry.
+//
+// The entry must be known not to be expunged.
+func (e *entry[V]) swapLocked(vs *versions, i V) *V {
+	v, _ := e.trySwap(vs, i)
+	return v
+}
+
+// CompareAndSwapFunc swaps the old and new values for key if the value stored
+// in the map is equal to old according to eq.
+func (m *Map[K, V]) CompareAndSwapFunc(key K, old, new V, eq func(a, b V) bool) (swapped bool) {
+	if m.Closed() {
+		return false
+	}
+	key = m.key(key)
+	read, _ := m.read.Load().(readOnly[K, V])
+	if e, ok := read.m[key]; ok {
+		swapped = e.tryCompareAndSwap(&m.ver, old, new, eq)
+	} else if !read.amended {
+		return false // No existing value for key.
+	} else {
+		m.mu.Lock()
+		read, _ = m.read.Load().(readOnly[K, V])
+		if e, ok := read.m[key]; ok {
+			swapped = e.tryCompareAndSwap(&m.ver, old, new, eq)
+		} else if e, ok := m.dirty[key]; ok {
+			swapped = e.tryCompareAndSwap(&m.ver, old, new, eq)
+			// We needed to lock mu in order to load the entry for key,
+			// and the operation didn't change the set of keys in the map
+			// (so it would be made more efficient by promoting the dirty
+			// map to read-only).
+			// Count it as a miss so that we will eventually switch to the
+			// more efficient steady state.
+			m.missLocked()
+		}
+		m.mu.Unlock()
+	}
+	if swapped {
+		m.stored(key, new)
+	}
+	return swapped
+}
+
+// tryCompareAndSwap compares the entry with the given old value and swaps
+// it with a new value if the entry is equal to the old value according to
+// eq.
+//
+// If the entry is expunged, tryCompareAndSwap returns false and leaves
+// the entry unchanged.
+func (e *entry[V]) tryCompareAndSwap(vs *versions, old, new V, eq func(a, b V) bool) bool {
+	p := e.loadPointer()
+	v, ok := valueOf[V](vs, p)
+	if !ok || !eq(*v, old) {
+		return false
+	}
+
+	// Copy the value after the first load to make this method more amenable
+	// to escape analysis: if the comparison fails from the start, we shouldn't
+	// bother heap-allocating.
+	nv := newValue(vs, new)
+	for {
+		if e.publish(vs, p, nv) {
+			return true
+		}
+		p = e.loadPointer()
+		v, ok = valueOf[V](vs, p)
+		if !ok || !eq(*v, old) {
+			return false
+		}
+	}
+}
+
+// CompareAndDeleteFunc deletes the entry for key if its value is equal to
+// old according to eq.
+//
+// If there is no current value for key in the map, CompareAndDeleteFunc
+// returns false.
+func (m *Map[K, V]) CompareAndDeleteFunc(key K, old V, eq func(a, b V) bool) (deleted bool) {
+	if m.Closed() {
+		return false
+	}
+	return m.deleteIf(m.key(key), func(v V) bool { return eq(v, old) })
+}
+
+// CompareAndSwap swaps the old and new values for key if the value stored in
+// m is equal to old. It is CompareAndSwapFunc with the == operator, for maps
+// of comparable values.
+func CompareAndSwap[K, V comparable](m *Map[K, V], key K, old, new V) (swapped bool) {
+	return m.CompareAndSwapFunc(key, old, new, equal)
+}
+
+// CompareAndDelete deletes the entry for key if its value is equal to old.
+// It is CompareAndDeleteFunc with the == operator, for maps of comparable
+// values.
+func CompareAndDelete[K, V comparable](m *Map[K, V], key K, old V) (deleted bool) {
+	return m.CompareAndDeleteFunc(key, old, equal)
+}
+
+func equal[V comparable](a, b V) bool { return a == b }
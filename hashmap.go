@@ -0,0 +1,202 @@
+package syncmapt
+
+// HashMap is a concurrent map for keys that are not comparable, such as
+// []byte or structs containing slices, which Map cannot hold. The keys are
+// hashed and compared with functions supplied to NewHashMap.
+//
+// A HashMap keeps its entries in a Map from hashes to buckets of the keys
+// with that hash, so it has the performance characteristics of Map: lookups
+// take no lock, and each update replaces the bucket of its key atomically.
+// Keys whose hashes collide share a bucket, which is scanned with equal.
+//
+// The keys and values passed to a HashMap are retained as they are, so a key
+// must not be modified after it is stored; Range may report the same key
+// slice that was passed to Store.
+//
+// A HashMap must be created with NewHashMap and must not be copied after
+// first use.
+type HashMap[K, V any] struct {
+	hash    func(K) uint64
+	equal   func(a, b K) bool
+	buckets Map[uint64, []hashEntry[K, V]]
+}
+
+// A hashEntry is an entry in a bucket of a HashMap.
+type hashEntry[K, V any] struct {
+	key   K
+	value V
+}
+
+// NewHashMap returns an empty HashMap that hashes keys with hash and compares
+// them with equal. Keys that are equal must have the same hash, and both
+// functions must be safe for concurrent use.
+func NewHashMap[K, V any](hash func(K) uint64, equal func(a, b K) bool) *HashMap[K, V] {
+	return &HashMap[K, V]{hash: hash, equal: equal}
+}
+
+// find returns the index of key in bucket, or -1.
+func (m *HashMap[K, V]) find(bucket []hashEntry[K, V], key K) int {
+	for i, p := range bucket {
+		if m.equal(p.key, key) {
+			return i
+		}
+	}
+	return -1
+}
+
+// update atomically replaces the entry for key with the result of f, which is
+// called with the current value, if loaded is true, and returns the new value,
+// or keep set to false to delete the entry. As with Map.Compute, f may be
+// called more than once, and the results are those of the last call. If the
+// map is closed, f is not called.
+func (m *HashMap[K, V]) update(key K, f func(old V, loaded bool) (new V, keep bool)) {
+	if m.buckets.Closed() {
+		return
+	}
+	m.buckets.compute(m.hash(key), func(bucket []hashEntry[K, V], _ bool) ([]hashEntry[K, V], bool) {
+		i := m.find(bucket, key)
+		var old V
+		if i >= 0 {
+			old = bucket[i].value
+		}
+		v, keep := f(old, i >= 0)
+		switch {
+		case keep && i >= 0:
+			// Copy the bucket: concurrent loads may be reading it.
+			bucket = append([]hashEntry[K, V](nil), bucket...)
+			bucket[i].value = v
+		case keep:
+			bucket = append(bucket[:len(bucket):len(bucket)], hashEntry[K, V]{key, v})
+		case i >= 0:
+			fresh := make([]hashEntry[K, V], 0, len(bucket)-1)
+			bucket = append(append(fresh, bucket[:i]...), bucket[i+1:]...)
+		}
+		return bucket, len(bucket) == 0
+	})
+}
+
+// Load returns the value stored in the map for a key, or the zero value if no
+// value is present. The ok result indicates whether value was found in the
+// map.
+func (m *HashMap[K, V]) Load(key K) (value V, ok bool) {
+	bucket, _ := m.buckets.load(m.hash(key))
+	if i := m.find(bucket, key); i >= 0 {
+		return bucket[i].value, true
+	}
+	return value, false
+}
+
+// Store sets the value for a key.
+func (m *HashMap[K, V]) Store(key K, value V) {
+	m.update(key, func(V, bool) (V, bool) { return value, true })
+}
+
+// LoadOrStore returns the existing value for the key if present. Otherwise,
+// it stores and returns the given value. The loaded result is true if the
+// value was loaded, false if stored.
+func (m *HashMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	if actual, loaded = m.Load(key); loaded {
+		return actual, true
+	}
+	m.update(key, func(old V, ok bool) (V, bool) {
+		if actual, loaded = old, ok; !ok {
+			actual = value
+		}
+		return actual, true
+	})
+	return actual, loaded
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if
+// any. The loaded result reports whether the key was present.
+func (m *HashMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	if _, ok := m.Load(key); !ok {
+		return value, false
+	}
+	m.update(key, func(old V, ok bool) (V, bool) {
+		value, loaded = old, ok
+		return old, false
+	})
+	return value, loaded
+}
+
+// Delete deletes the value for a key.
+func (m *HashMap[K, V]) Delete(key K) {
+	m.LoadAndDelete(key)
+}
+
+// Swap swaps the value for a key and returns the previous value if any.
+// The loaded result reports whether the key was present.
+func (m *HashMap[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	m.update(key, func(old V, ok bool) (V, bool) {
+		previous, loaded = old, ok
+		return value, true
+	})
+	return previous, loaded
+}
+
+// CompareAndSwapFunc swaps the old and new values for key if the value stored
+// in the map is equal to old according to eq.
+func (m *HashMap[K, V]) CompareAndSwapFunc(key K, old, new V, eq func(a, b V) bool) (swapped bool) {
+	if v, ok := m.Load(key); !ok || !eq(v, old) {
+		return false
+	}
+	m.update(key, func(v V, ok bool) (V, bool) {
+		if swapped = ok && eq(v, old); swapped {
+			return new, true
+		}
+		return v, ok
+	})
+	return swapped
+}
+
+// CompareAndDeleteFunc deletes the entry for key if its value is equal to
+// old according to eq.
+func (m *HashMap[K, V]) CompareAndDeleteFunc(key K, old V, eq func(a, b V) bool) (deleted bool) {
+	if v, ok := m.Load(key); !ok || !eq(v, old) {
+		return false
+	}
+	m.update(key, func(v V, ok bool) (V, bool) {
+		deleted = ok && eq(v, old)
+		return v, ok && !deleted
+	})
+	return deleted
+}
+
+// Range calls f sequentially for each key and value present in the map. If f
+// returns false, range stops the iteration. Range has the consistency of
+// Map.Range.
+func (m *HashMap[K, V]) Range(f func(key K, value V) bool) {
+	m.buckets.Range(func(_ uint64, bucket []hashEntry[K, V]) bool {
+		for _, p := range bucket {
+			if !f(p.key, p.value) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Len returns the number of entries in the map. Unlike Map.Len, it visits
+// every bucket of the map.
+func (m *HashMap[K, V]) Len() int {
+	n := 0
+	m.buckets.Range(func(_ uint64, bucket []hashEntry[K, V]) bool {
+		n += len(bucket)
+		return true
+	})
+	return n
+}
+
+// Clear deletes all the entries, resulting in an empty HashMap.
+func (m *HashMap[K, V]) Clear() {
+	m.buckets.Clear()
+}
+
+// Close closes the map as Map.Close does: afterwards its contents can still
+// be read, but the methods that would modify it do nothing, and those that
+// report a change report that nothing was stored, swapped or deleted. Close
+// is idempotent and always returns nil.
+func (m *HashMap[K, V]) Close() error {
+	return m.buckets.Close()
+}
@@ -0,0 +1,108 @@
+package syncmapt_test
+
+import (
+	"bytes"
+	"hash/maphash"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+	"github.com/holdno/syncmapt/syncmapttest"
+)
+
+func TestHashMap(t *testing.T) {
+	seed := maphash.MakeSeed()
+	m := syncmapt.NewHashMap[[]byte, int](func(k []byte) uint64 { return maphash.Bytes(seed, k) }, bytes.Equal)
+
+	m.Store([]byte("a"), 1)
+	if v, ok := m.Load([]byte("a")); !ok || v != 1 {
+		t.Fatalf("Load(a) = %v, %v; want 1, true", v, ok)
+	}
+	if _, ok := m.Load([]byte("b")); ok {
+		t.Fatal("Load(b) found a value that was never stored")
+	}
+	if v, loaded := m.LoadOrStore([]byte("a"), 2); !loaded || v != 1 {
+		t.Fatalf("LoadOrStore(a, 2) = %v, %v; want 1, true", v, loaded)
+	}
+	if v, loaded := m.LoadOrStore([]byte("b"), 2); loaded || v != 2 {
+		t.Fatalf("LoadOrStore(b, 2) = %v, %v; want 2, false", v, loaded)
+	}
+	if v, loaded := m.Swap([]byte("b"), 3); !loaded || v != 2 {
+		t.Fatalf("Swap(b, 3) = %v, %v; want 2, true", v, loaded)
+	}
+	eq := func(a, b int) bool { return a == b }
+	if m.CompareAndSwapFunc([]byte("b"), 2, 4, eq) || !m.CompareAndSwapFunc([]byte("b"), 3, 4, eq) {
+		t.Fatal("CompareAndSwapFunc did not compare with the current value")
+	}
+	if m.CompareAndDeleteFunc([]byte("b"), 3, eq) || !m.CompareAndDeleteFunc([]byte("b"), 4, eq) {
+		t.Fatal("CompareAndDeleteFunc did not compare with the current value")
+	}
+	if v, loaded := m.LoadAndDelete([]byte("a")); !loaded || v != 1 {
+		t.Fatalf("LoadAndDelete(a) = %v, %v; want 1, true", v, loaded)
+	}
+	if m.Len() != 0 {
+		t.Fatalf("Len() = %d after deleting every key", m.Len())
+	}
+}
+
+func TestHashMapConformance(t *testing.T) {
+	seed := maphash.MakeSeed()
+	syncmapttest.RunConformance(t, func() syncmapt.Interface[string, string] {
+		return syncmapt.NewHashMap[string, string](
+			func(k string) uint64 { return maphash.String(seed, k) },
+			func(a, b string) bool { return a == b },
+		)
+	})
+}
+
+func TestHashMapCollisions(t *testing.T) {
+	type key struct {
+		name string
+		tags []string
+	}
+	// Every key hashes to one of two buckets.
+	m := syncmapt.NewHashMap[key, int](
+		func(k key) uint64 { return uint64(len(k.tags) % 2) },
+		func(a, b key) bool { return a.name == b.name && slices.Equal(a.tags, b.tags) },
+	)
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				k := key{name: strconv.Itoa(i), tags: make([]string, i%3)}
+				m.Store(k, i)
+				if v, ok := m.Load(k); !ok || v != i {
+					t.Errorf("Load(%v) = %v, %v; want %d, true", k, v, ok, i)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if m.Len() != 50 {
+		t.Fatalf("Len() = %d, want 50", m.Len())
+	}
+
+	for i := 0; i < 50; i += 2 {
+		m.Delete(key{name: strconv.Itoa(i), tags: make([]string, i%3)})
+	}
+	var names []string
+	m.Range(func(k key, v int) bool {
+		names = append(names, k.name)
+		return true
+	})
+	sort.Slice(names, func(i, j int) bool {
+		return len(names[i]) < len(names[j]) || len(names[i]) == len(names[j]) && names[i] < names[j]
+	})
+	if len(names) != 25 || names[0] != "1" || names[24] != "49" {
+		t.Fatalf("Range after deleting even keys = %v", names)
+	}
+	m.Clear()
+	if m.Len() != 0 {
+		t.Fatalf("Len() = %d after Clear", m.Len())
+	}
+}
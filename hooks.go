@@ -0,0 +1,89 @@
+package syncmapt
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// hooks holds the callbacks registered with OnStore, OnDelete and OnEvict.
+// It is copied on write, so that mutations can run the callbacks without
+// taking a lock.
+type hooks[K comparable, V any] struct {
+	store, delete, evict []func(key K, value V)
+}
+
+// addHook registers f in the list of the hooks in p selected by list. mu
+// serializes the writers of p.
+func addHook[K comparable, V any](p *atomic.Pointer[hooks[K, V]], mu *sync.Mutex, list func(*hooks[K, V]) *[]func(K, V), f func(K, V)) {
+	mu.Lock()
+	defer mu.Unlock()
+	var h hooks[K, V]
+	if old := p.Load(); old != nil {
+		h = hooks[K, V]{slices.Clip(old.store), slices.Clip(old.delete), slices.Clip(old.evict)}
+	}
+	l := list(&h)
+	*l = append(*l, f)
+	p.Store(&h)
+}
+
+func (h *hooks[K, V]) run(fs []func(K, V), key K, value V) {
+	for _, f := range fs {
+		f(key, value)
+	}
+}
+
+func storeHooks[K comparable, V any](h *hooks[K, V]) *[]func(K, V)  { return &h.store }
+func deleteHooks[K comparable, V any](h *hooks[K, V]) *[]func(K, V) { return &h.delete }
+func evictHooks[K comparable, V any](h *hooks[K, V]) *[]func(K, V)  { return &h.evict }
+
+// OnStore registers f to be called after every store of a value for a key,
+// by Store, LoadOrStore, Swap, CompareAndSwap and the other methods that
+// store values. f is called with the stored value, outside of the map's
+// internal locks, by the goroutine that stored it, and possibly concurrently
+// with other hooks; it may call methods of m.
+//
+// ReplaceAll reports each value it stores. A value moved to another key by
+// Move is not reported.
+func (m *Map[K, V]) OnStore(f func(key K, value V)) {
+	addHook(&m.hooks, &m.obsMu, storeHooks[K, V], f)
+}
+
+// OnDelete registers f to be called after every deletion of a value, by
+// Delete, LoadAndDelete, CompareAndDelete and the other methods that delete
+// values, in the same way as OnStore. Clear and ReplaceAll report each value
+// they delete. A value replaced by a store or moved to another key by Move is
+// not reported, as it was not deleted.
+func (m *Map[K, V]) OnDelete(f func(key K, value V)) {
+	addHook(&m.hooks, &m.obsMu, deleteHooks[K, V], f)
+}
+
+// detachedLocked returns the storage of the contents being discarded by Clear
+// or ReplaceAll, if the map has OnDelete hooks to report them to. Once
+// detached, the storage is no longer modified under m.mu, so the caller can
+// enumerate it with reportCleared after unlocking.
+func (m *Map[K, V]) detachedLocked() map[K]*entry[V] {
+	h := m.hooks.Load()
+	if h == nil || len(h.delete) == 0 {
+		return nil
+	}
+	read, _ := m.read.Load().(readOnly[K, V])
+	if read.amended {
+		return m.dirty
+	}
+	return read.m
+}
+
+// reportCleared reports the values of the storage detached by detachedLocked
+// to the OnDelete hooks.
+func (m *Map[K, V]) reportCleared(detached map[K]*entry[V]) {
+	if len(detached) == 0 {
+		return
+	}
+	h := m.hooks.Load()
+	for k, e := range detached {
+		if v, ok := e.load(&m.ver); ok {
+			h.run(h.delete, k, v)
+		}
+	}
+}
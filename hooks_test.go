@@ -0,0 +1,110 @@
+package syncmapt_test
+
+import (
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/holdno/syncmapt"
+)
+
+// hookLog records the calls of the hooks it is registered as.
+type hookLog struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (l *hookLog) hook(event string) func(string, int) {
+	return func(k string, v int) {
+		l.mu.Lock()
+		l.calls = append(l.calls, event+" "+k+"="+strconv.Itoa(v))
+		l.mu.Unlock()
+	}
+}
+
+func (l *hookLog) take() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	calls := l.calls
+	slices.Sort(calls)
+	l.calls = nil
+	return calls
+}
+
+func (l *hookLog) expect(t *testing.T, want ...string) {
+	t.Helper()
+	slices.Sort(want)
+	if got := l.take(); !slices.Equal(got, want) {
+		t.Fatalf("hook calls = %q, want %q", got, want)
+	}
+}
+
+func TestMapHooks(t *testing.T) {
+	var l hookLog
+	m := new(syncmapt.Map[string, int])
+	m.OnStore(l.hook("store"))
+	m.OnDelete(l.hook("delete"))
+
+	m.Store("a", 1)
+	m.LoadOrStore("a", 2)
+	m.LoadOrStore("b", 2)
+	m.Swap("a", 3)
+	m.CompareAndSwapFunc("b", 2, 4, func(x, y int) bool { return x == y })
+	l.expect(t, "store a=1", "store b=2", "store a=3", "store b=4")
+
+	m.Delete("a")
+	m.Delete("missing")
+	m.LoadAndDelete("b")
+	l.expect(t, "delete a=3", "delete b=4")
+
+	m.Store("c", 5)
+	m.Move("c", "d")
+	l.expect(t, "store c=5")
+
+	m.ReplaceAll(map[string]int{"e": 6})
+	l.expect(t, "delete d=5", "store e=6")
+	m.Clear()
+	l.expect(t, "delete e=6")
+}
+
+func TestBoundedMapHooks(t *testing.T) {
+	var l hookLog
+	m := syncmapt.NewBoundedMap(syncmapt.WithMaxEntries[string, int](1))
+	m.OnStore(l.hook("store"))
+	m.OnDelete(l.hook("delete"))
+	m.OnEvict(l.hook("evict"))
+
+	m.Store("a", 1)
+	m.Store("b", 2)
+	l.expect(t, "store a=1", "evict a=1", "store b=2")
+	m.Delete("b")
+	l.expect(t, "delete b=2")
+}
+
+func TestExpiringMapHooks(t *testing.T) {
+	var l hookLog
+	clock := newFakeClock()
+	m := syncmapt.NewExpiringMap(syncmapt.WithClock[string, int](clock))
+	defer m.Close()
+	m.OnStore(l.hook("store"))
+	m.OnDelete(l.hook("delete"))
+	m.OnEvict(l.hook("evict"))
+
+	m.StoreWithTTL("a", 1, time.Second)
+	m.StoreWithTTL("b", 2, time.Second)
+	m.StoreWithTTL("c", 3, time.Second)
+	m.Delete("c")
+	l.expect(t, "store a=1", "store b=2", "store c=3", "delete c=3")
+
+	clock.Advance(time.Second)
+	m.DeleteExpired()
+	m.Delete("b")
+	l.expect(t, "evict a=1", "evict b=2")
+
+	m.StoreWithTTL("d", 4, time.Second)
+	clock.Advance(time.Second)
+	m.Delete("d")
+	l.expect(t, "store d=4", "evict d=4")
+}
@@ -0,0 +1,181 @@
+package syncmapt
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// An Index is a secondary index over the values of a Map, mapping a derived
+// value to the keys whose values derive it. It is kept up to date by every
+// mutation of the Map.
+//
+// An Index is safe for concurrent use. Updates are applied by the goroutine
+// that mutates the Map, right after the mutation, so a Lookup concurrent with
+// a mutation may or may not reflect it, but one that happens after the
+// mutation returned always does.
+type Index[K comparable, V any, I comparable] struct {
+	m *Map[K, V]
+	f func(V) I
+
+	mu      sync.Mutex
+	byKey   map[K]I
+	byIndex map[I]map[K]struct{}
+}
+
+// IndexBy returns an Index of the values of m by f, built from the current
+// contents of m and maintained until Close is called. f must be deterministic
+// and must not call methods of m.
+func IndexBy[K comparable, V any, I comparable](m *Map[K, V], f func(V) I) *Index[K, V, I] {
+	x := &Index[K, V, I]{m: m, f: f}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	// Register first, so that no mutation made while the index is built is
+	// missed; its notification waits for x.mu and then re-reads the key.
+	m.addObserver(x)
+	x.rebuildLocked()
+	return x
+}
+
+// Lookup returns the keys whose values derive i, in an indeterminate order.
+func (x *Index[K, V, I]) Lookup(i I) []K {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	set := x.byIndex[i]
+	keys := make([]K, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Close detaches x from its Map. The Index is no longer updated, and keeps
+// answering Lookups with the contents it had.
+func (x *Index[K, V, I]) Close() {
+	x.m.removeObserver(x)
+}
+
+func (x *Index[K, V, I]) rebuildLocked() {
+	x.byKey = make(map[K]I)
+	x.byIndex = make(map[I]map[K]struct{})
+	x.m.Range(func(k K, v V) bool {
+		x.addLocked(k, x.f(v))
+		return true
+	})
+}
+
+func (x *Index[K, V, I]) addLocked(k K, i I) {
+	x.byKey[k] = i
+	set, ok := x.byIndex[i]
+	if !ok {
+		set = make(map[K]struct{})
+		x.byIndex[i] = set
+	}
+	set[k] = struct{}{}
+}
+
+func (x *Index[K, V, I]) removeLocked(k K, i I) {
+	delete(x.byKey, k)
+	set := x.byIndex[i]
+	delete(set, k)
+	if len(set) == 0 {
+		delete(x.byIndex, i)
+	}
+}
+
+func (x *Index[K, V, I]) keyChanged(k K) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	old, indexed := x.byKey[k]
+	v, ok := x.m.load(k)
+	if !ok {
+		if indexed {
+			x.removeLocked(k, old)
+		}
+		return
+	}
+	i := x.f(v)
+	if indexed {
+		if i == old {
+			return
+		}
+		x.removeLocked(k, old)
+	}
+	x.addLocked(k, i)
+}
+
+func (x *Index[K, V, I]) reset() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.rebuildLocked()
+}
+
+// ErrDuplicate is matched, via errors.Is, by every DuplicateError.
+var ErrDuplicate = errors.New("syncmapt: duplicate value in unique index")
+
+// A DuplicateError is returned by UniqueIndex.Store when the value to store
+// derives the same index value as the value of another key.
+type DuplicateError[K comparable, I comparable] struct {
+	Key      K // key being stored
+	Existing K // key already holding Index
+	Index    I
+}
+
+func (e *DuplicateError[K, I]) Error() string {
+	return fmt.Sprintf("syncmapt: storing key %v: index value %v already held by key %v", e.Key, e.Index, e.Existing)
+}
+
+func (e *DuplicateError[K, I]) Is(target error) bool { return target == ErrDuplicate }
+
+// A UniqueIndex is an Index whose derived values must be unique across keys.
+// The constraint is enforced by its Store method, which is the write path for
+// values subject to it: Map.Store and other direct mutations of the Map keep
+// the index up to date but are not checked.
+type UniqueIndex[K comparable, V any, I comparable] struct {
+	x  *Index[K, V, I]
+	mu sync.Mutex // serializes Store
+}
+
+// UniqueIndexBy returns a UniqueIndex of the values of m by f. Like IndexBy,
+// it is built from the current contents of m, which are not checked.
+func UniqueIndexBy[K comparable, V any, I comparable](m *Map[K, V], f func(V) I) *UniqueIndex[K, V, I] {
+	return &UniqueIndex[K, V, I]{x: IndexBy(m, f)}
+}
+
+// Store stores value for key in the indexed Map, unless another key holds a
+// value deriving the same index value, in which case it returns a
+// *DuplicateError and leaves the Map unchanged. Replacing the value of a key
+// with one deriving the same index value is allowed.
+func (u *UniqueIndex[K, V, I]) Store(key K, value V) error {
+	key = u.x.m.key(key)
+	i := u.x.f(value)
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.x.mu.Lock()
+	for existing := range u.x.byIndex[i] {
+		if existing != key {
+			u.x.mu.Unlock()
+			return &DuplicateError[K, I]{Key: key, Existing: existing, Index: i}
+		}
+	}
+	u.x.mu.Unlock()
+
+	u.x.m.Store(key, value)
+	return nil
+}
+
+// Lookup returns the key whose value derives i, if any.
+func (u *UniqueIndex[K, V, I]) Lookup(i I) (key K, ok bool) {
+	u.x.mu.Lock()
+	defer u.x.mu.Unlock()
+	for k := range u.x.byIndex[i] {
+		return k, true
+	}
+	return key, false
+}
+
+// Close detaches u from its Map, as Index.Close does.
+func (u *UniqueIndex[K, V, I]) Close() {
+	u.x.Close()
+}
@@ -0,0 +1,157 @@
+package syncmapt_test
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+	"github.com/holdno/syncmapt/syncmapttest"
+)
+
+type conn struct {
+	User string
+}
+
+func lookup(x *syncmapt.Index[string, conn, string], user string) []string {
+	keys := x.Lookup(user)
+	slices.Sort(keys)
+	return keys
+}
+
+func TestIndexBy(t *testing.T) {
+	m := syncmapttest.New(map[string]conn{"c1": {"alice"}, "c2": {"bob"}})
+	byUser := syncmapt.IndexBy(m, func(c conn) string { return c.User })
+
+	if got := lookup(byUser, "alice"); !slices.Equal(got, []string{"c1"}) {
+		t.Fatal("unexpected", got)
+	}
+
+	m.Store("c3", conn{"alice"})
+	m.Store("c2", conn{"alice"})
+	if got := lookup(byUser, "alice"); !slices.Equal(got, []string{"c1", "c2", "c3"}) {
+		t.Fatal("unexpected after Store", got)
+	}
+	if got := lookup(byUser, "bob"); len(got) != 0 {
+		t.Fatal("want bob unindexed after his connection changed user, got", got)
+	}
+
+	m.Delete("c1")
+	m.Move("c3", "c4")
+	if got := lookup(byUser, "alice"); !slices.Equal(got, []string{"c2", "c4"}) {
+		t.Fatal("unexpected after Delete and Move", got)
+	}
+
+	m.ReplaceAll(map[string]conn{"c9": {"carol"}})
+	if got := lookup(byUser, "carol"); !slices.Equal(got, []string{"c9"}) {
+		t.Fatal("unexpected after ReplaceAll", got)
+	}
+	m.Clear()
+	if got := lookup(byUser, "carol"); len(got) != 0 {
+		t.Fatal("unexpected after Clear", got)
+	}
+
+	byUser.Close()
+	m.Store("c1", conn{"dave"})
+	if got := lookup(byUser, "dave"); len(got) != 0 {
+		t.Fatal("want closed index no longer updated, got", got)
+	}
+}
+
+func TestIndexByConcurrent(t *testing.T) {
+	m := new(syncmapt.Map[string, conn])
+	byUser := syncmapt.IndexBy(m, func(c conn) string { return c.User })
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				k := strconv.Itoa(i % 16)
+				if i%3 == 0 {
+					m.Delete(k)
+				} else {
+					m.Store(k, conn{strconv.Itoa(g)})
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	// Once writers are done, the index must agree with the map.
+	want := make(map[string][]string)
+	m.Range(func(k string, c conn) bool {
+		want[c.User] = append(want[c.User], k)
+		return true
+	})
+	for g := 0; g < 8; g++ {
+		user := strconv.Itoa(g)
+		w := want[user]
+		slices.Sort(w)
+		if got := lookup(byUser, user); !slices.Equal(got, w) {
+			t.Fatalf("index for %s = %v, want %v", user, got, w)
+		}
+	}
+}
+
+func TestUniqueIndexBy(t *testing.T) {
+	type route struct{ Addr string }
+	m := new(syncmapt.Map[string, route])
+	byAddr := syncmapt.UniqueIndexBy(m, func(r route) string { return r.Addr })
+
+	if err := byAddr.Store("svc-a", route{"10.0.0.1:80"}); err != nil {
+		t.Fatal(err)
+	}
+	err := byAddr.Store("svc-b", route{"10.0.0.1:80"})
+	var dup *syncmapt.DuplicateError[string, string]
+	if !errors.As(err, &dup) || !errors.Is(err, syncmapt.ErrDuplicate) {
+		t.Fatal("want DuplicateError, got", err)
+	}
+	if dup.Key != "svc-b" || dup.Existing != "svc-a" || dup.Index != "10.0.0.1:80" {
+		t.Fatalf("unexpected %+v", dup)
+	}
+	if _, ok := m.Load("svc-b"); ok {
+		t.Fatal("want rejected Store to leave the map unchanged")
+	}
+
+	// Re-storing the same identity under the same key is fine.
+	if err := byAddr.Store("svc-a", route{"10.0.0.1:80"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Once the address is released, another key may take it.
+	m.Delete("svc-a")
+	if err := byAddr.Store("svc-b", route{"10.0.0.1:80"}); err != nil {
+		t.Fatal(err)
+	}
+	if k, ok := byAddr.Lookup("10.0.0.1:80"); !ok || k != "svc-b" {
+		t.Fatal("unexpected", k, ok)
+	}
+}
+
+func TestUniqueIndexByConcurrent(t *testing.T) {
+	m := new(syncmapt.Map[int, string])
+	u := syncmapt.UniqueIndexBy(m, func(v string) string { return v })
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	won := 0
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if u.Store(i, "same") == nil {
+				mu.Lock()
+				won++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	if won != 1 || m.Len() != 1 {
+		t.Fatalf("want exactly one Store to win, got %d (Len %d)", won, m.Len())
+	}
+}
@@ -0,0 +1,26 @@
+package syncmapt
+
+// Interface is the set of methods shared by the concurrent map types in this
+// package. Code that only needs basic map operations can accept an Interface
+// to work with any of them, or with a test double.
+type Interface[K comparable, V any] interface {
+	Load(key K) (value V, ok bool)
+	Store(key K, value V)
+	LoadOrStore(key K, value V) (actual V, loaded bool)
+	LoadAndDelete(key K) (value V, loaded bool)
+	Delete(key K)
+	Range(f func(key K, value V) bool)
+	Len() int
+}
+
+var _ Interface[string, any] = (*Map[string, any])(nil)
+
+// ReadOnlyMap is the read side of a concurrent map. Every map type in this
+// package is a ReadOnlyMap, and so are the views returned by Map.View.
+type ReadOnlyMap[K comparable, V any] interface {
+	Load(key K) (value V, ok bool)
+	Range(f func(key K, value V) bool)
+	Len() int
+}
+
+var _ ReadOnlyMap[string, any] = (*Map[string, any])(nil)
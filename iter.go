@@ -0,0 +1,76 @@
+package syncmapt
+
+import (
+	"context"
+	"iter"
+)
+
+// All returns an iterator over the key-value pairs in the map, with the same
+// semantics as Range: no key is visited more than once, but the sequence does
+// not necessarily correspond to any consistent snapshot of the map's contents.
+//
+// All can be passed to the helpers of the standard maps package, for example
+// maps.Collect(m.All()) to copy a Map into a plain map.
+func (m *Map[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.Range(yield)
+	}
+}
+
+// Keys returns an iterator over the keys in the map, with the semantics of
+// All. slices.Collect(m.Keys()) returns them as a slice.
+func (m *Map[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		m.Range(func(k K, _ V) bool {
+			return yield(k)
+		})
+	}
+}
+
+// Values returns an iterator over the values in the map, with the semantics
+// of All. slices.Collect(m.Values()) returns them as a slice.
+func (m *Map[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		m.Range(func(_ K, v V) bool {
+			return yield(v)
+		})
+	}
+}
+
+// RangeCtx is Range for long iterations that must respect a deadline or a
+// cancellation: it checks ctx before each entry, and stops the iteration and
+// returns ctx.Err() once ctx is done. It returns nil if the iteration ends,
+// including when f returns false.
+func (m *Map[K, V]) RangeCtx(ctx context.Context, f func(key K, value V) bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	done := ctx.Done()
+	var err error
+	m.Range(func(k K, v V) bool {
+		select {
+		case <-done:
+			err = ctx.Err()
+			return false
+		default:
+		}
+		return f(k, v)
+	})
+	return err
+}
+
+// Collect collects key-value pairs from seq into a new Map and returns it.
+// If seq yields the same key more than once, the last value wins.
+func Collect[K comparable, V any](seq iter.Seq2[K, V]) *Map[K, V] {
+	m := new(Map[K, V])
+	Insert(m, seq)
+	return m
+}
+
+// Insert stores the key-value pairs from seq in m, overwriting existing
+// entries for the same keys.
+func Insert[K comparable, V any](m *Map[K, V], seq iter.Seq2[K, V]) {
+	for k, v := range seq {
+		m.Store(k, v)
+	}
+}
@@ -0,0 +1,96 @@
+package syncmapt_test
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+	"github.com/holdno/syncmapt/syncmapttest"
+)
+
+func TestAll(t *testing.T) {
+	want := map[string]int{"a": 1, "b": 2, "c": 3}
+	m := syncmapttest.New(want)
+
+	got := make(map[string]int)
+	for k, v := range m.All() {
+		got[k] = v
+	}
+	if !maps.Equal(got, want) {
+		t.Fatalf("All yielded %v, want %v", got, want)
+	}
+
+	if got := maps.Collect(m.All()); !maps.Equal(got, want) {
+		t.Fatalf("maps.Collect(All) = %v, want %v", got, want)
+	}
+
+	n := 0
+	for range m.All() {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Fatal("want All to stop when the loop breaks")
+	}
+}
+
+func TestKeysValues(t *testing.T) {
+	m := syncmapttest.New(map[string]int{"a": 1, "b": 2, "c": 3})
+	m.Delete("b")
+
+	if got, want := slices.Sorted(m.Keys()), []string{"a", "c"}; !slices.Equal(got, want) {
+		t.Fatalf("Keys yielded %v, want %v", got, want)
+	}
+	if got, want := slices.Sorted(m.Values()), []int{1, 3}; !slices.Equal(got, want) {
+		t.Fatalf("Values yielded %v, want %v", got, want)
+	}
+	for range m.Keys() {
+		break
+	}
+	for range m.Values() {
+		break
+	}
+	if keys := slices.Collect(new(syncmapt.Map[string, int]).Keys()); len(keys) != 0 {
+		t.Fatal("unexpected", keys)
+	}
+}
+
+func TestCollectInsert(t *testing.T) {
+	src := map[string]int{"a": 1, "b": 2}
+
+	m := syncmapt.Collect(maps.All(src))
+	syncmapttest.RequireEqual(t, m, src)
+
+	syncmapt.Insert(m, maps.All(map[string]int{"b": 20, "c": 30}))
+	syncmapttest.RequireEqual(t, m, map[string]int{"a": 1, "b": 20, "c": 30})
+}
+
+func TestRangeCtx(t *testing.T) {
+	m := new(syncmapt.Map[int, int])
+	for i := 0; i < 100; i++ {
+		m.Store(i, i)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	n := 0
+	err := m.RangeCtx(ctx, func(int, int) bool {
+		if n++; n == 10 {
+			cancel()
+		}
+		return true
+	})
+	if !errors.Is(err, context.Canceled) || n != 10 {
+		t.Fatalf("RangeCtx cancelled after 10 entries = %v after %d entries", err, n)
+	}
+	if err := m.RangeCtx(ctx, func(int, int) bool { t.Fatal("f called with ctx done"); return true }); err == nil {
+		t.Fatal("RangeCtx with ctx done returned nil")
+	}
+
+	n = 0
+	if err := m.RangeCtx(context.Background(), func(int, int) bool { n++; return n < 5 }); err != nil || n != 5 {
+		t.Fatalf("RangeCtx stopped by f = %v after %d entries; want nil after 5", err, n)
+	}
+}
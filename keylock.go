@@ -0,0 +1,106 @@
+package syncmapt
+
+import (
+	"hash/maphash"
+	"slices"
+	"sync"
+)
+
+// lockSeed is the seed of the hashes by which key locks are found and
+// ordered.
+var lockSeed = maphash.MakeSeed()
+
+// A keyLock is the lock of the keys with a given hash, with the number of
+// goroutines holding or waiting for it. Keys whose hashes collide share a
+// lock, which only makes it coarser.
+type keyLock struct {
+	mu   sync.Mutex
+	refs int // guarded by the Map's lockMu
+}
+
+// LockKey locks key for a sequence of operations that must not interleave with
+// those of other holders of the lock, and returns the function that unlocks
+// it, which must be called exactly once. Locking a key blocks only other calls
+// of LockKey, WithLock and Txn for the same key: other keys stay available,
+// and the other methods of the map do not take key locks, so the lock
+// excludes only the code that agrees to use it.
+//
+//	unlock := m.LockKey(id)
+//	s, _ := m.Load(id)
+//	s.Refresh()
+//	if s.Expired() {
+//		m.Delete(id)
+//	}
+//	unlock()
+//
+// A goroutine holding a key lock must not lock another key, which could
+// deadlock; Txn locks several keys safely. A lock is kept only while it is
+// held or waited for, so locking many distinct keys does not make the map
+// grow.
+func (m *Map[K, V]) LockKey(key K) (unlock func()) {
+	return m.lockKeys([]K{m.key(key)})
+}
+
+// lockKeys locks keys, which must already have been transformed, and returns
+// the function that unlocks them. The locks are taken in the order of their
+// hashes, so that goroutines locking overlapping sets of keys cannot
+// deadlock.
+func (m *Map[K, V]) lockKeys(keys []K) (unlock func()) {
+	m.lockMu.Lock()
+	if m.locks == nil {
+		m.locks = make(map[uint64]*keyLock)
+	}
+	hashes := make([]uint64, len(keys))
+	for i, k := range keys {
+		hashes[i] = maphash.Comparable(lockSeed, k)
+	}
+	slices.Sort(hashes)
+	hashes = slices.Compact(hashes)
+	locks := make([]*keyLock, len(hashes))
+	for i, h := range hashes {
+		l, ok := m.locks[h]
+		if !ok {
+			l = new(keyLock)
+			m.locks[h] = l
+		}
+		l.refs++
+		locks[i] = l
+	}
+	m.lockMu.Unlock()
+
+	for _, l := range locks {
+		l.mu.Lock()
+	}
+	return func() {
+		for _, l := range locks {
+			l.mu.Unlock()
+		}
+		m.lockMu.Lock()
+		for i, l := range locks {
+			if l.refs--; l.refs == 0 {
+				delete(m.locks, hashes[i])
+			}
+		}
+		m.lockMu.Unlock()
+	}
+}
+
+// WithLock calls f with the current value for key, if loaded is true, or the
+// zero value, and replaces the value with the one f returns, or deletes it if
+// delete is true, all with key locked as by LockKey. The results are the
+// value stored for key afterwards, with ok false if there is none.
+//
+// Unlike Compute, WithLock calls f exactly once, so f may have side effects
+// or block; concurrent calls of WithLock for the same key run one after the
+// other. An update of key by a method other than LockKey and WithLock while f
+// runs is overwritten by the result of f.
+func (m *Map[K, V]) WithLock(key K, f func(value V, loaded bool) (new V, delete bool)) (value V, ok bool) {
+	if m.Closed() {
+		return m.Load(key)
+	}
+	key = m.key(key)
+	unlock := m.lockKeys([]K{key})
+	defer unlock()
+	nv, del := f(m.load(key))
+	return m.compute(key, func(V, bool) (V, bool) { return nv, del })
+}
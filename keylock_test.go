@@ -0,0 +1,64 @@
+package syncmapt_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+)
+
+func TestWithLock(t *testing.T) {
+	m := new(syncmapt.Map[string, int])
+	var calls atomic.Int64
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				m.WithLock("n", func(v int, _ bool) (int, bool) {
+					calls.Add(1) // a side effect, run once per call
+					return v + 1, false
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	if v, _ := m.Load("n"); v != 800 || calls.Load() != 800 {
+		t.Fatalf("n = %d after %d calls of f, want 800 after 800", v, calls.Load())
+	}
+
+	if v, ok := m.WithLock("n", func(v int, loaded bool) (int, bool) { return 0, loaded && v == 800 }); ok || v != 0 {
+		t.Fatalf("WithLock deleting n = %v, %v", v, ok)
+	}
+	if _, ok := m.Load("n"); ok {
+		t.Fatal("n still present after WithLock deleted it")
+	}
+}
+
+func TestLockKey(t *testing.T) {
+	m := new(syncmapt.Map[string, int])
+	unlockA := m.LockKey("a")
+
+	// Other keys are not locked.
+	unlockB := m.LockKey("b")
+	unlockB()
+
+	locked := make(chan struct{})
+	go func() {
+		unlock := m.LockKey("a")
+		v, _ := m.Load("a")
+		m.Store("a", v*10)
+		unlock()
+		close(locked)
+	}()
+	m.Store("a", 1)
+	v, _ := m.Load("a")
+	m.Store("a", v+1)
+	unlockA()
+	<-locked
+	if v, _ := m.Load("a"); v != 20 {
+		t.Fatalf("a = %d, want 20: the second holder ran before the first unlocked", v)
+	}
+}
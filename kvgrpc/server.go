@@ -0,0 +1,198 @@
+// Package kvgrpc serves a syncmapt map over gRPC, so that sidecars and
+// debugging tools can read and modify the map of a live process.
+//
+// The service is defined in kvpb/kv.proto. Keys and values travel as bytes,
+// encoded by the Codecs given to NewServer:
+//
+//	srv := grpc.NewServer()
+//	kvpb.RegisterKVServer(srv, kvgrpc.NewServer(m, kvgrpc.StringCodec{}, kvgrpc.JSONCodec[Session]{}))
+package kvgrpc
+
+//go:generate buf generate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/holdno/syncmapt"
+	"github.com/holdno/syncmapt/kvgrpc/kvpb"
+)
+
+// A Codec converts keys or values to and from their wire form.
+type Codec[T any] interface {
+	Marshal(v T) ([]byte, error)
+	Unmarshal(data []byte) (T, error)
+}
+
+// StringCodec is the Codec for string keys or values, sent as their bytes.
+type StringCodec struct{}
+
+func (StringCodec) Marshal(s string) ([]byte, error)      { return []byte(s), nil }
+func (StringCodec) Unmarshal(data []byte) (string, error) { return string(data), nil }
+
+// JSONCodec is a Codec that encodes T as JSON.
+type JSONCodec[T any] struct{}
+
+func (JSONCodec[T]) Marshal(v T) ([]byte, error) { return json.Marshal(v) }
+
+func (JSONCodec[T]) Unmarshal(data []byte) (T, error) {
+	var v T
+	err := json.Unmarshal(data, &v)
+	return v, err
+}
+
+// A ServerOption configures a Server.
+type ServerOption func(*serverOptions)
+
+type serverOptions struct {
+	readOnly bool
+}
+
+// ReadOnly makes the Server reject Set and Delete with codes.PermissionDenied.
+func ReadOnly() ServerOption {
+	return func(o *serverOptions) { o.readOnly = true }
+}
+
+// Server implements kvpb.KVServer on top of a syncmapt.Map.
+type Server[K comparable, V any] struct {
+	kvpb.UnimplementedKVServer
+
+	m      *syncmapt.Map[K, V]
+	keys   Codec[K]
+	values Codec[V]
+	opts   serverOptions
+}
+
+// NewServer returns a Server exposing m, using keys and values to encode its
+// entries.
+func NewServer[K comparable, V any](m *syncmapt.Map[K, V], keys Codec[K], values Codec[V], opts ...ServerOption) *Server[K, V] {
+	s := &Server[K, V]{m: m, keys: keys, values: values}
+	for _, opt := range opts {
+		opt(&s.opts)
+	}
+	return s
+}
+
+func (s *Server[K, V]) key(data []byte) (K, error) {
+	k, err := s.keys.Unmarshal(data)
+	if err != nil {
+		return k, status.Errorf(codes.InvalidArgument, "invalid key: %v", err)
+	}
+	return k, nil
+}
+
+func (s *Server[K, V]) value(v V) ([]byte, error) {
+	data, err := s.values.Marshal(v)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "encoding value: %v", err)
+	}
+	return data, nil
+}
+
+func (s *Server[K, V]) Get(_ context.Context, req *kvpb.GetRequest) (*kvpb.GetResponse, error) {
+	k, err := s.key(req.GetKey())
+	if err != nil {
+		return nil, err
+	}
+	v, ok := s.m.Load(k)
+	if !ok {
+		return &kvpb.GetResponse{}, nil
+	}
+	data, err := s.value(v)
+	if err != nil {
+		return nil, err
+	}
+	return &kvpb.GetResponse{Value: data, Found: true}, nil
+}
+
+func (s *Server[K, V]) Set(_ context.Context, req *kvpb.SetRequest) (*kvpb.SetResponse, error) {
+	if s.opts.readOnly {
+		return nil, status.Error(codes.PermissionDenied, "map is read-only")
+	}
+	k, err := s.key(req.GetKey())
+	if err != nil {
+		return nil, err
+	}
+	v, err := s.values.Unmarshal(req.GetValue())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid value: %v", err)
+	}
+	if s.m.Closed() {
+		return nil, errClosed
+	}
+	s.m.Store(k, v)
+	return &kvpb.SetResponse{}, nil
+}
+
+func (s *Server[K, V]) Delete(_ context.Context, req *kvpb.DeleteRequest) (*kvpb.DeleteResponse, error) {
+	if s.opts.readOnly {
+		return nil, status.Error(codes.PermissionDenied, "map is read-only")
+	}
+	k, err := s.key(req.GetKey())
+	if err != nil {
+		return nil, err
+	}
+	if s.m.Closed() {
+		return nil, errClosed
+	}
+	_, found := s.m.LoadAndDelete(k)
+	return &kvpb.DeleteResponse{Found: found}, nil
+}
+
+func (s *Server[K, V]) List(_ *kvpb.ListRequest, stream grpc.ServerStreamingServer[kvpb.Entry]) error {
+	var err error
+	s.m.Range(func(k K, v V) bool {
+		var e kvpb.Entry
+		if e.Key, err = s.keys.Marshal(k); err != nil {
+			err = status.Errorf(codes.Internal, "encoding key: %v", err)
+			return false
+		}
+		if e.Value, err = s.value(v); err != nil {
+			return false
+		}
+		err = stream.Send(&e)
+		return err == nil
+	})
+	return err
+}
+
+// Watch sends the value stored for the key, waiting for one if there is
+// none, and then every value stored for the key that encodes differently
+// from the previous one. Deletions are not reported.
+func (s *Server[K, V]) Watch(req *kvpb.WatchRequest, stream grpc.ServerStreamingServer[kvpb.Entry]) error {
+	k, err := s.key(req.GetKey())
+	if err != nil {
+		return err
+	}
+	var last []byte
+	sent := false
+	for {
+		v, err := s.m.WaitForValue(stream.Context(), k, func(v V) bool {
+			data, err := s.values.Marshal(v)
+			return err != nil || !sent || !bytes.Equal(data, last)
+		})
+		if errors.Is(err, syncmapt.ErrClosed) {
+			return errClosed
+		}
+		if err != nil {
+			return status.FromContextError(err).Err()
+		}
+		data, err := s.value(v)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(&kvpb.Entry{Key: req.GetKey(), Value: data}); err != nil {
+			return err
+		}
+		last, sent = data, true
+	}
+}
+
+// errClosed is the error of a request to a Server whose map is closed.
+var errClosed = status.Error(codes.Unavailable, "map is closed")
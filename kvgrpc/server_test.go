@@ -0,0 +1,144 @@
+package kvgrpc_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/holdno/syncmapt"
+	"github.com/holdno/syncmapt/kvgrpc"
+	"github.com/holdno/syncmapt/kvgrpc/kvpb"
+)
+
+type session struct {
+	User string `json:"user"`
+}
+
+func newClient(t *testing.T, s kvpb.KVServer) kvpb.KVClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	kvpb.RegisterKVServer(srv, s)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return kvpb.NewKVClient(conn)
+}
+
+func TestServer(t *testing.T) {
+	m := new(syncmapt.Map[string, session])
+	m.Store("s1", session{User: "alice"})
+	c := newClient(t, kvgrpc.NewServer(m, kvgrpc.StringCodec{}, kvgrpc.JSONCodec[session]{}))
+	ctx := context.Background()
+
+	got, err := c.Get(ctx, &kvpb.GetRequest{Key: []byte("s1")})
+	if err != nil || !got.Found || string(got.Value) != `{"user":"alice"}` {
+		t.Fatalf("Get = %v, %v", got, err)
+	}
+
+	if _, err := c.Set(ctx, &kvpb.SetRequest{Key: []byte("s2"), Value: []byte(`{"user":"bob"}`)}); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := m.Load("s2"); !ok || v.User != "bob" {
+		t.Fatalf("want Set visible in the map, got %v, %v", v, ok)
+	}
+
+	_, err = c.Set(ctx, &kvpb.SetRequest{Key: []byte("s3"), Value: []byte(`not json`)})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatal("want InvalidArgument for a malformed value, got", err)
+	}
+
+	del, err := c.Delete(ctx, &kvpb.DeleteRequest{Key: []byte("s1")})
+	if err != nil || !del.Found {
+		t.Fatalf("Delete = %v, %v", del, err)
+	}
+
+	list, err := c.List(ctx, &kvpb.ListRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for {
+		e, err := list.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, string(e.Key))
+	}
+	if len(keys) != 1 || keys[0] != "s2" {
+		t.Fatal("unexpected List keys", keys)
+	}
+}
+
+func TestServerReadOnly(t *testing.T) {
+	m := new(syncmapt.Map[string, string])
+	c := newClient(t, kvgrpc.NewServer(m, kvgrpc.StringCodec{}, kvgrpc.StringCodec{}, kvgrpc.ReadOnly()))
+
+	_, err := c.Set(context.Background(), &kvpb.SetRequest{Key: []byte("k"), Value: []byte("v")})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatal("want PermissionDenied, got", err)
+	}
+}
+
+func TestServerClosed(t *testing.T) {
+	m := new(syncmapt.Map[string, string])
+	m.Close()
+	c := newClient(t, kvgrpc.NewServer(m, kvgrpc.StringCodec{}, kvgrpc.StringCodec{}))
+
+	_, err := c.Set(context.Background(), &kvpb.SetRequest{Key: []byte("k"), Value: []byte("v")})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatal("want Unavailable for Set, got", err)
+	}
+	_, err = c.Delete(context.Background(), &kvpb.DeleteRequest{Key: []byte("k")})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatal("want Unavailable for Delete, got", err)
+	}
+}
+
+func TestServerWatch(t *testing.T) {
+	m := new(syncmapt.Map[string, string])
+	c := newClient(t, kvgrpc.NewServer(m, kvgrpc.StringCodec{}, kvgrpc.StringCodec{}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	w, err := c.Watch(ctx, &kvpb.WatchRequest{Key: []byte("leader")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		m.Store("leader", "a")
+		time.Sleep(10 * time.Millisecond)
+		m.Store("leader", "a") // unchanged: not sent
+		m.Store("leader", "b")
+	}()
+
+	for _, want := range []string{"a", "b"} {
+		e, err := w.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(e.Value) != want {
+			t.Fatalf("Watch sent %q, want %q", e.Value, want)
+		}
+	}
+}
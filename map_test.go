@@ -1,88 +1,37 @@
 package syncmapt_test
 
 import (
+	"io"
 	"math/rand"
 	"reflect"
 	"runtime"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
+	"unsafe"
 
-	"github.com/holdno/syncmapt"
-)
-
-type mapOp string
+	"golang.org/x/text/unicode/norm"
 
-const (
-	opLoad        = mapOp("Load")
-	opStore       = mapOp("Store")
-	opLoadOrStore = mapOp("LoadOrStore")
-	opDelete      = mapOp("Delete")
+	"github.com/holdno/syncmapt"
+	"github.com/holdno/syncmapt/syncmapttest"
 )
 
 type AnyKey string
 
-var mapOps = [...]mapOp{opLoad, opStore, opLoadOrStore, opDelete}
-
-// mapCall is a quick.Generator for calls on mapInterface.
-type mapCall struct {
-	op mapOp
-	k  string
-	v  interface{}
-}
-
-func (c mapCall) apply(m mapInterface[string]) (interface{}, bool) {
-	switch c.op {
-	case opLoad:
-		return m.Load(c.k)
-	case opStore:
-		m.Store(c.k, c.v)
-		return nil, false
-	case opLoadOrStore:
-		return m.LoadOrStore(c.k, c.v)
-	case opDelete:
-		m.Delete(c.k)
-		return nil, false
-	default:
-		panic("invalid mapOp")
-	}
-}
-
-type mapResult struct {
-	value interface{}
-	ok    bool
-}
-
-func randValue(r *rand.Rand) string {
-	b := make([]byte, r.Intn(4))
-	for i := range b {
-		b[i] = 'a' + byte(rand.Intn(26))
-	}
-	return string(b)
-}
-
-func (mapCall) Generate(r *rand.Rand, size int) reflect.Value {
-	c := mapCall{op: mapOps[rand.Intn(len(mapOps))], k: randValue(r)}
-	switch c.op {
-	case opStore, opLoadOrStore:
-		c.v = randValue(r)
-	}
-	return reflect.ValueOf(c)
-}
-
-func applyCalls(m mapInterface[string], calls []mapCall) (results []mapResult, final map[interface{}]interface{}) {
-	for _, c := range calls {
-		v, ok := c.apply(m)
-		results = append(results, mapResult{v, ok})
-	}
-
-	final = make(map[interface{}]interface{})
-	m.Range(func(k string, v interface{}) bool {
-		final[k] = v
-		return true
+func Test_Conformance(t *testing.T) {
+	t.Run("Map", func(t *testing.T) {
+		syncmapttest.RunConformance(t, func() syncmapt.Interface[string, string] {
+			return new(syncmapt.Map[string, string])
+		})
+	})
+	t.Run("WithSnapshots", func(t *testing.T) {
+		syncmapttest.RunConformance(t, func() syncmapt.Interface[string, string] {
+			return syncmapt.New(syncmapt.WithSnapshots[string, string]())
+		})
 	})
-
-	return results, final
 }
 
 func TestConcurrentRange(t *testing.T) {
@@ -180,6 +129,163 @@ func Test_Any(t *testing.T) {
 	}
 }
 
+func Test_LoadAndDelete(t *testing.T) {
+	// Concurrent LoadAndDelete calls of a key hand its value to exactly one
+	// of them, including while the key is only in the dirty map.
+	for _, promoted := range []bool{true, false} {
+		var m syncmapt.Map[int, int]
+		for round := 0; round < 100; round++ {
+			m.Store(round, round)
+			if promoted {
+				m.Load(round)
+				m.Load(round)
+			}
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			winners := 0
+			for g := 0; g < 4; g++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if v, loaded := m.LoadAndDelete(round); loaded {
+						if v != round {
+							t.Errorf("LoadAndDelete(%d) = %d", round, v)
+						}
+						mu.Lock()
+						winners++
+						mu.Unlock()
+					}
+				}()
+			}
+			wg.Wait()
+			if winners != 1 {
+				t.Fatalf("%d LoadAndDelete calls of key %d reported loaded, want 1", winners, round)
+			}
+			if _, ok := m.Load(round); ok {
+				t.Fatalf("key %d still present after LoadAndDelete", round)
+			}
+		}
+	}
+}
+
+func Test_Swap(t *testing.T) {
+	for name, m := range map[string]*syncmapt.Map[string, int]{
+		"Map":           new(syncmapt.Map[string, int]),
+		"WithSnapshots": syncmapt.New(syncmapt.WithSnapshots[string, int]()),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if prev, loaded := m.Swap("a", 1); loaded {
+				t.Errorf("Swap of a new key = %v, true; want false", prev)
+			}
+			if prev, loaded := m.Swap("a", 2); !loaded || prev != 1 {
+				t.Errorf("Swap = %v, %v; want 1, true", prev, loaded)
+			}
+			m.Delete("a")
+			if prev, loaded := m.Swap("a", 3); loaded {
+				t.Errorf("Swap of a deleted key = %v, true; want false", prev)
+			}
+			if v, _ := m.Load("a"); v != 3 {
+				t.Errorf("Load after Swap = %v, want 3", v)
+			}
+
+			// Every value stored by a Swap is returned by exactly one later
+			// Swap, or is the final value.
+			const goroutines, swaps = 4, 1000
+			var wg sync.WaitGroup
+			seen := make([][]int, goroutines)
+			for g := 0; g < goroutines; g++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < swaps; i++ {
+						prev, _ := m.Swap("hot", g*swaps+i+1)
+						seen[g] = append(seen[g], prev)
+					}
+				}()
+			}
+			wg.Wait()
+			last, _ := m.Load("hot")
+			count := map[int]int{last: 1}
+			for _, vs := range seen {
+				for _, v := range vs {
+					count[v]++
+				}
+			}
+			if count[0] != 1 {
+				t.Errorf("%d Swaps reported no previous value, want 1", count[0])
+			}
+			for v := 1; v <= goroutines*swaps; v++ {
+				if count[v] != 1 {
+					t.Fatalf("value %d was observed %d times, want 1", v, count[v])
+				}
+			}
+		})
+	}
+}
+
+func Test_CompareAndSwap(t *testing.T) {
+	for name, m := range map[string]*syncmapt.Map[string, int]{
+		"Map":           new(syncmapt.Map[string, int]),
+		"WithSnapshots": syncmapt.New(syncmapt.WithSnapshots[string, int]()),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if syncmapt.CompareAndSwap(m, "a", 0, 1) {
+				t.Error("CompareAndSwap of a missing key succeeded")
+			}
+			m.Store("a", 1)
+			if syncmapt.CompareAndSwap(m, "a", 2, 3) {
+				t.Error("CompareAndSwap with the wrong old value succeeded")
+			}
+			if !syncmapt.CompareAndSwap(m, "a", 1, 2) {
+				t.Error("CompareAndSwap with the current value failed")
+			}
+			if syncmapt.CompareAndDelete(m, "a", 1) {
+				t.Error("CompareAndDelete with the wrong old value succeeded")
+			}
+			if !syncmapt.CompareAndDelete(m, "a", 2) {
+				t.Error("CompareAndDelete with the current value failed")
+			}
+			if _, ok := m.Load("a"); ok {
+				t.Error("key present after CompareAndDelete")
+			}
+
+			// Optimistic increments by concurrent goroutines are all kept.
+			const goroutines, increments = 4, 500
+			m.Store("counter", 0)
+			var wg sync.WaitGroup
+			for g := 0; g < goroutines; g++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < increments; i++ {
+						for {
+							v, _ := m.Load("counter")
+							if syncmapt.CompareAndSwap(m, "counter", v, v+1) {
+								break
+							}
+						}
+					}
+				}()
+			}
+			wg.Wait()
+			if v, _ := m.Load("counter"); v != goroutines*increments {
+				t.Errorf("counter = %d, want %d", v, goroutines*increments)
+			}
+		})
+	}
+
+	// Values that are not comparable are compared by the given function.
+	var m syncmapt.Map[string, []string]
+	m.Store("tags", []string{"a"})
+	eq := func(a, b []string) bool { return slices.Equal(a, b) }
+	if !m.CompareAndSwapFunc("tags", []string{"a"}, []string{"a", "b"}, eq) {
+		t.Error("CompareAndSwapFunc with an equal slice failed")
+	}
+	if !m.CompareAndDeleteFunc("tags", []string{"a", "b"}, eq) {
+		t.Error("CompareAndDeleteFunc with an equal slice failed")
+	}
+}
+
 func Test_Len(t *testing.T) {
 	m := new(syncmapt.Map[int, any])
 
@@ -209,6 +315,87 @@ func Test_Len(t *testing.T) {
 	}
 }
 
+func Test_LenCount(t *testing.T) {
+	for _, snapshots := range []bool{false, true} {
+		var opts []syncmapt.Option[int, int]
+		if snapshots {
+			opts = append(opts, syncmapt.WithSnapshots[int, int]())
+		}
+		m := syncmapt.New(opts...)
+		check := func(op string) {
+			t.Helper()
+			n := 0
+			m.Range(func(int, int) bool { n++; return true })
+			if m.Len() != n {
+				t.Fatalf("snapshots=%v: after %s, Len() = %d, want %d", snapshots, op, m.Len(), n)
+			}
+		}
+
+		for i := 0; i < 10; i++ {
+			m.Store(i, i)
+		}
+		m.Store(0, 1)
+		m.Swap(10, 10)
+		m.LoadOrStore(11, 11)
+		m.LoadOrStore(11, 12)
+		check("stores")
+		m.Delete(0)
+		m.Delete(0)
+		m.LoadAndDelete(1)
+		syncmapt.CompareAndDelete(m, 2, 2)
+		check("deletes")
+		m.Move(3, 30)
+		m.Move(4, 5)
+		m.MoveIfAbsent(6, 7)
+		check("moves")
+		m.Compute(40, func(int, bool) (int, bool) { return 40, false })
+		m.Compute(8, func(int, bool) (int, bool) { return 0, true })
+		check("Compute")
+		m.Clear()
+		check("Clear")
+		m.ReplaceAll(map[int]int{1: 1, 2: 2})
+		m.Store(3, 3)
+		check("ReplaceAll")
+		if c := m.Clone(); c.Len() != m.Len() {
+			t.Fatalf("Clone().Len() = %d, want %d", c.Len(), m.Len())
+		}
+	}
+
+	keyed := syncmapt.NewFromMap(map[string]int{"a": 1, "A": 2, "b": 3},
+		syncmapt.WithKeyTransform[string, int](strings.ToLower))
+	if keyed.Len() != 2 {
+		t.Fatalf("NewFromMap with folded keys: Len() = %d, want 2", keyed.Len())
+	}
+
+	m := new(syncmapt.Map[int, int])
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				k := rand.Intn(64)
+				switch i % 4 {
+				case 0:
+					m.Store(k, i)
+				case 1:
+					m.LoadAndDelete(k)
+				case 2:
+					m.Move(k, rand.Intn(64))
+				case 3:
+					m.LoadOrStore(k, i)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	n := 0
+	m.Range(func(int, int) bool { n++; return true })
+	if m.Len() != n {
+		t.Fatalf("after concurrent updates, Len() = %d, want %d", m.Len(), n)
+	}
+}
+
 func TestCustome(t *testing.T) {
 	type Custome struct {
 		Address    []string
@@ -238,3 +425,190 @@ func TestCustome(t *testing.T) {
 
 	t.Log(cust.Address)
 }
+
+func Test_UnicodeNormalization(t *testing.T) {
+	m := syncmapt.New(syncmapt.WithUnicodeNormalization[string, int](norm.NFC))
+
+	composed := "caf\u00e9"
+	decomposed := "cafe\u0301"
+
+	m.Store(composed, 1)
+	if v, ok := m.Load(decomposed); !ok || v != 1 {
+		t.Fatalf("Load(%q) = %v, %v; want 1, true", decomposed, v, ok)
+	}
+
+	m.Store(decomposed, 2)
+	if m.Len() != 1 {
+		t.Fatal("want equivalent keys to share one entry, got", m.Len())
+	}
+
+	m.Range(func(k string, _ int) bool {
+		if k != composed {
+			t.Fatalf("Range saw key %q, want NFC form %q", k, composed)
+		}
+		return true
+	})
+
+	m.Delete(decomposed)
+	if _, ok := m.Load(composed); ok {
+		t.Fatal("want entry deleted through equivalent key")
+	}
+}
+
+func Test_KeyTransform(t *testing.T) {
+	m := syncmapt.New(
+		syncmapt.WithKeyTransform[string, int](strings.TrimSpace),
+		syncmapt.WithKeyTransform[string, int](strings.ToLower),
+	)
+
+	m.Store("  Content-Type ", 1)
+	if v, ok := m.Load("content-type"); !ok || v != 1 {
+		t.Fatalf("Load = %v, %v; want 1, true", v, ok)
+	}
+
+	if _, loaded := m.LoadOrStore("CONTENT-TYPE", 2); !loaded {
+		t.Fatal("want LoadOrStore to find the transformed key")
+	}
+
+	if v, loaded := m.LoadAndDelete(" content-TYPE"); !loaded || v != 1 {
+		t.Fatalf("LoadAndDelete = %v, %v; want 1, true", v, loaded)
+	}
+
+	if m.Len() != 0 {
+		t.Fatal("unexpected", m.Len())
+	}
+}
+
+func Test_New(t *testing.T) {
+	var zero syncmapt.Map[string, int]
+	maps := map[string]*syncmapt.Map[string, int]{
+		"zero":            &zero,
+		"New":             syncmapt.New[string, int](),
+		"WithCapacity":    syncmapt.New(syncmapt.WithCapacity[string, int](64)),
+		"NewWithCapacity": syncmapt.NewWithCapacity[string, int](64),
+	}
+
+	for name, m := range maps {
+		for i := 0; i < 100; i++ {
+			m.Store(strconv.Itoa(i), i)
+		}
+		for i := 0; i < 100; i++ {
+			if v, ok := m.Load(strconv.Itoa(i)); !ok || v != i {
+				t.Fatalf("%s: Load(%d) = %v, %v", name, i, v, ok)
+			}
+		}
+		if m.Len() != 100 {
+			t.Fatalf("%s: unexpected Len %d", name, m.Len())
+		}
+	}
+}
+
+func BenchmarkStoreNewKeys(b *testing.B) {
+	const n = 1 << 16
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+	for _, bm := range []struct {
+		name string
+		new  func() *syncmapt.Map[string, int]
+	}{
+		{"New", func() *syncmapt.Map[string, int] { return syncmapt.New[string, int]() }},
+		{"NewWithCapacity", func() *syncmapt.Map[string, int] { return syncmapt.NewWithCapacity[string, int](n) }},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				m := bm.new()
+				for j, k := range keys {
+					m.Store(k, j)
+				}
+			}
+		})
+	}
+}
+
+func Test_Close(t *testing.T) {
+	type closingMap interface {
+		syncmapt.Interface[string, int]
+		io.Closer
+	}
+	wal, err := syncmapt.OpenWAL[string, int](t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, m := range map[string]closingMap{
+		"Map":         new(syncmapt.Map[string, int]),
+		"ShardedMap":  new(syncmapt.ShardedMap[string, int]),
+		"BoundedMap":  new(syncmapt.BoundedMap[string, int]),
+		"OrderedMap":  new(syncmapt.OrderedMap[string, int]),
+		"BiMap":       new(syncmapt.BiMap[string, int]),
+		"COWMap":      new(syncmapt.COWMap[string, int]),
+		"HashMap":     syncmapt.NewHashMap[string, int](func(s string) uint64 { return uint64(len(s)) }, func(a, b string) bool { return a == b }),
+		"ExpiringMap": syncmapt.NewExpiringMap[string, int](),
+		"WALMap":      wal,
+	} {
+		m.Store("a", 1)
+		if err := m.Close(); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := m.Close(); err != nil {
+			t.Fatalf("%s: want Close to be idempotent, got %v", name, err)
+		}
+		if v, ok := m.Load("a"); !ok || v != 1 {
+			t.Fatalf("%s: want contents readable after Close", name)
+		}
+
+		m.Store("a", 2)
+		m.Store("b", 2)
+		m.Delete("a")
+		if v, loaded := m.LoadOrStore("a", 3); !loaded || v != 1 {
+			t.Fatalf("%s: LoadOrStore after Close = %v, %v; want 1, true", name, v, loaded)
+		}
+		if v, loaded := m.LoadOrStore("c", 3); loaded || v != 0 {
+			t.Fatalf("%s: LoadOrStore after Close = %v, %v; want 0, false", name, v, loaded)
+		}
+		if _, loaded := m.LoadAndDelete("a"); loaded {
+			t.Fatalf("%s: LoadAndDelete after Close deleted a value", name)
+		}
+		if v, ok := m.Load("a"); !ok || v != 1 || m.Len() != 1 {
+			t.Fatalf("%s: map modified after Close", name)
+		}
+	}
+}
+
+// Test_NoRawAtomic64 guards 32-bit platforms (GOARCH=386, arm): 64-bit values
+// used with sync/atomic must be 8-byte aligned there, which the compiler only
+// guarantees for the atomic.Int64 and atomic.Uint64 types. No struct in the
+// package may therefore hold a plain 64-bit integer.
+func Test_NoRawAtomic64(t *testing.T) {
+	var check func(typ reflect.Type, path string)
+	check = func(typ reflect.Type, path string) {
+		if typ.Kind() != reflect.Struct || typ.PkgPath() == "sync/atomic" || typ.PkgPath() == "sync" {
+			return
+		}
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			switch f.Type.Kind() {
+			case reflect.Int64, reflect.Uint64:
+				t.Errorf("%s.%s is a plain %s; use atomic.%s if it is ever accessed atomically",
+					path, f.Name, f.Type, map[reflect.Kind]string{reflect.Int64: "Int64", reflect.Uint64: "Uint64"}[f.Type.Kind()])
+			case reflect.Struct:
+				check(f.Type, path+"."+f.Name)
+			}
+		}
+	}
+	check(reflect.TypeOf(syncmapt.Map[string, int]{}), "Map")
+
+	if unsafe.Sizeof(uintptr(0)) == 4 {
+		// Exercise the atomic paths on a 32-bit build.
+		m := new(syncmapt.Map[int, int])
+		for i := 0; i < 1000; i++ {
+			m.Store(i, i)
+			m.LoadOrStore(i, -i)
+		}
+		if m.Len() != 1000 {
+			t.Fatal("unexpected", m.Len())
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Package maps defines generic functions useful with syncmapt maps.
+//
+// It mirrors golang.org/x/exp/maps, so that code written against that
+// package can be ported to concurrent maps by changing the import path.
+// Every function accepts any syncmapt.Interface. Because the maps may be
+// modified concurrently, the results reflect the contents observed during a
+// single Range call of each map, not necessarily a consistent snapshot.
+package maps
+
+import "github.com/holdno/syncmapt"
+
+// Keys returns the keys of the map m.
+// The keys will be in an indeterminate order.
+func Keys[K comparable, V any](m syncmapt.Interface[K, V]) []K {
+	r := make([]K, 0, m.Len())
+	m.Range(func(k K, _ V) bool {
+		r = append(r, k)
+		return true
+	})
+	return r
+}
+
+// Values returns the values of the map m.
+// The values will be in an indeterminate order.
+func Values[K comparable, V any](m syncmapt.Interface[K, V]) []V {
+	r := make([]V, 0, m.Len())
+	m.Range(func(_ K, v V) bool {
+		r = append(r, v)
+		return true
+	})
+	return r
+}
+
+// Equal reports whether two maps contain the same key/value pairs.
+// Values are compared using ==.
+func Equal[K, V comparable](m1, m2 syncmapt.Interface[K, V]) bool {
+	return EqualFunc(m1, m2, func(v1, v2 V) bool { return v1 == v2 })
+}
+
+// EqualFunc is like Equal, but compares values using eq.
+// Keys are still compared with ==.
+func EqualFunc[K comparable, V1, V2 any](m1 syncmapt.Interface[K, V1], m2 syncmapt.Interface[K, V2], eq func(V1, V2) bool) bool {
+	n, equal := 0, true
+	m1.Range(func(k K, v1 V1) bool {
+		n++
+		v2, ok := m2.Load(k)
+		equal = ok && eq(v1, v2)
+		return equal
+	})
+	return equal && n == m2.Len()
+}
+
+// Clear removes all entries from m, leaving it empty.
+func Clear[K comparable, V any](m syncmapt.Interface[K, V]) {
+	m.Range(func(k K, _ V) bool {
+		m.Delete(k)
+		return true
+	})
+}
+
+// Clone returns a copy of m. This is a shallow clone:
+// the new keys and values are set using ordinary assignment.
+func Clone[K comparable, V any](m syncmapt.Interface[K, V]) *syncmapt.Map[K, V] {
+	r := new(syncmapt.Map[K, V])
+	Copy(r, m)
+	return r
+}
+
+// Copy copies all key/value pairs in src adding them to dst.
+// When a key in src is already present in dst,
+// the value in dst will be overwritten by the value associated
+// with the key in src.
+func Copy[K comparable, V any](dst, src syncmapt.Interface[K, V]) {
+	src.Range(func(k K, v V) bool {
+		dst.Store(k, v)
+		return true
+	})
+}
+
+// DeleteFunc deletes any key/value pairs from m for which del returns true.
+func DeleteFunc[K comparable, V any](m syncmapt.Interface[K, V], del func(K, V) bool) {
+	m.Range(func(k K, v V) bool {
+		if del(k, v) {
+			m.Delete(k)
+		}
+		return true
+	})
+}
@@ -0,0 +1,68 @@
+package maps_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/holdno/syncmapt/maps"
+	"github.com/holdno/syncmapt/syncmapttest"
+)
+
+func TestKeysValues(t *testing.T) {
+	m := syncmapttest.New(map[int]string{1: "one", 2: "two", 3: "three"})
+
+	keys := maps.Keys(m)
+	slices.Sort(keys)
+	if want := []int{1, 2, 3}; !slices.Equal(keys, want) {
+		t.Fatalf("Keys = %v, want %v", keys, want)
+	}
+
+	values := maps.Values(m)
+	slices.Sort(values)
+	if want := []string{"one", "three", "two"}; !slices.Equal(values, want) {
+		t.Fatalf("Values = %v, want %v", values, want)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	a := syncmapttest.New(map[string]int{"a": 1, "b": 2})
+	b := syncmapttest.New(map[string]int{"a": 1, "b": 2})
+	if !maps.Equal(a, b) {
+		t.Fatal("want equal maps")
+	}
+
+	b.Store("c", 3)
+	if maps.Equal(a, b) || maps.Equal(b, a) {
+		t.Fatal("want maps with different key sets to differ")
+	}
+
+	b.Delete("c")
+	b.Store("b", 20)
+	if maps.Equal(a, b) {
+		t.Fatal("want maps with different values to differ")
+	}
+	if !maps.EqualFunc(a, b, func(x, y int) bool { return x%10 == y%10 || x*10 == y }) {
+		t.Fatal("want EqualFunc to use eq")
+	}
+}
+
+func TestCloneCopyClearDeleteFunc(t *testing.T) {
+	src := syncmapttest.New(map[string]int{"a": 1, "b": 2, "c": 3})
+
+	c := maps.Clone(src)
+	syncmapttest.RequireEqual(t, c, map[string]int{"a": 1, "b": 2, "c": 3})
+	c.Store("a", 10)
+	if v, _ := src.Load("a"); v != 1 {
+		t.Fatal("want Clone to be independent of its source")
+	}
+
+	dst := syncmapttest.New(map[string]int{"a": 0, "z": 26})
+	maps.Copy(dst, src)
+	syncmapttest.RequireEqual(t, dst, map[string]int{"a": 1, "b": 2, "c": 3, "z": 26})
+
+	maps.DeleteFunc(dst, func(_ string, v int) bool { return v%2 == 0 })
+	syncmapttest.RequireEqual(t, dst, map[string]int{"a": 1, "c": 3})
+
+	maps.Clear(dst)
+	syncmapttest.RequireEqual(t, dst, map[string]int{})
+}
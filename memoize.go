@@ -0,0 +1,54 @@
+package syncmapt
+
+import "time"
+
+// A memoResult is a result of a memoized function.
+type memoResult[V any] struct {
+	val     V
+	expires time.Time // zero if the result never expires
+}
+
+// Memoize returns a function that caches the results of f by key.
+//
+// Concurrent calls for the same key are deduplicated as by Map.LoadOrCompute:
+// only one of them runs f, and the others wait for and share its result.
+// Errors are not cached: if f fails, every caller waiting on that call
+// receives the error, and the next call for the key runs f again. If f
+// panics, the panic propagates to the caller that ran it, and the waiting
+// callers try again.
+//
+// Of the Options, WithTTL bounds how long a result is reused, WithClock sets
+// the time source for it, and WithKeyTransform canonicalizes keys before they
+// are looked up and passed to f.
+func Memoize[K comparable, V any](f func(K) (V, error), opts ...Option[K, V]) func(K) (V, error) {
+	cfg := newConfig(opts)
+	var results Map[K, *memoResult[V]]
+
+	return func(key K) (V, error) {
+		if cfg.keyTransform != nil {
+			key = cfg.keyTransform(key)
+		}
+		for {
+			r, ok := results.load(key)
+			if !ok {
+				var err error
+				if r, _, err = results.loadOrCompute(key, func() (*memoResult[V], error) {
+					r := new(memoResult[V])
+					var err error
+					if r.val, err = f(key); err == nil && cfg.ttl > 0 {
+						r.expires = cfg.clock.Now().Add(cfg.ttl)
+					}
+					return r, err
+				}); err != nil {
+					return r.val, err
+				}
+			}
+			if r.expires.IsZero() || cfg.clock.Now().Before(r.expires) {
+				return r.val, nil
+			}
+			// The result expired: retire it, unless another caller already
+			// replaced it, and try again.
+			results.deleteIf(key, func(v *memoResult[V]) bool { return v == r })
+		}
+	}
+}
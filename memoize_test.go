@@ -0,0 +1,129 @@
+package syncmapt_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/holdno/syncmapt"
+)
+
+// fakeClock is a syncmapt.Clock that only moves when told to.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock { return &fakeClock{now: time.Unix(1e9, 0)} }
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+func TestMemoize(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	f := syncmapt.Memoize(func(k string) (int, error) {
+		calls.Add(1)
+		<-release
+		return len(k), nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := f("abc"); err != nil || v != 3 {
+				t.Errorf("f = %v, %v; want 3, nil", v, err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("want concurrent calls deduplicated, f ran %d times", n)
+	}
+	f("abc")
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("want cached result reused, f ran %d times", n)
+	}
+	if testing.AllocsPerRun(100, func() { f("abc") }) != 0 {
+		t.Fatal("cached call allocates")
+	}
+}
+
+func TestMemoizeError(t *testing.T) {
+	var calls int
+	errBoom := errors.New("boom")
+	f := syncmapt.Memoize(func(k string) (int, error) {
+		calls++
+		if calls == 1 {
+			return 0, errBoom
+		}
+		return 1, nil
+	})
+
+	if _, err := f("k"); err != errBoom {
+		t.Fatal("want errBoom, got", err)
+	}
+	if v, err := f("k"); err != nil || v != 1 {
+		t.Fatalf("want errors not cached, got %v, %v", v, err)
+	}
+	if calls != 2 {
+		t.Fatal("unexpected", calls)
+	}
+}
+
+func TestMemoizeTTL(t *testing.T) {
+	clock := newFakeClock()
+	var calls int
+	f := syncmapt.Memoize(func(k string) (int, error) {
+		calls++
+		return calls, nil
+	}, syncmapt.WithTTL[string, int](time.Minute), syncmapt.WithClock[string, int](clock))
+
+	f("k")
+	clock.Advance(30 * time.Second)
+	if v, _ := f("k"); v != 1 {
+		t.Fatal("want cached result before TTL, got", v)
+	}
+	clock.Advance(time.Minute)
+	if v, _ := f("k"); v != 2 {
+		t.Fatal("want recomputed result after TTL, got", v)
+	}
+}
+
+func TestMemoizePanic(t *testing.T) {
+	f := syncmapt.Memoize(func(k string) (int, error) {
+		if k == "panic" {
+			panic("boom")
+		}
+		return 1, nil
+	})
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("want panic to propagate")
+			}
+		}()
+		f("panic")
+	}()
+
+	if v, err := f("ok"); err != nil || v != 1 {
+		t.Fatal("unexpected", v, err)
+	}
+}
@@ -0,0 +1,202 @@
+package syncmapt
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// Move atomically moves the value stored for oldKey to newKey, replacing any
+// value stored for newKey. Concurrent operations observe the Map either before
+// or after the move: never with the value present under both keys, and never
+// with it missing from both.
+//
+// The moved result reports whether oldKey was present. Moving a key onto
+// itself leaves the Map unchanged.
+func (m *Map[K, V]) Move(oldKey, newKey K) (moved bool) {
+	return m.move(oldKey, newKey, true)
+}
+
+// MoveIfAbsent is like Move, but leaves the Map unchanged and returns false
+// if a value is already stored for newKey.
+func (m *Map[K, V]) MoveIfAbsent(oldKey, newKey K) (moved bool) {
+	return m.move(oldKey, newKey, false)
+}
+
+func (m *Map[K, V]) move(oldKey, newKey K, overwrite bool) bool {
+	if m.Closed() {
+		return false
+	}
+	oldKey, newKey = m.key(oldKey), m.key(newKey)
+	if oldKey == newKey {
+		_, ok := m.load(oldKey)
+		return ok && overwrite
+	}
+
+	m.mu.Lock()
+	moved := m.moveLocked(oldKey, newKey, overwrite)
+	m.mu.Unlock()
+
+	if moved {
+		m.moved(oldKey, newKey)
+	}
+	return moved
+}
+
+func (m *Map[K, V]) moveLocked(oldKey, newKey K, overwrite bool) bool {
+	read, _ := m.read.Load().(readOnly[K, V])
+	src, ok := read.m[oldKey]
+	if !ok {
+		if src, ok = m.dirty[oldKey]; !ok {
+			return false
+		}
+	}
+	dst := m.entryLocked(newKey)
+
+	// Mark the source as moving: lock-free operations on oldKey wait until the
+	// value is published under newKey and the source is cleared.
+	p := atomic.LoadPointer(&src.p)
+	var v *V
+	for {
+		var ok bool
+		if v, ok = valueOf[V](&m.ver, p); !ok {
+			return false
+		}
+		if atomic.CompareAndSwapPointer(&src.p, p, moving) {
+			break
+		}
+		p = atomic.LoadPointer(&src.p)
+	}
+
+	nv := newValue(&m.ver, *v)
+	for {
+		q := atomic.LoadPointer(&dst.p)
+		if _, ok := valueOf[V](&m.ver, q); ok && !overwrite {
+			atomic.StorePointer(&src.p, p)
+			return false
+		}
+		if dst.publish(&m.ver, q, nv) {
+			break
+		}
+	}
+	src.bury(&m.ver, p)
+	return true
+}
+
+// bury clears e, which is marked as moving, after its value p has been moved
+// away.
+func (e *entry[V]) bury(vs *versions, p unsafe.Pointer) {
+	e.n.Add(-1)
+	if !vs.on {
+		atomic.StorePointer(&e.p, nil)
+		return
+	}
+	(*version[V])(p).bornIn(vs)
+	tomb := &version[V]{dead: true, prev: p}
+	atomic.StorePointer(&e.p, unsafe.Pointer(tomb))
+	tomb.settle(vs)
+}
+
+// release replaces the mark of e, which is moving, with nv, which replaces
+// the value p that e held when it was marked and must not have been
+// published yet.
+func (e *entry[V]) release(vs *versions, p, nv unsafe.Pointer) {
+	if !vs.on {
+		atomic.StorePointer(&e.p, nv)
+		e.recount(vs, p, nv)
+		return
+	}
+	if p != nil {
+		(*version[V])(p).bornIn(vs)
+	}
+	v := (*version[V])(nv)
+	v.prev = p
+	atomic.StorePointer(&e.p, nv)
+	v.settle(vs)
+	e.recount(vs, p, nv)
+}
+
+// entryLocked returns the entry for key, adding an empty one to the dirty map
+// if there is none, so that a value can be published for key with a single
+// atomic store. The returned entry is not expunged.
+func (m *Map[K, V]) entryLocked(key K) *entry[V] {
+	read, _ := m.read.Load().(readOnly[K, V])
+	if e, ok := read.m[key]; ok {
+		if e.unexpungeLocked() {
+			m.dirty[key] = e
+		}
+		return e
+	}
+	if e, ok := m.dirty[key]; ok {
+		return e
+	}
+	if !read.amended {
+		m.dirtyLocked()
+		m.read.Store(readOnly[K, V]{m: read.m, amended: true})
+	}
+	e := &entry[V]{n: m.countLocked()}
+	m.dirty[key] = e
+	return e
+}
+
+// MoveFunc moves every entry for which pred returns true from m to dst,
+// replacing entries of dst with the same keys, and returns the number of
+// entries moved. Each entry moves atomically: concurrent operations never
+// observe it in both maps or in neither.
+//
+// pred is called with the internal locks of both maps held, so it must not
+// call methods of m or dst. If dst is m, MoveFunc does nothing and returns 0.
+func (m *Map[K, V]) MoveFunc(dst *Map[K, V], pred func(key K, value V) bool) int {
+	if dst == m {
+		return 0
+	}
+	if m.Closed() || dst.Closed() {
+		return 0
+	}
+
+	// Lock the maps in address order, so that concurrent MoveFuncs in
+	// opposite directions cannot deadlock.
+	first, second := m, dst
+	if uintptr(unsafe.Pointer(dst)) < uintptr(unsafe.Pointer(m)) {
+		first, second = dst, m
+	}
+	first.mu.Lock()
+	second.mu.Lock()
+
+	read := m.promotedLocked()
+
+	type move struct {
+		src, dst K
+		v        V
+	}
+	var moves []move
+	for k, src := range read.m {
+		p := atomic.LoadPointer(&src.p)
+		for {
+			v, ok := valueOf[V](&m.ver, p)
+			if !ok || !pred(k, *v) {
+				break
+			}
+			if !atomic.CompareAndSwapPointer(&src.p, p, moving) {
+				p = atomic.LoadPointer(&src.p)
+				continue
+			}
+			dk := dst.key(k)
+			de := dst.entryLocked(dk)
+			nv := newValue(&dst.ver, *v)
+			for !de.publish(&dst.ver, atomic.LoadPointer(&de.p), nv) {
+			}
+			src.bury(&m.ver, p)
+			moves = append(moves, move{k, dk, *v})
+			break
+		}
+	}
+
+	second.mu.Unlock()
+	first.mu.Unlock()
+
+	for _, mv := range moves {
+		m.deleted(mv.src, mv.v)
+		dst.stored(mv.dst, mv.v)
+	}
+	return len(moves)
+}
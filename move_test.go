@@ -0,0 +1,121 @@
+package syncmapt_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+	"github.com/holdno/syncmapt/syncmapttest"
+)
+
+func TestMove(t *testing.T) {
+	m := syncmapttest.New(map[string]int{"old": 1, "other": 2})
+
+	if !m.Move("old", "new") {
+		t.Fatal("want Move of a present key to succeed")
+	}
+	syncmapttest.RequireEqual(t, m, map[string]int{"new": 1, "other": 2})
+
+	if m.Move("missing", "new") {
+		t.Fatal("want Move of a missing key to fail")
+	}
+
+	if !m.Move("new", "other") {
+		t.Fatal("want Move to overwrite")
+	}
+	syncmapttest.RequireEqual(t, m, map[string]int{"other": 1})
+
+	if !m.Move("other", "other") {
+		t.Fatal("want Move onto itself to report presence")
+	}
+	syncmapttest.RequireEqual(t, m, map[string]int{"other": 1})
+}
+
+func TestMoveIfAbsent(t *testing.T) {
+	m := syncmapttest.New(map[string]int{"a": 1, "b": 2})
+
+	if m.MoveIfAbsent("a", "b") {
+		t.Fatal("want MoveIfAbsent onto a present key to fail")
+	}
+	syncmapttest.RequireEqual(t, m, map[string]int{"a": 1, "b": 2})
+
+	m.Delete("b")
+	if !m.MoveIfAbsent("a", "b") {
+		t.Fatal("want MoveIfAbsent onto a deleted key to succeed")
+	}
+	syncmapttest.RequireEqual(t, m, map[string]int{"b": 1})
+}
+
+func TestMoveConcurrent(t *testing.T) {
+	m := new(syncmapt.Map[int, int])
+	m.Store(0, 42)
+
+	const keys = 8
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				for k := 0; k < keys; k++ {
+					if v, ok := m.Load(k); ok && v != 42 {
+						t.Errorf("Load(%d) = %d", k, v)
+					}
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 10000; i++ {
+		m.Move(i%keys, (i+1)%keys)
+	}
+	close(stop)
+	wg.Wait()
+
+	if n := m.Len(); n != 1 {
+		t.Fatalf("want the value under exactly one key, got %d keys", n)
+	}
+}
+
+func TestMoveFunc(t *testing.T) {
+	retries := syncmapttest.New(map[string]int{"a": 1, "b": 5, "c": 7, "d": 2})
+	ready := syncmapttest.New(map[string]int{"c": 0})
+
+	n := retries.MoveFunc(ready, func(_ string, attempts int) bool { return attempts > 3 })
+	if n != 2 {
+		t.Fatal("want 2 entries moved, got", n)
+	}
+	syncmapttest.RequireEqual(t, retries, map[string]int{"a": 1, "d": 2})
+	syncmapttest.RequireEqual(t, ready, map[string]int{"b": 5, "c": 7})
+
+	if n := retries.MoveFunc(retries, func(string, int) bool { return true }); n != 0 {
+		t.Fatal("want MoveFunc into itself to do nothing, got", n)
+	}
+}
+
+func TestMoveFuncConcurrent(t *testing.T) {
+	a, b := new(syncmapt.Map[int, int]), new(syncmapt.Map[int, int])
+	const n = 1000
+	for i := 0; i < n; i++ {
+		a.Store(i, i)
+	}
+
+	var wg sync.WaitGroup
+	all := func(int, int) bool { return true }
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() { defer wg.Done(); a.MoveFunc(b, all) }()
+		go func() { defer wg.Done(); b.MoveFunc(a, all) }()
+	}
+	wg.Wait()
+
+	if got := a.Len() + b.Len(); got != n {
+		t.Fatalf("want %d entries across both maps, got %d", n, got)
+	}
+}
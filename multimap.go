@@ -0,0 +1,112 @@
+package syncmapt
+
+import "slices"
+
+// A MultiMap is a concurrent map from keys to lists of values. Append and
+// RemoveValue update the list of a key atomically, so concurrent appends to
+// the same key are never lost, as they can be with a Load and a Store on a
+// Map of slices.
+//
+// A MultiMap keeps the list of each key in a Map and replaces it with an
+// updated copy on every change, so lookups take no lock. It suits keys that
+// hold a few values each.
+//
+// The zero MultiMap is empty and ready for use. A MultiMap must not be copied
+// after first use.
+type MultiMap[K, V comparable] struct {
+	m Map[K, []V]
+}
+
+// NewMultiMap returns an empty MultiMap configured by opts, which are the
+// Options of a Map of lists of values.
+func NewMultiMap[K, V comparable](opts ...Option[K, []V]) *MultiMap[K, V] {
+	mm := new(MultiMap[K, V])
+	mm.m.configure(opts)
+	return mm
+}
+
+// Append appends values to the list of key, creating it if there is none.
+func (mm *MultiMap[K, V]) Append(key K, values ...V) {
+	if len(values) == 0 {
+		return
+	}
+	mm.m.Compute(key, func(old []V, _ bool) ([]V, bool) {
+		// Copy the list: concurrent readers may be reading it.
+		return append(old[:len(old):len(old)], values...), false
+	})
+}
+
+// GetAll returns a copy of the list of values of key, in the order they were
+// appended, or nil if there is none.
+func (mm *MultiMap[K, V]) GetAll(key K) []V {
+	values, _ := mm.m.Load(key)
+	return slices.Clone(values)
+}
+
+// Has reports whether the list of key holds value.
+func (mm *MultiMap[K, V]) Has(key K, value V) bool {
+	values, _ := mm.m.Load(key)
+	return slices.Contains(values, value)
+}
+
+// RemoveValue removes every occurrence of value from the list of key, and
+// deletes key if its list becomes empty. It reports whether value was found.
+func (mm *MultiMap[K, V]) RemoveValue(key K, value V) (removed bool) {
+	if !mm.Has(key, value) {
+		return false
+	}
+	mm.m.Compute(key, func(old []V, loaded bool) ([]V, bool) {
+		removed = slices.Contains(old, value)
+		if !removed {
+			return old, !loaded
+		}
+		kept := make([]V, 0, len(old)-1)
+		for _, v := range old {
+			if v != value {
+				kept = append(kept, v)
+			}
+		}
+		return kept, len(kept) == 0
+	})
+	return removed
+}
+
+// Delete deletes key and its list of values.
+func (mm *MultiMap[K, V]) Delete(key K) {
+	mm.m.Delete(key)
+}
+
+// Range calls f sequentially for each key and its list of values. If f
+// returns false, range stops the iteration. f must not modify values. Range
+// has the semantics of Map.Range.
+func (mm *MultiMap[K, V]) Range(f func(key K, values []V) bool) {
+	mm.m.Range(f)
+}
+
+// Len returns the number of keys in the map.
+func (mm *MultiMap[K, V]) Len() int {
+	return mm.m.Len()
+}
+
+// GroupBy returns a new MultiMap holding items grouped by the key returned by
+// keyFn, each group in the order of items. The groups are allocated at their
+// final size and go straight into the map's storage, so building the map
+// takes no lock and no copying of lists.
+func GroupBy[T, K comparable](items []T, keyFn func(T) K) *MultiMap[K, T] {
+	keys := make([]K, len(items))
+	sizes := make(map[K]int)
+	for i, item := range items {
+		keys[i] = keyFn(item)
+		sizes[keys[i]]++
+	}
+	groups := make(map[K][]T, len(sizes))
+	for k, n := range sizes {
+		groups[k] = make([]T, 0, n)
+	}
+	for i, item := range items {
+		groups[keys[i]] = append(groups[keys[i]], item)
+	}
+	mm := new(MultiMap[K, T])
+	mm.m.fill(groups)
+	return mm
+}
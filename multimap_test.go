@@ -0,0 +1,78 @@
+package syncmapt_test
+
+import (
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+)
+
+func TestMultiMap(t *testing.T) {
+	var mm syncmapt.MultiMap[string, int]
+	mm.Append("a", 1, 2)
+	mm.Append("a", 1)
+	mm.Append("b", 3)
+	if got := mm.GetAll("a"); !slices.Equal(got, []int{1, 2, 1}) {
+		t.Fatalf("GetAll(a) = %v, want [1 2 1]", got)
+	}
+	got := mm.GetAll("b")
+	got[0] = 100
+	if !mm.Has("b", 3) {
+		t.Fatal("modifying the result of GetAll changed the map")
+	}
+
+	if !mm.RemoveValue("a", 1) || mm.RemoveValue("a", 1) {
+		t.Fatal("RemoveValue did not report whether the value was found")
+	}
+	if got := mm.GetAll("a"); !slices.Equal(got, []int{2}) {
+		t.Fatalf("GetAll(a) after RemoveValue(a, 1) = %v, want [2]", got)
+	}
+	mm.RemoveValue("a", 2)
+	if mm.Len() != 1 || mm.GetAll("a") != nil {
+		t.Fatalf("RemoveValue of the last value did not delete the key: Len() = %d", mm.Len())
+	}
+	mm.Delete("b")
+	if mm.Len() != 0 {
+		t.Fatalf("Len() = %d after Delete, want 0", mm.Len())
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	words := []string{"apple", "avocado", "banana", "blueberry", "cherry", "apricot"}
+	mm := syncmapt.GroupBy(words, func(w string) byte { return w[0] })
+	if mm.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3", mm.Len())
+	}
+	if got := mm.GetAll('a'); !slices.Equal(got, []string{"apple", "avocado", "apricot"}) {
+		t.Fatalf("GetAll('a') = %v", got)
+	}
+	mm.Append('c', "cranberry")
+	if got := mm.GetAll('c'); !slices.Equal(got, []string{"cherry", "cranberry"}) {
+		t.Fatalf("GetAll('c') after Append = %v", got)
+	}
+	if syncmapt.GroupBy([]int(nil), func(int) int { return 0 }).Len() != 0 {
+		t.Fatal("GroupBy of no items is not empty")
+	}
+}
+
+func TestMultiMapConcurrentAppend(t *testing.T) {
+	var mm syncmapt.MultiMap[string, int]
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				mm.Append("k", g*100+i)
+				mm.GetAll("k")
+			}
+		}()
+	}
+	wg.Wait()
+	values := mm.GetAll("k")
+	slices.Sort(values)
+	if len(values) != 800 || len(slices.Compact(values)) != 800 {
+		t.Fatalf("got %d distinct values out of %d appended, want 800", len(values), 800)
+	}
+}
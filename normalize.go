@@ -0,0 +1,14 @@
+package syncmapt
+
+import "golang.org/x/text/unicode/norm"
+
+// WithUnicodeNormalization returns an Option that normalizes string keys to the
+// given Unicode normalization form (typically norm.NFC or norm.NFKC) before
+// every operation, so that canonically equivalent keys refer to the same entry.
+//
+// It is a key transform and composes with WithKeyTransform in option order.
+func WithUnicodeNormalization[K ~string, V any](form norm.Form) Option[K, V] {
+	return WithKeyTransform[K, V](func(k K) K {
+		return K(form.String(string(k)))
+	})
+}
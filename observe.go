@@ -0,0 +1,117 @@
+package syncmapt
+
+import "slices"
+
+// An observer is told about changes to the contents of a Map. Its methods are
+// called after the change, outside of the Map's internal locks, by the
+// goroutine that made it, and possibly concurrently. They only identify what
+// changed: observers that need the new contents read them from the Map, which
+// makes them robust to notifications for the same key arriving out of order.
+type observer[K comparable] interface {
+	// keyChanged is called after a value has been stored or deleted for key.
+	keyChanged(key K)
+	// reset is called after the entire contents have been replaced.
+	reset()
+}
+
+// An expiryObserver is an observer that distinguishes values deleted because
+// they expired from other deletions.
+type expiryObserver[K comparable] interface {
+	observer[K]
+	// keyExpired is called instead of keyChanged after the value for key
+	// has been deleted because it expired.
+	keyExpired(key K)
+}
+
+func (m *Map[K, V]) addObserver(o observer[K]) {
+	m.obsMu.Lock()
+	var list []observer[K]
+	if old := m.observers.Load(); old != nil {
+		list = slices.Clone(*old)
+	}
+	list = append(list, o)
+	m.observers.Store(&list)
+	m.obsMu.Unlock()
+}
+
+func (m *Map[K, V]) removeObserver(o observer[K]) {
+	m.obsMu.Lock()
+	if old := m.observers.Load(); old != nil {
+		list := slices.DeleteFunc(slices.Clone(*old), func(x observer[K]) bool { return x == o })
+		if len(list) == 0 {
+			m.observers.Store(nil)
+		} else {
+			m.observers.Store(&list)
+		}
+	}
+	m.obsMu.Unlock()
+}
+
+// stored must be called after value has been stored for key.
+func (m *Map[K, V]) stored(key K, value V) {
+	m.wake(key)
+	m.changed(key)
+	if m.counting() {
+		m.stats.stores.Add(1)
+	}
+	if h := m.hooks.Load(); h != nil {
+		h.run(h.store, key, value)
+	}
+}
+
+// deleted must be called after value, stored for key, has been deleted.
+func (m *Map[K, V]) deleted(key K, value V) {
+	m.changed(key)
+	if m.counting() {
+		m.stats.deletes.Add(1)
+	}
+	if h := m.hooks.Load(); h != nil {
+		h.run(h.delete, key, value)
+	}
+}
+
+// expired must be called after value, stored for key, has been deleted
+// because it expired.
+func (m *Map[K, V]) expired(key K, value V) {
+	if list := m.observers.Load(); list != nil {
+		for _, o := range *list {
+			if e, ok := o.(expiryObserver[K]); ok {
+				e.keyExpired(key)
+			} else {
+				o.keyChanged(key)
+			}
+		}
+	}
+	if m.counting() {
+		m.stats.expirations.Add(1)
+	}
+	if h := m.hooks.Load(); h != nil {
+		h.run(h.evict, key, value)
+	}
+}
+
+// moved must be called after the value for oldKey has been moved to newKey.
+// A move within a map is not reported to its hooks, as the value is still
+// in the map.
+func (m *Map[K, V]) moved(oldKey, newKey K) {
+	m.changed(oldKey)
+	m.wake(newKey)
+	m.changed(newKey)
+}
+
+func (m *Map[K, V]) changed(key K) {
+	if list := m.observers.Load(); list != nil {
+		for _, o := range *list {
+			o.keyChanged(key)
+		}
+	}
+}
+
+// resetAll must be called after the entire contents have been replaced.
+func (m *Map[K, V]) resetAll() {
+	if list := m.observers.Load(); list != nil {
+		for _, o := range *list {
+			o.reset()
+		}
+	}
+}
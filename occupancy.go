@@ -0,0 +1,58 @@
+package syncmapt
+
+import "sync/atomic"
+
+// Occupancy describes how the internal storage of a Map is used.
+type Occupancy struct {
+	// Live is the number of entries holding a value.
+	Live int
+	// Tombstones is the number of deleted entries that still occupy a slot in
+	// the internal maps. They are dropped when the dirty map is next rebuilt.
+	Tombstones int
+
+	// ReadSlots and DirtySlots are the sizes of the lock-free read map and of
+	// the locked dirty map. A nil dirty map has no slots.
+	ReadSlots  int
+	DirtySlots int
+	// Misses counts the loads that fell through to the dirty map since it was
+	// last promoted. The dirty map is promoted when Misses reaches DirtySlots.
+	Misses int
+}
+
+// TombstoneRatio returns the fraction of occupied slots that hold deleted
+// entries.
+func (o Occupancy) TombstoneRatio() float64 {
+	if o.Live+o.Tombstones == 0 {
+		return 0
+	}
+	return float64(o.Tombstones) / float64(o.Live+o.Tombstones)
+}
+
+// Occupancy reports how the Map's internal storage is used. It takes the
+// Map's internal lock and visits every slot, so it is intended for occasional
+// diagnostics rather than for hot paths.
+func (m *Map[K, V]) Occupancy() Occupancy {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	read, _ := m.read.Load().(readOnly[K, V])
+	o := Occupancy{ReadSlots: len(read.m), DirtySlots: len(m.dirty), Misses: m.misses}
+	count := func(e *entry[V]) {
+		if _, ok := valueOf[V](&m.ver, atomic.LoadPointer(&e.p)); ok {
+			o.Live++
+		} else {
+			o.Tombstones++
+		}
+	}
+	for _, e := range read.m {
+		count(e)
+	}
+	if read.amended {
+		for k, e := range m.dirty {
+			if _, ok := read.m[k]; !ok {
+				count(e)
+			}
+		}
+	}
+	return o
+}
@@ -0,0 +1,30 @@
+package syncmapt_test
+
+import (
+	"testing"
+
+	"github.com/holdno/syncmapt"
+)
+
+func TestOccupancy(t *testing.T) {
+	m := new(syncmapt.Map[int, int])
+	if o := m.Occupancy(); o != (syncmapt.Occupancy{}) {
+		t.Fatalf("want empty Occupancy for zero Map, got %+v", o)
+	}
+
+	for i := 0; i < 10; i++ {
+		m.Store(i, i)
+	}
+	m.Range(func(int, int) bool { return true }) // promote to the read map
+	for i := 0; i < 4; i++ {
+		m.Delete(i)
+	}
+
+	o := m.Occupancy()
+	if o.Live != 6 || o.Tombstones != 4 || o.ReadSlots != 10 {
+		t.Fatalf("unexpected %+v", o)
+	}
+	if r := o.TombstoneRatio(); r != 0.4 {
+		t.Fatal("unexpected TombstoneRatio", r)
+	}
+}
@@ -0,0 +1,200 @@
+package offheap
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"sync"
+	"sync/atomic"
+)
+
+// A handle locates a block of arena memory: the index of its chunk in the
+// upper 32 bits and its offset in the chunk in the lower 32 bits. A block
+// starts with the length of the value it holds, as a 4-byte prefix.
+type handle uint64
+
+func (h handle) chunk() uint32 { return uint32(h >> 32) }
+func (h handle) off() uint32   { return uint32(h) }
+
+const (
+	prefix    = 4
+	minClass  = 5  // smallest block, 32 bytes
+	maxClass  = 16 // largest block carved from a chunk, 64 KiB
+	chunkSize = 1 << 20
+)
+
+// class returns the size class of a block of n bytes, or 0 if the block needs
+// a chunk of its own.
+func class(n int) int {
+	if n > 1<<maxClass {
+		return 0
+	}
+	return max(bits.Len(uint(n-1)), minClass)
+}
+
+// An arena allocates blocks from chunks of memory obtained with mapMemory.
+// Blocks of up to 64 KiB are carved from shared chunks in power-of-two size
+// classes; larger blocks get a chunk each.
+//
+// Freed blocks are reclaimed by epoch: readers pin the current epoch while
+// they use blocks, retired blocks wait in the limbo list of the epoch they
+// were retired in, and the epoch only advances past e once no reader pinned
+// e-1. A block retired in epoch e can therefore be reused once the epoch
+// reaches e+2.
+type arena struct {
+	mu     sync.Mutex
+	chunks atomic.Pointer[[][]byte] // index 0 is unused
+	unused []uint32                 // indices of released chunks
+	free   [maxClass + 1][]handle   // freed blocks per size class
+	next   [maxClass + 1]handle     // next uncarved block per size class, or 0
+	limbo  [3][]handle
+	mapped int
+
+	epoch  atomic.Uint64
+	active [3]atomic.Int64 // readers pinned per epoch, modulo 3
+}
+
+func (a *arena) pin() uint64 {
+	for {
+		e := a.epoch.Load()
+		a.active[e%3].Add(1)
+		if a.epoch.Load() == e {
+			return e
+		}
+		// The epoch advanced before the pin was visible; the advancing
+		// goroutine may not have seen it.
+		a.active[e%3].Add(-1)
+	}
+}
+
+func (a *arena) unpin(e uint64) {
+	a.active[e%3].Add(-1)
+}
+
+// bytes returns the value held in the block at h. The caller must have
+// pinned an epoch since loading h.
+func (a *arena) bytes(h handle) []byte {
+	b := (*a.chunks.Load())[h.chunk()][h.off():]
+	n := prefix + binary.LittleEndian.Uint32(b)
+	return b[prefix:n:n]
+}
+
+// alloc returns a block of at least n bytes and its memory.
+func (a *arena) alloc(n int) (handle, []byte) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	c := class(n)
+	if c == 0 {
+		i := a.newChunk(n)
+		return handle(i) << 32, (*a.chunks.Load())[i][:n]
+	}
+	var h handle
+	if free := a.free[c]; len(free) > 0 {
+		h = free[len(free)-1]
+		a.free[c] = free[:len(free)-1]
+	} else {
+		if a.next[c] == 0 {
+			a.next[c] = handle(a.newChunk(chunkSize)) << 32
+		}
+		h = a.next[c]
+		a.next[c] += 1 << c
+		if a.next[c].off() == chunkSize {
+			a.next[c] = 0
+		}
+	}
+	return h, (*a.chunks.Load())[h.chunk()][h.off():][:n]
+}
+
+// newChunk maps a chunk of n bytes and returns its index. a.mu must be held.
+func (a *arena) newChunk(n int) uint32 {
+	b, err := mapMemory(n)
+	if err != nil {
+		panic("offheap: " + err.Error())
+	}
+	a.mapped += len(b)
+	chunks := a.chunks.Load()
+	if chunks == nil {
+		chunks = &[][]byte{nil}
+	}
+	if k := len(a.unused); k > 0 {
+		// No reader can be using a released chunk, so its slot can be
+		// written in place.
+		i := a.unused[k-1]
+		a.unused = a.unused[:k-1]
+		(*chunks)[i] = b
+		return i
+	}
+	s := append(*chunks, b)
+	a.chunks.Store(&s)
+	return uint32(len(s) - 1)
+}
+
+// retire frees the block at h once no reader can be using it. a.mu must be
+// held.
+func (a *arena) retire(h handle) {
+	e := a.epoch.Load()
+	a.limbo[e%3] = append(a.limbo[e%3], h)
+	// Without concurrent readers, two advances reclaim h immediately.
+	for range 2 {
+		if !a.advance() {
+			break
+		}
+	}
+}
+
+// advance moves to the next epoch if no reader is pinned to the previous
+// one, and releases the blocks retired in it. a.mu must be held.
+func (a *arena) advance() bool {
+	e := a.epoch.Load()
+	prev := (e + 2) % 3
+	if a.active[prev].Load() != 0 {
+		return false
+	}
+	a.epoch.Store(e + 1)
+	// Readers are now pinned to e or e+1, and the blocks retired in e-1
+	// were unreachable before either began.
+	for _, h := range a.limbo[prev] {
+		a.release(h)
+	}
+	clear(a.limbo[prev])
+	a.limbo[prev] = a.limbo[prev][:0]
+	return true
+}
+
+// release frees the block at h for reuse. a.mu must be held.
+func (a *arena) release(h handle) {
+	chunks := *a.chunks.Load()
+	b := chunks[h.chunk()][h.off():]
+	if c := class(prefix + int(binary.LittleEndian.Uint32(b))); c != 0 {
+		a.free[c] = append(a.free[c], h)
+		return
+	}
+	if err := unmapMemory(chunks[h.chunk()]); err != nil {
+		panic("offheap: " + err.Error())
+	}
+	a.mapped -= len(chunks[h.chunk()])
+	chunks[h.chunk()] = nil
+	a.unused = append(a.unused, h.chunk())
+}
+
+// close returns every chunk to the operating system and resets a.
+func (a *arena) close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var err error
+	if chunks := a.chunks.Load(); chunks != nil {
+		for _, b := range *chunks {
+			if b != nil {
+				if e := unmapMemory(b); e != nil && err == nil {
+					err = e
+				}
+			}
+		}
+	}
+	a.chunks.Store(nil)
+	a.unused = nil
+	a.free = [maxClass + 1][]handle{}
+	a.next = [maxClass + 1]handle{}
+	a.limbo = [3][]handle{}
+	a.mapped = 0
+	return err
+}
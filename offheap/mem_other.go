@@ -0,0 +1,13 @@
+//go:build !unix
+
+package offheap
+
+// mapMemory allocates chunks on the Go heap on platforms without anonymous
+// mmap. They contain no pointers, so the garbage collector does not scan them.
+func mapMemory(n int) ([]byte, error) {
+	return make([]byte, n), nil
+}
+
+func unmapMemory([]byte) error {
+	return nil
+}
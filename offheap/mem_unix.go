@@ -0,0 +1,13 @@
+//go:build unix
+
+package offheap
+
+import "syscall"
+
+func mapMemory(n int) ([]byte, error) {
+	return syscall.Mmap(-1, 0, n, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+}
+
+func unmapMemory(b []byte) error {
+	return syscall.Munmap(b)
+}
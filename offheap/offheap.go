@@ -0,0 +1,116 @@
+// Package offheap provides a concurrent map of byte slices whose contents are
+// kept outside the Go heap.
+//
+// A large cache of []byte values costs the garbage collector in proportion to
+// the number of values held, even though their bytes contain no pointers.
+// Map copies each value into manually managed arenas obtained from the
+// operating system, and keeps only a small handle per entry on the heap. On
+// platforms without anonymous mmap, such as js/wasm, the arenas are large Go
+// byte slices instead, which still replaces one heap object per value with
+// one per arena.
+package offheap
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/holdno/syncmapt"
+)
+
+// Map is a concurrent map from keys to byte slices, like a
+// syncmapt.Map[K, []byte] that copies values in and out of off-heap memory.
+//
+// Load and Range do not lock. Stores and deletes serialize briefly on an
+// internal lock while replacing the handle of an entry; the copying of value
+// bytes happens outside it.
+//
+// The zero Map is empty and ready for use. A Map must not be copied after
+// first use. Memory freed by Store and Delete is reused by later stores once
+// no Load or Range that could still be reading it is in progress; Close
+// returns all of it to the operating system.
+type Map[K comparable] struct {
+	m syncmapt.Map[K, handle]
+	a arena
+}
+
+// Load returns a copy of the value stored in the map for key, or nil if no
+// value is present. The ok result indicates whether the value was found.
+func (m *Map[K]) Load(key K) (value []byte, ok bool) {
+	return m.AppendValue(nil, key)
+}
+
+// AppendValue appends the value stored in the map for key to dst and returns
+// the extended slice. It lets callers reuse a buffer across lookups.
+func (m *Map[K]) AppendValue(dst []byte, key K) ([]byte, bool) {
+	e := m.a.pin()
+	defer m.a.unpin(e)
+	h, ok := m.m.Load(key)
+	if !ok {
+		return dst, false
+	}
+	return append(dst, m.a.bytes(h)...), true
+}
+
+// Store copies value into off-heap memory and sets it as the value for key.
+func (m *Map[K]) Store(key K, value []byte) {
+	if uint64(len(value)) > math.MaxUint32-prefix {
+		panic("offheap: value too large")
+	}
+	h, b := m.a.alloc(prefix + len(value))
+	binary.LittleEndian.PutUint32(b, uint32(len(value)))
+	copy(b[prefix:], value)
+
+	m.a.mu.Lock()
+	old, loaded := m.m.Load(key)
+	m.m.Store(key, h)
+	if loaded {
+		m.a.retire(old)
+	}
+	m.a.mu.Unlock()
+}
+
+// Delete deletes the value for key and frees its memory.
+func (m *Map[K]) Delete(key K) {
+	m.a.mu.Lock()
+	if old, loaded := m.m.LoadAndDelete(key); loaded {
+		m.a.retire(old)
+	}
+	m.a.mu.Unlock()
+}
+
+// Range calls f sequentially for each key and value present in the map, with
+// the same consistency as syncmapt.Map.Range. If f returns false, Range stops
+// the iteration.
+//
+// The value passed to f points into off-heap memory and is valid only until
+// f returns; f must not modify it. Memory freed while Range is in progress is
+// not reused until it finishes.
+func (m *Map[K]) Range(f func(key K, value []byte) bool) {
+	e := m.a.pin()
+	defer m.a.unpin(e)
+	m.m.Range(func(k K, h handle) bool {
+		return f(k, m.a.bytes(h))
+	})
+}
+
+// Len returns the number of entries in the map.
+func (m *Map[K]) Len() int {
+	return m.m.Len()
+}
+
+// Mapped returns the number of bytes of off-heap memory held by the map,
+// including memory freed but not yet returned to the operating system.
+func (m *Map[K]) Mapped() int {
+	m.a.mu.Lock()
+	defer m.a.mu.Unlock()
+	return m.a.mapped
+}
+
+// Close deletes every entry and returns the map's off-heap memory to the
+// operating system. It must not be called concurrently with other methods,
+// and slices passed to Range must not be used afterwards. The map is empty
+// and ready for use again once Close returns.
+func (m *Map[K]) Close() error {
+	m.m.Clear()
+	return m.a.close()
+}
@@ -0,0 +1,134 @@
+package offheap_test
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/holdno/syncmapt/offheap"
+)
+
+func TestMap(t *testing.T) {
+	var m offheap.Map[string]
+	defer m.Close()
+	big := bytes.Repeat([]byte("x"), 1<<17)
+	for k, v := range map[string][]byte{"empty": {}, "small": []byte("value"), "big": big} {
+		m.Store(k, v)
+		if got, ok := m.Load(k); !ok || !bytes.Equal(got, v) {
+			t.Errorf("Load(%q) = %d bytes, %v; want %d bytes, true", k, len(got), ok, len(v))
+		}
+	}
+	if m.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", m.Len())
+	}
+
+	buf := []byte("prefix:")
+	m.Store("small", []byte("replaced"))
+	if got, ok := m.AppendValue(buf, "small"); !ok || string(got) != "prefix:replaced" {
+		t.Errorf("AppendValue = %q, %v; want %q, true", got, ok, "prefix:replaced")
+	}
+	m.Delete("big")
+	if _, ok := m.Load("big"); ok {
+		t.Error("Load after Delete reported a value")
+	}
+	n := 0
+	m.Range(func(k string, v []byte) bool {
+		n++
+		return true
+	})
+	if n != 2 {
+		t.Errorf("Range visited %d entries, want 2", n)
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if m.Len() != 0 || m.Mapped() != 0 {
+		t.Errorf("after Close: Len() = %d, Mapped() = %d; want 0, 0", m.Len(), m.Mapped())
+	}
+}
+
+func TestMapReusesMemory(t *testing.T) {
+	var m offheap.Map[int]
+	defer m.Close()
+	v := make([]byte, 1000)
+	for i := 0; i < 1000; i++ {
+		m.Store(i, v)
+	}
+	m.Store(-1, make([]byte, 1<<20))
+	mapped := m.Mapped()
+	for round := 0; round < 10; round++ {
+		for i := 0; i < 1000; i++ {
+			m.Store(i, v)
+		}
+		m.Delete(-1)
+		m.Store(-1, make([]byte, 1<<20))
+	}
+	if got := m.Mapped(); got != mapped {
+		t.Errorf("Mapped() = %d after rewriting every entry, want %d", got, mapped)
+	}
+}
+
+func TestMapConcurrent(t *testing.T) {
+	var m offheap.Map[int]
+	defer m.Close()
+	const keys = 64
+	value := func(k, gen int) []byte {
+		return bytes.Repeat([]byte{byte(k)}, 16+(gen*37)%300)
+	}
+	for k := 0; k < keys; k++ {
+		m.Store(k, value(k, 0))
+	}
+
+	var wg sync.WaitGroup
+	procs := max(runtime.GOMAXPROCS(0), 2)
+	for g := 0; g < procs; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var buf []byte
+			for i := 0; i < 2000; i++ {
+				k := (i*7 + g) % keys
+				switch {
+				case g%2 == 0:
+					m.Store(k, value(k, i))
+				case i%3 == 0:
+					m.Range(func(k int, v []byte) bool {
+						if len(bytes.Trim(v, string(rune(k)))) != 0 {
+							t.Errorf("Range: value for %d holds another key's bytes", k)
+							return false
+						}
+						return true
+					})
+				default:
+					var ok bool
+					buf, ok = m.AppendValue(buf[:0], k)
+					if !ok || len(bytes.Trim(buf, string(rune(k)))) != 0 {
+						t.Errorf("AppendValue(%d) = %v, %v", k, buf, ok)
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkLoad(b *testing.B) {
+	var m offheap.Map[string]
+	defer m.Close()
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = fmt.Sprint("key", i)
+		m.Store(keys[i], make([]byte, 256))
+	}
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		var buf []byte
+		for i := 0; pb.Next(); i++ {
+			buf, _ = m.AppendValue(buf[:0], keys[i%len(keys)])
+		}
+	})
+}
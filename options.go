@@ -0,0 +1,177 @@
+package syncmapt
+
+import "time"
+
+// An Option configures a map created by New or by the constructor of any other
+// map type in this package. The same Options are shared by every map type, so
+// a set of Options can be reused when switching between them; a type ignores
+// Options for features it does not have.
+//
+// Options only exist on maps created by a constructor; the zero Map is always
+// usable and behaves like New called without options.
+type Option[K comparable, V any] func(*config[K, V])
+
+// config collects the settings applied by Options.
+type config[K comparable, V any] struct {
+	// keyTransform, if non-nil, is applied to every key passed to the map
+	// before it is looked up or stored.
+	keyTransform func(K) K
+
+	// capacity is a hint for the number of entries the map will hold.
+	capacity int
+
+	// clock is the time source for time-dependent features.
+	clock Clock
+
+	// ttl is how long entries live after being stored; 0 means forever.
+	ttl time.Duration
+
+	// janitor, if positive, is how often an ExpiringMap reaps expired
+	// entries in the background.
+	janitor time.Duration
+
+	// snapshots enables Map.Snapshot.
+	snapshots bool
+
+	// stats enables the operation counters reported by Map.Stats.
+	stats bool
+
+	// maxEntries, if positive, caps the number of entries of a BoundedMap,
+	// onFull is what it does when a new key is stored while it is full, and
+	// eviction, if not nil, chooses the entries it evicts.
+	maxEntries int
+	onFull     OnFull
+	eviction   EvictionPolicy[K]
+
+	// shards is the number of shards of a ShardedMap; 0 selects the default.
+	shards int
+
+	// codec, if non-nil, compresses the values of a BytesMap of at least
+	// compressAbove bytes.
+	codec         Codec
+	compressAbove int
+
+	// spillLimit, if positive, is the number of bytes of values a BytesMap
+	// keeps in memory before spilling values to a file in spillDir.
+	spillDir   string
+	spillLimit int64
+
+	// keys, if not nil, encrypts the files written by SaveFile and a
+	// WALMap.
+	keys KeyProvider
+
+	// syncLog makes a WALMap sync its log to disk after every write.
+	syncLog bool
+}
+
+// newConfig returns the config resulting from applying opts in order.
+func newConfig[K comparable, V any](opts []Option[K, V]) *config[K, V] {
+	c := &config[K, V]{clock: systemClock{}}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// New returns an empty Map configured by opts.
+//
+// New with no options returns a Map that behaves exactly like the zero Map.
+func New[K comparable, V any](opts ...Option[K, V]) *Map[K, V] {
+	m := new(Map[K, V])
+	m.configure(opts)
+	return m
+}
+
+// configure applies opts to m, which must be a new Map.
+func (m *Map[K, V]) configure(opts []Option[K, V]) {
+	if len(opts) == 0 {
+		return
+	}
+	m.cfg = newConfig(opts)
+	m.ver.on = m.cfg.snapshots
+	if m.cfg.capacity > 0 {
+		// Pre-size the dirty map: the first keys stored in an empty Map all go
+		// there, and it is promoted as a whole to the read map.
+		m.dirty = make(map[K]*entry[V], m.cfg.capacity)
+	}
+}
+
+// NewWithCapacity returns an empty Map sized for about n entries. It is New
+// with WithCapacity(n), for the common case of a map filled with many keys up
+// front.
+func NewWithCapacity[K comparable, V any](n int) *Map[K, V] {
+	return New(WithCapacity[K, V](n))
+}
+
+// WithKeyTransform returns an Option that applies f to every key passed to
+// the map before it is looked up, stored or deleted, so that keys which f maps
+// to the same value refer to the same entry. Typical transforms trim
+// whitespace, fold case or strip a prefix.
+//
+// If several key transforms are given, they are applied in the order in which
+// they appear in the options. Range reports keys in their transformed form.
+// f must be safe for concurrent use and must be deterministic.
+func WithKeyTransform[K comparable, V any](f func(K) K) Option[K, V] {
+	return func(c *config[K, V]) {
+		if prev := c.keyTransform; prev != nil {
+			c.keyTransform = func(k K) K { return f(prev(k)) }
+			return
+		}
+		c.keyTransform = f
+	}
+}
+
+// WithCapacity returns an Option that sizes the map's internal storage for
+// about n entries, avoiding incremental growth when the map is filled up front.
+// It is only a hint: the map grows beyond n as needed. A Map also sizes the
+// storage it rebuilds for new keys, such as after a Clear, for n entries.
+func WithCapacity[K comparable, V any](n int) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.capacity = n
+	}
+}
+
+// A Clock reports the current time.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the Clock backed by time.Now.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// WithClock returns an Option that makes time-dependent features, such as
+// entry expiration, read the time from clock instead of the system clock.
+// It is mainly useful for deterministic tests.
+func WithClock[K comparable, V any](clock Clock) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.clock = clock
+	}
+}
+
+// WithTTL returns an Option that makes entries expire d after they are
+// stored. A non-positive d means entries never expire, which is the default.
+func WithTTL[K comparable, V any](d time.Duration) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.ttl = d
+	}
+}
+
+// WithSnapshots returns an Option that enables Map.Snapshot. Such a map keeps
+// track of the order of its writes relative to its snapshots, which makes
+// every write somewhat slower and the value stored for each key larger, even
+// while no snapshot exists.
+func WithSnapshots[K comparable, V any]() Option[K, V] {
+	return func(c *config[K, V]) {
+		c.snapshots = true
+	}
+}
+
+// key returns the form of k under which it is stored in m.
+func (m *Map[K, V]) key(k K) K {
+	if m.cfg == nil || m.cfg.keyTransform == nil {
+		return k
+	}
+	return m.cfg.keyTransform(k)
+}
@@ -0,0 +1,211 @@
+package syncmapt
+
+import (
+	"container/list"
+	"iter"
+	"sync"
+)
+
+// An OrderedMap is a concurrent map that remembers the order in which its
+// keys were inserted: Range and the iterators visit entries from the oldest
+// key to the newest. Storing a new value for a key that is present keeps its
+// place in the order; deleting a key and storing it again moves it to the
+// end.
+//
+// Lookups take a read lock and updates a write lock, both held for a constant
+// time. Range copies the entries under the read lock before calling f, so
+// unlike Map.Range it reports a consistent state of the map. Of the Options,
+// WithKeyTransform canonicalizes keys and WithCapacity sizes the map.
+//
+// The zero OrderedMap is empty and ready for use. An OrderedMap must not be
+// copied after first use.
+type OrderedMap[K comparable, V any] struct {
+	mu     sync.RWMutex
+	cfg    *config[K, V]
+	m      map[K]*list.Element // of *Pair[K, V]
+	order  list.List
+	closed bool // set by Close
+}
+
+var _ Interface[string, any] = (*OrderedMap[string, any])(nil)
+
+// NewOrderedMap returns an empty OrderedMap configured by opts.
+func NewOrderedMap[K comparable, V any](opts ...Option[K, V]) *OrderedMap[K, V] {
+	m := &OrderedMap[K, V]{cfg: newConfig(opts)}
+	m.initLocked()
+	return m
+}
+
+// initLocked allocates the map of a zero OrderedMap. m.cfg is only set by
+// NewOrderedMap, as key reads it without the lock.
+func (m *OrderedMap[K, V]) initLocked() {
+	if m.m != nil {
+		return
+	}
+	capacity := 0
+	if m.cfg != nil {
+		capacity = m.cfg.capacity
+	}
+	m.m = make(map[K]*list.Element, capacity)
+}
+
+func (m *OrderedMap[K, V]) key(k K) K {
+	if m.cfg == nil || m.cfg.keyTransform == nil {
+		return k
+	}
+	return m.cfg.keyTransform(k)
+}
+
+// Load returns the value stored in the map for a key, or the zero value if no
+// value is present. The ok result indicates whether value was found in the
+// map.
+func (m *OrderedMap[K, V]) Load(key K) (value V, ok bool) {
+	key = m.key(key)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if e, ok := m.m[key]; ok {
+		return e.Value.(*Pair[K, V]).Value, true
+	}
+	return value, false
+}
+
+// Store sets the value for a key. A new key goes to the end of the order.
+func (m *OrderedMap[K, V]) Store(key K, value V) {
+	m.Swap(key, value)
+}
+
+// Swap swaps the value for a key and returns the previous value if any.
+// The loaded result reports whether the key was present.
+func (m *OrderedMap[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	key = m.key(key)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.initLocked()
+	if m.closed {
+		return previous, false
+	}
+	if e, ok := m.m[key]; ok {
+		p := e.Value.(*Pair[K, V])
+		previous, p.Value = p.Value, value
+		return previous, true
+	}
+	m.m[key] = m.order.PushBack(&Pair[K, V]{key, value})
+	return previous, false
+}
+
+// LoadOrStore returns the existing value for the key if present. Otherwise,
+// it stores and returns the given value. The loaded result is true if the
+// value was loaded, false if stored.
+func (m *OrderedMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	key = m.key(key)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.initLocked()
+	if e, ok := m.m[key]; ok {
+		return e.Value.(*Pair[K, V]).Value, true
+	}
+	if m.closed {
+		return actual, false
+	}
+	m.m[key] = m.order.PushBack(&Pair[K, V]{key, value})
+	return value, false
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if
+// any. The loaded result reports whether the key was present.
+func (m *OrderedMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	key = m.key(key)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.m[key]
+	if !ok || m.closed {
+		return value, false
+	}
+	delete(m.m, key)
+	return m.order.Remove(e).(*Pair[K, V]).Value, true
+}
+
+// Delete deletes the value for a key.
+func (m *OrderedMap[K, V]) Delete(key K) {
+	m.LoadAndDelete(key)
+}
+
+// Range calls f sequentially for each key and value present in the map, in
+// insertion order. If f returns false, range stops the iteration.
+//
+// Range copies the entries under the map's read lock and calls f with no lock
+// held, so f may call any method of m.
+func (m *OrderedMap[K, V]) Range(f func(key K, value V) bool) {
+	for _, p := range m.Pairs() {
+		if !f(p.Key, p.Value) {
+			return
+		}
+	}
+}
+
+// Pairs returns the entries of the map in insertion order.
+func (m *OrderedMap[K, V]) Pairs() []Pair[K, V] {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	pairs := make([]Pair[K, V], 0, len(m.m))
+	for e := m.order.Front(); e != nil; e = e.Next() {
+		pairs = append(pairs, *e.Value.(*Pair[K, V]))
+	}
+	return pairs
+}
+
+// All returns an iterator over the key-value pairs in the map, in insertion
+// order, with the semantics of Range.
+func (m *OrderedMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.Range(yield)
+	}
+}
+
+// Keys returns an iterator over the keys in the map, in insertion order.
+func (m *OrderedMap[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		m.Range(func(k K, _ V) bool {
+			return yield(k)
+		})
+	}
+}
+
+// Values returns an iterator over the values in the map, in the insertion
+// order of their keys.
+func (m *OrderedMap[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		m.Range(func(_ K, v V) bool {
+			return yield(v)
+		})
+	}
+}
+
+// Len returns the number of entries in the map.
+func (m *OrderedMap[K, V]) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.m)
+}
+
+// Clear deletes all the entries, resulting in an empty OrderedMap.
+func (m *OrderedMap[K, V]) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return
+	}
+	clear(m.m)
+	m.order.Init()
+}
+
+// Close closes the map: afterwards its contents can still be read, but the
+// methods that would modify it do nothing. Swap and LoadAndDelete report that
+// nothing was swapped or deleted, and LoadOrStore returns the current value
+// for the key as Load does. Close is idempotent and always returns nil.
+func (m *OrderedMap[K, V]) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	return nil
+}
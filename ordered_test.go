@@ -0,0 +1,92 @@
+package syncmapt_test
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+	"github.com/holdno/syncmapt/syncmapttest"
+)
+
+func TestOrderedMap(t *testing.T) {
+	var m syncmapt.OrderedMap[string, int]
+	for i, k := range []string{"c", "a", "d", "b"} {
+		m.Store(k, i)
+	}
+	m.Store("a", 10) // keeps its place
+	m.Delete("d")
+	m.Store("d", 11) // moves to the end
+	if _, loaded := m.LoadOrStore("e", 12); loaded {
+		t.Fatal("LoadOrStore(e) loaded a value that was never stored")
+	}
+	if v, loaded := m.Swap("c", 13); !loaded || v != 0 {
+		t.Fatalf("Swap(c) = %v, %v; want 0, true", v, loaded)
+	}
+
+	want := []syncmapt.Pair[string, int]{{"c", 13}, {"a", 10}, {"b", 3}, {"d", 11}, {"e", 12}}
+	if got := m.Pairs(); !slices.Equal(got, want) {
+		t.Fatalf("Pairs() = %v, want %v", got, want)
+	}
+	var keys []string
+	m.Range(func(k string, _ int) bool {
+		keys = append(keys, k)
+		return len(keys) < 3
+	})
+	if !slices.Equal(keys, []string{"c", "a", "b"}) {
+		t.Fatalf("Range stopped after %v, want [c a b]", keys)
+	}
+	if got := slices.Collect(m.Keys()); !slices.Equal(got, []string{"c", "a", "b", "d", "e"}) {
+		t.Fatalf("Keys() = %v", got)
+	}
+	if got := slices.Collect(m.Values()); !slices.Equal(got, []int{13, 10, 3, 11, 12}) {
+		t.Fatalf("Values() = %v", got)
+	}
+
+	m.Clear()
+	m.Store("z", 1)
+	if m.Len() != 1 || len(m.Pairs()) != 1 {
+		t.Fatalf("after Clear and a Store, Len() = %d, Pairs() = %v", m.Len(), m.Pairs())
+	}
+}
+
+func TestOrderedMapConformance(t *testing.T) {
+	syncmapttest.RunConformance(t, func() syncmapt.Interface[string, string] {
+		return new(syncmapt.OrderedMap[string, string])
+	})
+}
+
+func TestOrderedMapKeyTransform(t *testing.T) {
+	m := syncmapt.NewOrderedMap(syncmapt.WithKeyTransform[string, int](strings.ToLower))
+	m.Store("Content-Type", 1)
+	m.Store("ACCEPT", 2)
+	m.Store("content-type", 3)
+	want := []syncmapt.Pair[string, int]{{"content-type", 3}, {"accept", 2}}
+	if got := m.Pairs(); !slices.Equal(got, want) {
+		t.Fatalf("Pairs() = %v, want %v", got, want)
+	}
+}
+
+func TestOrderedMapConcurrent(t *testing.T) {
+	m := syncmapt.NewOrderedMap[string, int]()
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				// Every goroutine stores the same keys in the same order.
+				m.LoadOrStore(strconv.Itoa(i), i)
+				m.Range(func(string, int) bool { return true })
+			}
+		}()
+	}
+	wg.Wait()
+	for i, p := range m.Pairs() {
+		if p.Key != strconv.Itoa(i) {
+			t.Fatalf("entry %d is %v, want key %d", i, p, i)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package syncmapt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// A saved Map is the binary encoding of the map, as produced by
+// MarshalBinary, framed to detect truncation and corruption:
+//
+//	"SYNCMAPT" | len(encoding) | encoding | CRC-32C
+//
+// The length is a uvarint and the CRC-32C, of everything before it, is four
+// bytes in big-endian byte order. The encoding starts with the number of
+// entries, checked against the entries that follow on load.
+const saveMagic = "SYNCMAPT"
+
+// ErrCorrupt is returned when saved map data is truncated or fails its
+// checksum.
+var ErrCorrupt = errors.New("syncmapt: saved map is corrupt")
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// SaveTo writes the contents of the map to w, copied as by ToMap, in a format
+// that LoadFrom reads back. To encrypt the data, pass a writer returned by
+// NewEncryptingWriter, or use SaveFile on a map created WithEncryption.
+func (m *Map[K, V]) SaveTo(w io.Writer) error {
+	b, err := m.appendSaved(nil)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// appendSaved appends the saved form of m to b.
+func (m *Map[K, V]) appendSaved(b []byte) ([]byte, error) {
+	enc, err := m.AppendBinary(nil)
+	if err != nil {
+		return nil, err
+	}
+	start := len(b)
+	b = append(b, saveMagic...)
+	b = binary.AppendUvarint(b, uint64(len(enc)))
+	b = append(b, enc...)
+	return binary.BigEndian.AppendUint32(b, crc32.Checksum(b[start:], castagnoli)), nil
+}
+
+// LoadFrom reads data written by SaveTo from r and replaces the contents of
+// the map with it, as by ReplaceAll. If the data is truncated or corrupt,
+// LoadFrom returns an error wrapping ErrCorrupt and leaves the map unchanged.
+func (m *Map[K, V]) LoadFrom(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	entries, rest, err := decodeSaved[K, V](data)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 0 {
+		return ErrCorrupt
+	}
+	contents := make(map[K]V, len(entries))
+	for _, e := range entries {
+		contents[e.Key] = e.Value
+	}
+	m.ReplaceAll(contents)
+	return nil
+}
+
+// decodeSaved returns the entries of the saved map at the start of data, and
+// the data that follows it.
+func decodeSaved[K comparable, V any](data []byte) (entries []Pair[K, V], rest []byte, err error) {
+	if len(data) < len(saveMagic) || string(data[:len(saveMagic)]) != saveMagic {
+		return nil, nil, ErrCorrupt
+	}
+	n, k := binary.Uvarint(data[len(saveMagic):])
+	if k <= 0 || n > uint64(len(data)) {
+		return nil, nil, ErrCorrupt
+	}
+	end := len(saveMagic) + k + int(n)
+	if end+4 > len(data) {
+		return nil, nil, ErrCorrupt
+	}
+	if crc32.Checksum(data[:end], castagnoli) != binary.BigEndian.Uint32(data[end:]) {
+		return nil, nil, ErrCorrupt
+	}
+	if entries, err = decodeBinary[K, V](data[len(saveMagic)+k : end]); err != nil {
+		return nil, nil, errors.Join(ErrCorrupt, err)
+	}
+	return entries, data[end+4:], nil
+}
+
+// WithEncryption returns an Option that makes SaveFile encrypt the file it
+// writes with a key from keys, as by NewEncryptingWriter, and LoadFile
+// decrypt it. A WALMap also encrypts its log with it.
+func WithEncryption[K comparable, V any](keys KeyProvider) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.keys = keys
+	}
+}
+
+// encryptionKeys returns the KeyProvider set by WithEncryption, or nil.
+func (m *Map[K, V]) encryptionKeys() KeyProvider {
+	if m.cfg == nil {
+		return nil
+	}
+	return m.cfg.keys
+}
+
+// encrypt returns data encrypted with a key from keys.
+func encrypt(keys KeyProvider, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := NewEncryptingWriter(&buf, keys)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SaveFile saves the contents of the map, as by SaveTo, to the file at path,
+// encrypted if the map was created WithEncryption. It writes a temporary file
+// in the same directory and renames it to path once it is synced to disk, so
+// path holds either the previous contents or the new ones, even if the
+// process crashes.
+func (m *Map[K, V]) SaveFile(path string) error {
+	b, err := m.appendSaved(nil)
+	if err != nil {
+		return err
+	}
+	if keys := m.encryptionKeys(); keys != nil {
+		if b, err = encrypt(keys, b); err != nil {
+			return err
+		}
+	}
+	return writeFileAtomic(path, b)
+}
+
+// writeFileAtomic replaces the file at path with data, so that it holds either
+// its previous contents or data, even if the process crashes. Once it returns,
+// the new contents are on disk.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // fails harmlessly once renamed
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+// syncDir syncs the directory at path to disk, making the renames and
+// removals of its files durable.
+func syncDir(path string) error {
+	d, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if cerr := d.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// LoadFile replaces the contents of the map with those saved to the file at
+// path by SaveFile, as by LoadFrom. If the map was created WithEncryption, the
+// file is decrypted, and LoadFile returns an error wrapping ErrDecrypt if it
+// fails authentication.
+func (m *Map[K, V]) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if keys := m.encryptionKeys(); keys != nil {
+		if r, err = NewDecryptingReader(f, keys); err != nil {
+			return err
+		}
+	}
+	return m.LoadFrom(r)
+}
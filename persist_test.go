@@ -0,0 +1,94 @@
+package syncmapt_test
+
+import (
+	"bytes"
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+)
+
+func TestSaveLoad(t *testing.T) {
+	want := map[string]int{"a": 1, "b": 2, "c": 3}
+	m := syncmapt.NewFromMap(want)
+	var buf bytes.Buffer
+	if err := m.SaveTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	restored := syncmapt.NewFromMap(map[string]int{"stale": 0})
+	if err := restored.LoadFrom(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	if got := restored.ToMap(); !maps.Equal(got, want) {
+		t.Fatalf("LoadFrom restored %v, want %v", got, want)
+	}
+
+	for name, bad := range map[string][]byte{
+		"truncated": data[:len(data)-1],
+		"corrupt":   append(bytes.Clone(data[:12]), append([]byte{data[12] ^ 1}, data[13:]...)...),
+		"trailing":  append(bytes.Clone(data), 0),
+		"empty":     nil,
+	} {
+		if err := restored.LoadFrom(bytes.NewReader(bad)); !errors.Is(err, syncmapt.ErrCorrupt) {
+			t.Errorf("LoadFrom of %s data = %v, want ErrCorrupt", name, err)
+		}
+	}
+	if got := restored.ToMap(); !maps.Equal(got, want) {
+		t.Fatalf("failed loads changed the map to %v", got)
+	}
+}
+
+func TestSaveLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state")
+	m := syncmapt.NewFromMap(map[int]string{1: "one", 2: "two"})
+	if err := m.SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+	m.Store(3, "three")
+	if err := m.SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+	matches, _ := filepath.Glob(path + "*")
+	if len(matches) != 1 {
+		t.Fatalf("SaveFile left %v behind", matches)
+	}
+
+	restored := new(syncmapt.Map[int, string])
+	if err := restored.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if !maps.Equal(restored.ToMap(), m.ToMap()) {
+		t.Fatalf("LoadFile restored %v, want %v", restored.ToMap(), m.ToMap())
+	}
+}
+
+func TestSaveLoadFileEncrypted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state")
+	key := bytes.Repeat([]byte{1}, 32)
+	m := syncmapt.New(syncmapt.WithEncryption[string, string](syncmapt.StaticKey(key)))
+	m.Store("token", "s3cr3t")
+	if err := m.SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); bytes.Contains(data, []byte("s3cr3t")) {
+		t.Fatal("SaveFile wrote the value in the clear")
+	}
+
+	restored := syncmapt.New(syncmapt.WithEncryption[string, string](syncmapt.StaticKey(key)))
+	if err := restored.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := restored.Load("token"); v != "s3cr3t" {
+		t.Fatalf("LoadFile restored token = %q", v)
+	}
+
+	wrong := syncmapt.New(syncmapt.WithEncryption[string, string](syncmapt.StaticKey(bytes.Repeat([]byte{2}, 32))))
+	if err := wrong.LoadFile(path); !errors.Is(err, syncmapt.ErrDecrypt) {
+		t.Fatalf("LoadFile with the wrong key = %v, want ErrDecrypt", err)
+	}
+}
@@ -0,0 +1,105 @@
+// Package promexp exports the statistics of syncmapt maps as Prometheus
+// metrics:
+//
+//	m := syncmapt.NewBoundedMap(syncmapt.WithMaxEntries[string, *User](10000))
+//	prometheus.MustRegister(promexp.NewCollector(m, promexp.Opts{
+//		ConstLabels: prometheus.Labels{"cache": "users"},
+//	}))
+//
+// A collector reports, under the namespace and subsystem of its Opts:
+//
+//	entries              gauge    number of entries
+//	loads_total          counter  calls to Load
+//	hits_total           counter  calls to Load that found a value
+//	stores_total         counter  values stored
+//	deletes_total        counter  values deleted
+//	expirations_total    counter  values deleted because they expired
+//	evictions_total      counter  values evicted to make room
+//
+// The counters are only maintained by maps that count their operations: a
+// Map or ExpiringMap created WithStats, or any BoundedMap.
+package promexp
+
+import (
+	"github.com/holdno/syncmapt"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// A Source is a map whose statistics can be collected, such as a
+// *syncmapt.Map, *syncmapt.BoundedMap or *syncmapt.ExpiringMap.
+type Source interface {
+	Len() int
+	Stats() syncmapt.Stats
+}
+
+// Opts configures the metrics of a Collector. Several maps can be exported to
+// the same registry under the same names by giving their collectors
+// different ConstLabels.
+type Opts struct {
+	// Namespace and Subsystem prefix the metric names. An empty Namespace
+	// selects "syncmapt".
+	Namespace, Subsystem string
+	// ConstLabels are added to every metric.
+	ConstLabels prometheus.Labels
+}
+
+// A Collector is a prometheus.Collector of the statistics of a map.
+type Collector struct {
+	m       Source
+	entries *prometheus.Desc
+	loads   *prometheus.Desc
+	hits    *prometheus.Desc
+	stores  *prometheus.Desc
+	deletes *prometheus.Desc
+	expired *prometheus.Desc
+	evicted *prometheus.Desc
+}
+
+var _ prometheus.Collector = (*Collector)(nil)
+
+// NewCollector returns a Collector of the statistics of m, configured by
+// opts. It reads the statistics each time it is collected.
+func NewCollector(m Source, opts Opts) *Collector {
+	if opts.Namespace == "" {
+		opts.Namespace = "syncmapt"
+	}
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(opts.Namespace, opts.Subsystem, name), help, nil, opts.ConstLabels)
+	}
+	return &Collector{
+		m:       m,
+		entries: desc("entries", "Number of entries in the map."),
+		loads:   desc("loads_total", "Number of calls to Load."),
+		hits:    desc("hits_total", "Number of calls to Load that found a value."),
+		stores:  desc("stores_total", "Number of values stored."),
+		deletes: desc("deletes_total", "Number of values deleted."),
+		expired: desc("expirations_total", "Number of values deleted because they expired."),
+		evicted: desc("evictions_total", "Number of values evicted to make room for new ones."),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.entries
+	ch <- c.loads
+	ch <- c.hits
+	ch <- c.stores
+	ch <- c.deletes
+	ch <- c.expired
+	ch <- c.evicted
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	s := c.m.Stats()
+	ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(c.m.Len()))
+	counter := func(d *prometheus.Desc, n int64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.CounterValue, float64(n))
+	}
+	counter(c.loads, s.Loads)
+	counter(c.hits, s.Hits)
+	counter(c.stores, s.Stores)
+	counter(c.deletes, s.Deletes)
+	counter(c.expired, s.Expirations)
+	counter(c.evicted, s.Evictions)
+}
@@ -0,0 +1,61 @@
+package promexp_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+	"github.com/holdno/syncmapt/promexp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	users := syncmapt.NewBoundedMap(syncmapt.WithMaxEntries[string, int](2))
+	users.Store("a", 1)
+	users.Store("b", 2)
+	users.Store("c", 3)
+	users.Load("c")
+	users.Load("a")
+
+	sessions := syncmapt.New(syncmapt.WithStats[string, int]())
+	sessions.Store("s", 1)
+	sessions.Delete("s")
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(
+		promexp.NewCollector(users, promexp.Opts{ConstLabels: prometheus.Labels{"cache": "users"}}),
+		promexp.NewCollector(sessions, promexp.Opts{ConstLabels: prometheus.Labels{"cache": "sessions"}}),
+	)
+
+	want := `
+# HELP syncmapt_entries Number of entries in the map.
+# TYPE syncmapt_entries gauge
+syncmapt_entries{cache="sessions"} 0
+syncmapt_entries{cache="users"} 2
+# HELP syncmapt_evictions_total Number of values evicted to make room for new ones.
+# TYPE syncmapt_evictions_total counter
+syncmapt_evictions_total{cache="sessions"} 0
+syncmapt_evictions_total{cache="users"} 1
+# HELP syncmapt_hits_total Number of calls to Load that found a value.
+# TYPE syncmapt_hits_total counter
+syncmapt_hits_total{cache="sessions"} 0
+syncmapt_hits_total{cache="users"} 1
+# HELP syncmapt_stores_total Number of values stored.
+# TYPE syncmapt_stores_total counter
+syncmapt_stores_total{cache="sessions"} 1
+syncmapt_stores_total{cache="users"} 3
+# HELP syncmapt_deletes_total Number of values deleted.
+# TYPE syncmapt_deletes_total counter
+syncmapt_deletes_total{cache="sessions"} 1
+syncmapt_deletes_total{cache="users"} 0
+`
+	err := testutil.GatherAndCompare(reg, strings.NewReader(want),
+		"syncmapt_entries", "syncmapt_evictions_total", "syncmapt_hits_total", "syncmapt_stores_total", "syncmapt_deletes_total")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := testutil.CollectAndCount(reg); n != 14 {
+		t.Fatalf("collected %d metrics, want 14", n)
+	}
+}
@@ -0,0 +1,126 @@
+package syncmapt
+
+import (
+	"container/heap"
+	"slices"
+	"sort"
+)
+
+// A Query selects, orders and pages the entries of a Map. It is built by
+// chaining calls starting from Map.Query and run by Collect:
+//
+//	page := m.Query().Where(active).OrderBy(byName).Offset(40).Limit(20).Collect()
+//
+// Each method returns a modified copy, so a partially built Query may be
+// reused as the base of several others. A Query runs in a single Range pass
+// and, like Range, does not necessarily observe a consistent snapshot of a
+// map that is modified concurrently.
+type Query[K comparable, V any] struct {
+	m      *Map[K, V]
+	where  []func(key K, value V) bool
+	less   func(a, b Pair[K, V]) bool
+	offset int
+	limit  int // < 0 means no limit
+}
+
+// Query returns a Query selecting every entry of the map.
+func (m *Map[K, V]) Query() Query[K, V] {
+	return Query[K, V]{m: m, limit: -1}
+}
+
+// Where restricts q to the entries for which pred returns true, in addition
+// to any earlier restriction.
+func (q Query[K, V]) Where(pred func(key K, value V) bool) Query[K, V] {
+	q.where = append(slices.Clip(q.where), pred)
+	return q
+}
+
+// OrderBy orders the results of q by less, which must define a strict weak
+// ordering. Without OrderBy, results are in an indeterminate order.
+func (q Query[K, V]) OrderBy(less func(a, b Pair[K, V]) bool) Query[K, V] {
+	q.less = less
+	return q
+}
+
+// Offset skips the first n results of q.
+func (q Query[K, V]) Offset(n int) Query[K, V] {
+	q.offset = max(n, 0)
+	return q
+}
+
+// Limit restricts q to at most n results. When q is ordered, only the
+// Offset+Limit smallest entries are retained during the pass, so paging
+// through a large map does not sort all of it.
+func (q Query[K, V]) Limit(n int) Query[K, V] {
+	q.limit = max(n, 0)
+	return q
+}
+
+// Collect runs q and returns its results.
+func (q Query[K, V]) Collect() []Pair[K, V] {
+	if q.limit == 0 {
+		return nil
+	}
+	keep := -1 // number of entries to retain, or -1 for all
+	if q.limit > 0 {
+		keep = q.offset + q.limit
+	}
+
+	var h pairHeap[K, V]
+	h.less = q.less
+	q.m.Range(func(key K, value V) bool {
+		if !q.match(key, value) {
+			return true
+		}
+		p := Pair[K, V]{key, value}
+		switch {
+		case keep < 0 || len(h.pairs) < keep:
+			if q.less != nil && keep >= 0 {
+				heap.Push(&h, p)
+			} else {
+				h.pairs = append(h.pairs, p)
+			}
+		case q.less == nil:
+			return false // unordered and already have enough
+		case q.less(p, h.pairs[0]):
+			h.pairs[0] = p
+			heap.Fix(&h, 0)
+		}
+		return true
+	})
+
+	results := h.pairs
+	if q.less != nil {
+		sort.Slice(results, func(i, j int) bool { return q.less(results[i], results[j]) })
+	}
+	if q.offset >= len(results) {
+		return nil
+	}
+	return results[q.offset:]
+}
+
+func (q Query[K, V]) match(key K, value V) bool {
+	for _, pred := range q.where {
+		if !pred(key, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// A pairHeap is a max-heap of pairs by less, holding the smallest entries
+// seen so far with the largest of them at the root.
+type pairHeap[K comparable, V any] struct {
+	pairs []Pair[K, V]
+	less  func(a, b Pair[K, V]) bool
+}
+
+func (h *pairHeap[K, V]) Len() int           { return len(h.pairs) }
+func (h *pairHeap[K, V]) Less(i, j int) bool { return h.less(h.pairs[j], h.pairs[i]) }
+func (h *pairHeap[K, V]) Swap(i, j int)      { h.pairs[i], h.pairs[j] = h.pairs[j], h.pairs[i] }
+func (h *pairHeap[K, V]) Push(x any)         { h.pairs = append(h.pairs, x.(Pair[K, V])) }
+func (h *pairHeap[K, V]) Pop() any {
+	p := h.pairs[len(h.pairs)-1]
+	h.pairs = h.pairs[:len(h.pairs)-1]
+	return p
+}
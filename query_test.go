@@ -0,0 +1,70 @@
+package syncmapt_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+)
+
+func TestQuery(t *testing.T) {
+	m := new(syncmapt.Map[int, int])
+	for i := 0; i < 1000; i++ {
+		m.Store(i, i%7)
+	}
+	byValueThenKey := func(a, b syncmapt.Pair[int, int]) bool {
+		if a.Value != b.Value {
+			return a.Value > b.Value
+		}
+		return a.Key < b.Key
+	}
+	keys := func(ps []syncmapt.Pair[int, int]) []int {
+		var ks []int
+		for _, p := range ps {
+			ks = append(ks, p.Key)
+		}
+		return ks
+	}
+
+	even := m.Query().Where(func(k, _ int) bool { return k%2 == 0 })
+	ordered := even.Where(func(k, _ int) bool { return k < 100 }).OrderBy(byValueThenKey)
+
+	if got, want := keys(ordered.Limit(4).Collect()), []int{6, 20, 34, 48}; !slices.Equal(got, want) {
+		t.Fatalf("page 1 = %v, want %v", got, want)
+	}
+	if got, want := keys(ordered.Offset(4).Limit(4).Collect()), []int{62, 76, 90, 12}; !slices.Equal(got, want) {
+		t.Fatalf("page 2 = %v, want %v", got, want)
+	}
+	if got := ordered.Offset(50).Limit(4).Collect(); len(got) != 0 {
+		t.Fatal("want empty page past the end, got", got)
+	}
+	if got := len(ordered.Collect()); got != 50 {
+		t.Fatalf("unlimited ordered query returned %d entries, want 50", got)
+	}
+
+	// Derived queries must not add restrictions to their base.
+	if got := len(even.Collect()); got != 500 {
+		t.Fatalf("even query returned %d entries, want 500", got)
+	}
+	if got := even.Limit(10).Collect(); len(got) != 10 {
+		t.Fatalf("unordered limited query returned %d entries, want 10", len(got))
+	}
+	if got := even.Limit(0).Collect(); len(got) != 0 {
+		t.Fatal("unexpected", got)
+	}
+}
+
+func BenchmarkQueryPage(b *testing.B) {
+	m := new(syncmapt.Map[int, int])
+	for i := 0; i < 100000; i++ {
+		m.Store(i, i*7919%100003)
+	}
+	q := m.Query().
+		Where(func(_, v int) bool { return v%3 == 0 }).
+		OrderBy(func(a, b syncmapt.Pair[int, int]) bool { return a.Value < b.Value }).
+		Limit(20)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		q.Collect()
+	}
+}
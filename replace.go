@@ -0,0 +1,69 @@
+package syncmapt
+
+// ReplaceAll atomically replaces the entire contents of the map with
+// contents, which is copied. Every operation observes either the old contents
+// or the new ones, never a mix of the two and never an empty map in between.
+//
+// ReplaceAll builds the new contents before taking the map's lock, and
+// reports the deleted and stored values to the OnDelete and OnStore hooks
+// after releasing it, so concurrent readers are never blocked by it and
+// writers only for the time it takes to swap the contents.
+func (m *Map[K, V]) ReplaceAll(contents map[K]V) {
+	if m.Closed() {
+		return
+	}
+	fresh, n := m.storage(contents)
+
+	m.mu.Lock()
+	detached := m.detachedLocked()
+	m.count.Store(n)
+	m.read.Store(readOnly[K, V]{m: fresh})
+	m.dirty = nil
+	m.misses = 0
+	m.mu.Unlock()
+
+	m.resetAll()
+	for k := range fresh {
+		m.wake(k)
+	}
+	m.reportCleared(detached)
+	if h := m.hooks.Load(); h != nil {
+		if len(h.store) > 0 {
+			for k, e := range fresh {
+				if v, ok := e.load(&m.ver); ok {
+					h.run(h.store, k, v)
+				}
+			}
+		}
+	}
+}
+
+// Clear deletes all the entries, resulting in an empty Map.
+//
+// Clear takes constant time regardless of the size of the map: it detaches
+// the internal storage instead of deleting entries one by one, and the memory
+// is reclaimed by the garbage collector once concurrent operations that
+// started before Clear no longer reference it. If OnDelete hooks are
+// registered, Clear then reports every deleted entry to them, which takes
+// time proportional to the size of the map but is done after releasing the
+// map's lock, so other writers are not blocked.
+func (m *Map[K, V]) Clear() {
+	if m.Closed() {
+		return
+	}
+	read, _ := m.read.Load().(readOnly[K, V])
+	if len(read.m) == 0 && !read.amended {
+		// Avoid allocating a new readOnly when the map is already clear.
+		return
+	}
+
+	m.mu.Lock()
+	detached := m.detachedLocked()
+	m.count.Store(nil)
+	m.read.Store(readOnly[K, V]{})
+	m.dirty = nil
+	m.misses = 0
+	m.mu.Unlock()
+	m.resetAll()
+	m.reportCleared(detached)
+}
@@ -0,0 +1,99 @@
+package syncmapt_test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+	"github.com/holdno/syncmapt/syncmapttest"
+)
+
+func TestReplaceAll(t *testing.T) {
+	m := syncmapttest.New(map[string]int{"a": 1, "b": 2})
+
+	m.Store("c", 3) // pending in the dirty map
+	m.ReplaceAll(map[string]int{"b": 20, "d": 40})
+	syncmapttest.RequireEqual(t, m, map[string]int{"b": 20, "d": 40})
+
+	m.Store("e", 50)
+	m.Delete("b")
+	syncmapttest.RequireEqual(t, m, map[string]int{"d": 40, "e": 50})
+
+	m.ReplaceAll(nil)
+	syncmapttest.RequireEqual(t, m, map[string]int{})
+}
+
+func TestReplaceAllNoEmptyWindow(t *testing.T) {
+	const n = 64
+	config := func(gen int) map[string]int {
+		c := make(map[string]int, n)
+		for i := 0; i < n; i++ {
+			c[strconv.Itoa(i)] = gen
+		}
+		return c
+	}
+
+	m := new(syncmapt.Map[string, int])
+	m.ReplaceAll(config(0))
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				for i := 0; i < n; i++ {
+					if _, ok := m.Load(strconv.Itoa(i)); !ok {
+						t.Errorf("key %d missing during ReplaceAll", i)
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	for gen := 1; gen < 200; gen++ {
+		m.ReplaceAll(config(gen))
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func TestClear(t *testing.T) {
+	m := new(syncmapt.Map[int, int])
+	m.Clear() // zero Map
+
+	for i := 0; i < 100; i++ {
+		m.Store(i, i)
+	}
+	m.Range(func(int, int) bool { return true })
+	m.Store(100, 100) // pending in the dirty map
+
+	m.Clear()
+	syncmapttest.RequireEqual(t, m, map[int]int{})
+	if o := m.Occupancy(); o.ReadSlots != 0 || o.DirtySlots != 0 {
+		t.Fatalf("want storage released by Clear, got %+v", o)
+	}
+
+	m.Store(1, 1)
+	syncmapttest.RequireEqual(t, m, map[int]int{1: 1})
+}
+
+func BenchmarkClear(b *testing.B) {
+	m := new(syncmapt.Map[int, int])
+	for i := 0; i < 1<<20; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Store(i, i)
+		m.Clear()
+	}
+}
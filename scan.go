@@ -0,0 +1,134 @@
+package syncmapt
+
+import (
+	"cmp"
+	"slices"
+	"sort"
+)
+
+// This file contains helpers that answer questions about the whole map with a
+// single Range pass. Like Range, they do not necessarily observe a consistent
+// snapshot of a map that is modified concurrently.
+
+// A Pair is a key and the value stored for it.
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// ContainsValue reports whether the map holds a value equal to v according to
+// eq. It stops at the first match.
+func (m *Map[K, V]) ContainsValue(v V, eq func(a, b V) bool) bool {
+	found := false
+	m.Range(func(_ K, value V) bool {
+		found = eq(value, v)
+		return !found
+	})
+	return found
+}
+
+// CountFunc returns the number of entries for which pred returns true,
+// counted in a single pass without collecting the entries.
+func (m *Map[K, V]) CountFunc(pred func(key K, value V) bool) int {
+	n := 0
+	m.Range(func(key K, value V) bool {
+		if pred(key, value) {
+			n++
+		}
+		return true
+	})
+	return n
+}
+
+// KeysOf returns the keys whose values are equal to v according to eq, in
+// an indeterminate order.
+func (m *Map[K, V]) KeysOf(v V, eq func(a, b V) bool) []K {
+	var keys []K
+	m.Range(func(key K, value V) bool {
+		if eq(value, v) {
+			keys = append(keys, key)
+		}
+		return true
+	})
+	return keys
+}
+
+// KeysSorted returns the keys of m in ascending order. The result is
+// allocated once and sorted in place.
+func KeysSorted[K cmp.Ordered, V any](m *Map[K, V]) []K {
+	keys := make([]K, 0, m.Len())
+	m.Range(func(key K, _ V) bool {
+		keys = append(keys, key)
+		return true
+	})
+	slices.Sort(keys)
+	return keys
+}
+
+// MinKey returns the smallest key of m. The ok result is false if m is empty.
+// MinKey takes a single Range pass over the map.
+func MinKey[K cmp.Ordered, V any](m *Map[K, V]) (key K, ok bool) {
+	return extremeKey(m, -1)
+}
+
+// MaxKey returns the largest key of m. The ok result is false if m is empty.
+// MaxKey takes a single Range pass over the map.
+func MaxKey[K cmp.Ordered, V any](m *Map[K, V]) (key K, ok bool) {
+	return extremeKey(m, +1)
+}
+
+// extremeKey returns the key k of m for which cmp.Compare(k, other) has the
+// sign of sign for every other key.
+func extremeKey[K cmp.Ordered, V any](m *Map[K, V], sign int) (key K, ok bool) {
+	m.Range(func(k K, _ V) bool {
+		if !ok || cmp.Compare(k, key) == sign {
+			key, ok = k, true
+		}
+		return true
+	})
+	return key, ok
+}
+
+// RangeSorted calls f sequentially for each key and value present in m, in
+// ascending order of keys. If f returns false, RangeSorted stops the
+// iteration.
+//
+// RangeSorted collects the entries in a single Range pass before calling f,
+// so each value reported is the one seen together with its key, and f may
+// modify m without affecting the iteration.
+func RangeSorted[K cmp.Ordered, V any](m *Map[K, V], f func(key K, value V) bool) {
+	entries := make([]Pair[K, V], 0, m.Len())
+	m.Range(func(key K, value V) bool {
+		entries = append(entries, Pair[K, V]{key, value})
+		return true
+	})
+	slices.SortFunc(entries, func(a, b Pair[K, V]) int { return cmp.Compare(a.Key, b.Key) })
+	for _, e := range entries {
+		if !f(e.Key, e.Value) {
+			return
+		}
+	}
+}
+
+// EntriesSorted returns the entries of the map sorted by less, which must
+// define a strict weak ordering. The sort is not guaranteed to be stable.
+func (m *Map[K, V]) EntriesSorted(less func(a, b Pair[K, V]) bool) []Pair[K, V] {
+	entries := make([]Pair[K, V], 0, m.Len())
+	m.Range(func(key K, value V) bool {
+		entries = append(entries, Pair[K, V]{key, value})
+		return true
+	})
+	sort.Slice(entries, func(i, j int) bool { return less(entries[i], entries[j]) })
+	return entries
+}
+
+// Histogram returns the number of values in the map for each bucket name
+// returned by bucket, computed in a single pass.
+func (m *Map[K, V]) Histogram(bucket func(V) string) map[string]int {
+	h := make(map[string]int)
+	m.Range(func(_ K, value V) bool {
+		h[bucket(value)]++
+		return true
+	})
+	return h
+}
@@ -0,0 +1,114 @@
+package syncmapt_test
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+	"github.com/holdno/syncmapt/syncmapttest"
+)
+
+func TestContainsValue(t *testing.T) {
+	m := syncmapttest.New(map[string]string{"a": "Alpha", "b": "beta", "c": "ALPHA"})
+
+	if !m.ContainsValue("beta", func(a, b string) bool { return a == b }) {
+		t.Fatal("want beta found")
+	}
+	if m.ContainsValue("gamma", strings.EqualFold) {
+		t.Fatal("want gamma not found")
+	}
+
+	keys := m.KeysOf("alpha", strings.EqualFold)
+	slices.Sort(keys)
+	if want := []string{"a", "c"}; !slices.Equal(keys, want) {
+		t.Fatalf("KeysOf = %v, want %v", keys, want)
+	}
+	if keys := m.KeysOf("gamma", strings.EqualFold); len(keys) != 0 {
+		t.Fatal("unexpected", keys)
+	}
+}
+
+func TestKeysSorted(t *testing.T) {
+	m := syncmapttest.New(map[int]string{3: "c", 1: "a", 2: "b", -5: "z"})
+
+	if got, want := syncmapt.KeysSorted(m), []int{-5, 1, 2, 3}; !slices.Equal(got, want) {
+		t.Fatalf("KeysSorted = %v, want %v", got, want)
+	}
+	if got := syncmapt.KeysSorted(new(syncmapt.Map[string, int])); len(got) != 0 {
+		t.Fatal("unexpected", got)
+	}
+}
+
+func TestCountFunc(t *testing.T) {
+	states := syncmapt.NewFromMap(map[int]string{1: "idle", 2: "active", 3: "idle", 4: "closed"})
+	if n := states.CountFunc(func(_ int, s string) bool { return s == "idle" }); n != 2 {
+		t.Fatalf("CountFunc(idle) = %d, want 2", n)
+	}
+	if n := new(syncmapt.Map[int, int]).CountFunc(func(int, int) bool { return true }); n != 0 {
+		t.Fatalf("CountFunc on an empty map = %d", n)
+	}
+}
+
+func TestMinMaxKey(t *testing.T) {
+	m := new(syncmapt.Map[int, string])
+	if _, ok := syncmapt.MinKey(m); ok {
+		t.Fatal("MinKey of an empty map reported a key")
+	}
+	for _, k := range []int{7, -3, 42, 0} {
+		m.Store(k, "")
+	}
+	if k, ok := syncmapt.MinKey(m); !ok || k != -3 {
+		t.Fatalf("MinKey = %d, %v; want -3, true", k, ok)
+	}
+	if k, ok := syncmapt.MaxKey(m); !ok || k != 42 {
+		t.Fatalf("MaxKey = %d, %v; want 42, true", k, ok)
+	}
+	m.Delete(-3)
+	if k, _ := syncmapt.MinKey(m); k != 0 {
+		t.Fatalf("MinKey after deleting -3 = %d, want 0", k)
+	}
+}
+
+func TestRangeSorted(t *testing.T) {
+	m := new(syncmapt.Map[string, int])
+	for i, k := range []string{"d", "b", "a", "c"} {
+		m.Store(k, i)
+	}
+	var got []syncmapt.Pair[string, int]
+	syncmapt.RangeSorted(m, func(k string, v int) bool {
+		got = append(got, syncmapt.Pair[string, int]{k, v})
+		m.Delete(k) // does not affect the iteration
+		return k < "c"
+	})
+	want := []syncmapt.Pair[string, int]{{"a", 2}, {"b", 1}, {"c", 3}}
+	if !slices.Equal(got, want) {
+		t.Fatalf("RangeSorted visited %v, want %v", got, want)
+	}
+	if m.Len() != 1 {
+		t.Fatalf("Len() = %d after deleting three keys, want 1", m.Len())
+	}
+}
+
+func TestEntriesSorted(t *testing.T) {
+	scores := syncmapttest.New(map[string]int{"ann": 30, "bob": 10, "cat": 20})
+
+	top := scores.EntriesSorted(func(a, b syncmapt.Pair[string, int]) bool {
+		return a.Value > b.Value
+	})
+	want := []syncmapt.Pair[string, int]{{"ann", 30}, {"cat", 20}, {"bob", 10}}
+	if !slices.Equal(top, want) {
+		t.Fatalf("EntriesSorted = %v, want %v", top, want)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	m := syncmapttest.New(map[int]string{1: "idle", 2: "busy", 3: "idle", 4: "closed", 5: "idle"})
+
+	got := m.Histogram(func(state string) string { return state })
+	want := map[string]int{"idle": 3, "busy": 1, "closed": 1}
+	if !maps.Equal(got, want) {
+		t.Fatalf("Histogram = %v, want %v", got, want)
+	}
+}
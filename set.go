@@ -0,0 +1,125 @@
+package syncmapt
+
+import "iter"
+
+// A Set is a concurrent set of keys, safe for use by multiple goroutines
+// without additional locking or coordination. It is a Map of empty values,
+// and has its performance characteristics; since the values take no space,
+// adding a key allocates no value.
+//
+// The zero Set is empty and ready for use. A Set must not be copied after
+// first use.
+type Set[K comparable] struct {
+	m Map[K, struct{}]
+}
+
+// NewSet returns an empty Set configured by opts, which are the Options of a
+// Map of empty values: NewSet(WithKeyTransform[string, struct{}](strings.ToLower))
+// returns a case-insensitive set of strings.
+func NewSet[K comparable](opts ...Option[K, struct{}]) *Set[K] {
+	s := new(Set[K])
+	s.m.configure(opts)
+	return s
+}
+
+// SetOf returns a new Set holding keys.
+func SetOf[K comparable](keys ...K) *Set[K] {
+	s := new(Set[K])
+	for _, k := range keys {
+		s.Add(k)
+	}
+	return s
+}
+
+// Add adds key to the set and reports whether it was absent.
+func (s *Set[K]) Add(key K) (added bool) {
+	_, loaded := s.m.LoadOrStore(key, struct{}{})
+	return !loaded
+}
+
+// Has reports whether key is in the set.
+func (s *Set[K]) Has(key K) bool {
+	_, ok := s.m.Load(key)
+	return ok
+}
+
+// Remove removes key from the set and reports whether it was present.
+func (s *Set[K]) Remove(key K) (removed bool) {
+	_, removed = s.m.LoadAndDelete(key)
+	return removed
+}
+
+// Len returns the number of keys in the set.
+func (s *Set[K]) Len() int {
+	return s.m.Len()
+}
+
+// Range calls f sequentially for each key in the set. If f returns false,
+// range stops the iteration. Range has the semantics of Map.Range.
+func (s *Set[K]) Range(f func(key K) bool) {
+	s.m.Range(func(k K, _ struct{}) bool {
+		return f(k)
+	})
+}
+
+// All returns an iterator over the keys in the set, with the semantics of
+// Range.
+func (s *Set[K]) All() iter.Seq[K] {
+	return s.m.Keys()
+}
+
+// Clear removes all the keys, resulting in an empty Set.
+func (s *Set[K]) Clear() {
+	s.m.Clear()
+}
+
+// Union returns a new Set holding every key present in s or other, with the
+// configuration of s.
+//
+// Like Range, Union does not necessarily observe a consistent snapshot of
+// sets that are modified concurrently; neither do Intersect and Difference.
+func (s *Set[K]) Union(other *Set[K]) *Set[K] {
+	u := s.empty()
+	s.Range(func(k K) bool { u.Add(k); return true })
+	other.Range(func(k K) bool { u.Add(k); return true })
+	return u
+}
+
+// Intersect returns a new Set holding the keys present in both s and other,
+// with the configuration of s.
+func (s *Set[K]) Intersect(other *Set[K]) *Set[K] {
+	in := s.empty()
+	// Iterate over the smaller set and look the keys up in the larger one.
+	small, large := s, other
+	if other.Len() < s.Len() {
+		small, large = other, s
+	}
+	small.Range(func(k K) bool {
+		if large.Has(k) {
+			in.Add(k)
+		}
+		return true
+	})
+	return in
+}
+
+// Difference returns a new Set holding the keys of s that are not present in
+// other, with the configuration of s.
+func (s *Set[K]) Difference(other *Set[K]) *Set[K] {
+	d := s.empty()
+	s.Range(func(k K) bool {
+		if !other.Has(k) {
+			d.Add(k)
+		}
+		return true
+	})
+	return d
+}
+
+// empty returns a new, empty Set with the configuration of s.
+func (s *Set[K]) empty() *Set[K] {
+	e := new(Set[K])
+	e.m.cfg = s.m.cfg
+	e.m.ver.on = s.m.ver.on
+	return e
+}
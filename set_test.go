@@ -0,0 +1,76 @@
+package syncmapt_test
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+)
+
+func TestSet(t *testing.T) {
+	var s syncmapt.Set[string]
+	if !s.Add("a") || s.Add("a") || !s.Add("b") {
+		t.Fatal("Add did not report whether the key was absent")
+	}
+	if !s.Has("a") || s.Has("c") {
+		t.Fatal("Has reported the wrong membership")
+	}
+	if !s.Remove("a") || s.Remove("a") {
+		t.Fatal("Remove did not report whether the key was present")
+	}
+	if s.Len() != 1 {
+		t.Fatalf("Len() = %d, want 1", s.Len())
+	}
+
+	x := syncmapt.SetOf(1, 2, 3, 4)
+	y := syncmapt.SetOf(3, 4, 5)
+	for name, tt := range map[string]struct {
+		got  *syncmapt.Set[int]
+		want []int
+	}{
+		"Union":      {x.Union(y), []int{1, 2, 3, 4, 5}},
+		"Intersect":  {x.Intersect(y), []int{3, 4}},
+		"Difference": {x.Difference(y), []int{1, 2}},
+	} {
+		if got := slices.Sorted(tt.got.All()); !slices.Equal(got, tt.want) {
+			t.Errorf("%s = %v, want %v", name, got, tt.want)
+		}
+	}
+}
+
+func TestSetKeyTransform(t *testing.T) {
+	s := syncmapt.NewSet(syncmapt.WithKeyTransform[string, struct{}](strings.ToLower))
+	s.Add("Go")
+	if !s.Has("GO") || s.Add("go") {
+		t.Fatal("key transform not applied")
+	}
+	u := s.Union(syncmapt.SetOf("RUST"))
+	if !u.Has("rust") || u.Len() != 2 {
+		t.Fatalf("Union does not keep the key transform: %v", slices.Collect(u.All()))
+	}
+}
+
+func TestSetConcurrentAdd(t *testing.T) {
+	var s syncmapt.Set[int]
+	var added sync.Map
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				if s.Add(i) {
+					if _, dup := added.LoadOrStore(i, true); dup {
+						t.Errorf("Add(%d) reported true twice", i)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if s.Len() != 100 {
+		t.Fatalf("Len() = %d, want 100", s.Len())
+	}
+}
@@ -0,0 +1,137 @@
+package syncmapt
+
+// Union returns a new Map holding every key present in m or other. For a key
+// present in both, the value is choose(key, m's value, other's value), or m's
+// value if choose is nil.
+//
+// Like Range, Union does not necessarily observe a consistent snapshot of
+// maps that are modified concurrently.
+func (m *Map[K, V]) Union(other *Map[K, V], choose func(key K, a, b V) V) *Map[K, V] {
+	contents := make(map[K]V)
+	other.Range(func(k K, v V) bool {
+		contents[k] = v
+		return true
+	})
+	m.Range(func(k K, a V) bool {
+		if b, ok := contents[k]; ok && choose != nil {
+			a = choose(k, a, b)
+		}
+		contents[k] = a
+		return true
+	})
+	return fromContents(contents)
+}
+
+// Intersect returns a new Map holding the keys present in both m and other,
+// with the value choose(key, m's value, other's value), or m's value if
+// choose is nil.
+func (m *Map[K, V]) Intersect(other *Map[K, V], choose func(key K, a, b V) V) *Map[K, V] {
+	contents := make(map[K]V)
+	m.Range(func(k K, a V) bool {
+		if b, ok := other.Load(k); ok {
+			if choose != nil {
+				a = choose(k, a, b)
+			}
+			contents[k] = a
+		}
+		return true
+	})
+	return fromContents(contents)
+}
+
+// Difference returns a new Map holding the entries of m whose keys are not
+// present in other.
+func (m *Map[K, V]) Difference(other *Map[K, V]) *Map[K, V] {
+	contents := make(map[K]V)
+	m.Range(func(k K, v V) bool {
+		if _, ok := other.Load(k); !ok {
+			contents[k] = v
+		}
+		return true
+	})
+	return fromContents(contents)
+}
+
+// Merge stores the entries of other in m. For a key present in both, the value
+// stored is resolve(key, m's value, other's value), or m's value is kept if
+// resolve is nil.
+//
+// Each key is merged atomically: a concurrent update of the key in m is
+// either seen by resolve or happens after the merged value is stored. resolve
+// may be called more than once for a key when m is updated concurrently, and
+// must not block on m's own operations. Like Range, Merge does not
+// necessarily observe a consistent snapshot of other.
+func (m *Map[K, V]) Merge(other *Map[K, V], resolve func(key K, a, b V) V) {
+	if m.Closed() {
+		return
+	}
+	other.Range(func(k K, b V) bool {
+		k = m.key(k)
+		m.update(k, func(a V, loaded bool) V {
+			switch {
+			case !loaded:
+				return b
+			case resolve == nil:
+				return a
+			}
+			return resolve(k, a, b)
+		})
+		return true
+	})
+}
+
+// fromContents returns a new Map holding contents.
+func fromContents[K comparable, V any](contents map[K]V) *Map[K, V] {
+	return NewFromMap(contents)
+}
+
+// KeySet returns a new Set holding the keys of m. Combined with the methods
+// of Set, it computes key-set operations between maps and sets:
+// KeySet(m).Difference(s) is the set of keys of m that are not in s.
+func KeySet[K comparable, V any](m ReadOnlyMap[K, V]) *Set[K] {
+	s := new(Set[K])
+	m.Range(func(k K, _ V) bool {
+		s.Add(k)
+		return true
+	})
+	return s
+}
+
+// UnionKeys returns a new Set holding every key present in a or b, whatever
+// the values of the two maps.
+//
+// Like Range, UnionKeys, IntersectKeys and DifferenceKeys do not necessarily
+// observe a consistent snapshot of maps that are modified concurrently.
+func UnionKeys[K comparable, V, W any](a ReadOnlyMap[K, V], b ReadOnlyMap[K, W]) *Set[K] {
+	s := KeySet(a)
+	b.Range(func(k K, _ W) bool {
+		s.Add(k)
+		return true
+	})
+	return s
+}
+
+// IntersectKeys returns a new Set holding the keys present in both a and b.
+func IntersectKeys[K comparable, V, W any](a ReadOnlyMap[K, V], b ReadOnlyMap[K, W]) *Set[K] {
+	s := new(Set[K])
+	a.Range(func(k K, _ V) bool {
+		if _, ok := b.Load(k); ok {
+			s.Add(k)
+		}
+		return true
+	})
+	return s
+}
+
+// DifferenceKeys returns a new Set holding the keys of a that are not present
+// in b.
+func DifferenceKeys[K comparable, V, W any](a ReadOnlyMap[K, V], b ReadOnlyMap[K, W]) *Set[K] {
+	s := new(Set[K])
+	a.Range(func(k K, _ V) bool {
+		if _, ok := b.Load(k); !ok {
+			s.Add(k)
+		}
+		return true
+	})
+	return s
+}
@@ -0,0 +1,76 @@
+package syncmapt_test
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+	"github.com/holdno/syncmapt/syncmapttest"
+)
+
+func TestKeySetOps(t *testing.T) {
+	cached := syncmapt.NewFromMap(map[string]int{"a": 1, "b": 2, "c": 3})
+	fresh := syncmapt.NewFromMap(map[string]bool{"b": true, "c": false, "d": true})
+	for name, tt := range map[string]struct {
+		got  *syncmapt.Set[string]
+		want []string
+	}{
+		"UnionKeys":      {syncmapt.UnionKeys[string, int, bool](cached, fresh), []string{"a", "b", "c", "d"}},
+		"IntersectKeys":  {syncmapt.IntersectKeys[string, int, bool](cached, fresh), []string{"b", "c"}},
+		"DifferenceKeys": {syncmapt.DifferenceKeys[string, int, bool](cached, fresh), []string{"a"}},
+		"KeySet":         {syncmapt.KeySet[string, int](cached).Difference(syncmapt.SetOf("a", "c")), []string{"b"}},
+	} {
+		if got := slices.Sorted(tt.got.All()); !slices.Equal(got, tt.want) {
+			t.Errorf("%s = %v, want %v", name, got, tt.want)
+		}
+	}
+}
+
+func TestSetOps(t *testing.T) {
+	desired := syncmapttest.New(map[string]int{"a": 1, "b": 2, "c": 3})
+	actual := syncmapttest.New(map[string]int{"b": 20, "c": 30, "d": 40})
+	sum := func(_ string, a, b int) int { return a + b }
+
+	syncmapttest.RequireEqual(t, desired.Union(actual, nil), map[string]int{"a": 1, "b": 2, "c": 3, "d": 40})
+	syncmapttest.RequireEqual(t, desired.Union(actual, sum), map[string]int{"a": 1, "b": 22, "c": 33, "d": 40})
+
+	syncmapttest.RequireEqual(t, desired.Intersect(actual, nil), map[string]int{"b": 2, "c": 3})
+	syncmapttest.RequireEqual(t, desired.Intersect(actual, sum), map[string]int{"b": 22, "c": 33})
+
+	syncmapttest.RequireEqual(t, desired.Difference(actual), map[string]int{"a": 1})
+	syncmapttest.RequireEqual(t, actual.Difference(desired), map[string]int{"d": 40})
+
+	// The results are new maps.
+	u := desired.Union(actual, nil)
+	u.Store("z", 0)
+	if _, ok := desired.Load("z"); ok {
+		t.Fatal("want Union result independent of its operands")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	m := syncmapttest.New(map[string]int{"a": 1, "b": 2})
+	m.Merge(syncmapttest.New(map[string]int{"b": 20, "c": 30}), nil)
+	syncmapttest.RequireEqual(t, m, map[string]int{"a": 1, "b": 2, "c": 30})
+
+	sum := func(_ string, a, b int) int { return a + b }
+	m.Merge(syncmapttest.New(map[string]int{"a": 10, "d": 40}), sum)
+	syncmapttest.RequireEqual(t, m, map[string]int{"a": 11, "b": 2, "c": 30, "d": 40})
+
+	// Per-worker counts merged concurrently into one map are all added up.
+	var total syncmapt.Map[string, int]
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			total.Merge(syncmapttest.New(map[string]int{"hits": 1, fmt.Sprint("worker", w): w}), sum)
+		}()
+	}
+	wg.Wait()
+	if hits, _ := total.Load("hits"); hits != 8 || total.Len() != 9 {
+		t.Fatalf("merged hits = %d with %d keys, want 8 with 9", hits, total.Len())
+	}
+}
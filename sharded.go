@@ -0,0 +1,272 @@
+package syncmapt
+
+import (
+	"hash/maphash"
+	"runtime"
+	"sync"
+)
+
+// WithShards returns an Option that makes a ShardedMap partition its keys
+// across n shards. A non-positive n selects the default, a small multiple of
+// GOMAXPROCS.
+func WithShards[K comparable, V any](n int) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.shards = n
+	}
+}
+
+// ShardedMap is a concurrent map that partitions its keys by hash across
+// independently locked shards, each a plain Go map behind a sync.RWMutex.
+//
+// Map is best for keys that are written once and read many times, or written
+// by disjoint goroutines; every store of a new key takes its single internal
+// lock. ShardedMap suits write-heavy workloads over many keys instead: writes
+// to keys in different shards do not contend, at the cost of a read lock on
+// every Load. It has the same methods as Map for basic operations, so the two
+// can be swapped behind an Interface.
+//
+// Of the Options, WithShards sets the number of shards, WithKeyTransform
+// canonicalizes keys, and WithCapacity sizes the shards.
+//
+// The zero ShardedMap is empty, uses the default number of shards, and is
+// ready for use. A ShardedMap must not be copied after first use.
+type ShardedMap[K comparable, V any] struct {
+	once   sync.Once
+	cfg    *config[K, V]
+	seed   maphash.Seed
+	shards []Shard[K, V]
+}
+
+var _ Interface[string, any] = (*ShardedMap[string, any])(nil)
+
+// A Shard is one of the partitions of a ShardedMap. Shards let a sequence of
+// operations on keys that live in the same shard run under a single lock:
+//
+//	s := m.ShardFor(key)
+//	s.Lock()
+//	v, _ := s.Load(key)
+//	s.Store(key, v+1)
+//	s.Unlock()
+//
+// The Load, Store and Delete methods of a Shard must be called with the
+// shard locked, and only with keys for which ShardFor returns the shard: an
+// entry stored in the wrong shard is invisible to the ShardedMap.
+type Shard[K comparable, V any] struct {
+	mu     sync.RWMutex
+	m      map[K]V
+	cfg    *config[K, V]
+	closed bool     // set by Close, with mu held
+	_      [64]byte // keeps the locks of adjacent shards in different cache lines
+}
+
+// NewShardedMap returns an empty ShardedMap configured by opts.
+func NewShardedMap[K comparable, V any](opts ...Option[K, V]) *ShardedMap[K, V] {
+	m := &ShardedMap[K, V]{cfg: newConfig(opts)}
+	m.init()
+	return m
+}
+
+func (m *ShardedMap[K, V]) init() {
+	m.once.Do(func() {
+		if m.cfg == nil {
+			m.cfg = newConfig[K, V](nil)
+		}
+		n := m.cfg.shards
+		if n <= 0 {
+			n = 4 * runtime.GOMAXPROCS(0)
+		}
+		m.seed = maphash.MakeSeed()
+		m.shards = make([]Shard[K, V], n)
+		for i := range m.shards {
+			m.shards[i].m = make(map[K]V, m.cfg.capacity/n)
+			m.shards[i].cfg = m.cfg
+		}
+	})
+}
+
+// shard returns the shard of key, which must already have been transformed.
+func (m *ShardedMap[K, V]) shard(key K) *Shard[K, V] {
+	return &m.shards[maphash.Comparable(m.seed, key)%uint64(len(m.shards))]
+}
+
+// ShardFor returns the shard that holds key.
+func (m *ShardedMap[K, V]) ShardFor(key K) *Shard[K, V] {
+	m.init()
+	return m.shard(m.key(key))
+}
+
+func (m *ShardedMap[K, V]) key(k K) K {
+	if m.cfg.keyTransform == nil {
+		return k
+	}
+	return m.cfg.keyTransform(k)
+}
+
+// Load returns the value stored in the map for a key, or the zero value if no
+// value is present. The ok result indicates whether value was found in the
+// map.
+func (m *ShardedMap[K, V]) Load(key K) (value V, ok bool) {
+	m.init()
+	key = m.key(key)
+	s := m.shard(key)
+	s.mu.RLock()
+	value, ok = s.m[key]
+	s.mu.RUnlock()
+	return value, ok
+}
+
+// Store sets the value for a key.
+func (m *ShardedMap[K, V]) Store(key K, value V) {
+	m.init()
+	key = m.key(key)
+	s := m.shard(key)
+	s.mu.Lock()
+	if !s.closed {
+		s.m[key] = value
+	}
+	s.mu.Unlock()
+}
+
+// LoadOrStore returns the existing value for the key if present. Otherwise,
+// it stores and returns the given value. The loaded result is true if the
+// value was loaded, false if stored.
+func (m *ShardedMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	m.init()
+	key = m.key(key)
+	s := m.shard(key)
+	s.mu.RLock()
+	actual, loaded = s.m[key]
+	s.mu.RUnlock()
+	if loaded {
+		return actual, true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if actual, loaded = s.m[key]; loaded || s.closed {
+		return actual, loaded
+	}
+	s.m[key] = value
+	return value, false
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if
+// any. The loaded result reports whether the key was present.
+func (m *ShardedMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	m.init()
+	key = m.key(key)
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return value, false
+	}
+	value, loaded = s.m[key]
+	delete(s.m, key)
+	return value, loaded
+}
+
+// Delete deletes the value for a key.
+func (m *ShardedMap[K, V]) Delete(key K) {
+	m.LoadAndDelete(key)
+}
+
+// Range calls f sequentially for each key and value present in the map. If f
+// returns false, range stops the iteration.
+//
+// Range copies the entries of one shard at a time under its read lock and
+// calls f with no lock held, so f may call any method of m. Each shard is
+// seen in a consistent state, but the map as a whole is not, as with Map.
+func (m *ShardedMap[K, V]) Range(f func(key K, value V) bool) {
+	m.init()
+	var entries []Pair[K, V]
+	for i := range m.shards {
+		s := &m.shards[i]
+		entries = entries[:0]
+		s.mu.RLock()
+		for k, v := range s.m {
+			entries = append(entries, Pair[K, V]{k, v})
+		}
+		s.mu.RUnlock()
+		for _, e := range entries {
+			if !f(e.Key, e.Value) {
+				return
+			}
+		}
+	}
+}
+
+// Len returns the number of entries in the map, adding up the shards one at a
+// time.
+func (m *ShardedMap[K, V]) Len() int {
+	m.init()
+	n := 0
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mu.RLock()
+		n += len(s.m)
+		s.mu.RUnlock()
+	}
+	return n
+}
+
+// Close closes the map: afterwards its contents can still be read, but the
+// methods that would modify it, including those of its Shards, do nothing,
+// and LoadAndDelete reports that nothing was deleted. Close is idempotent and
+// always returns nil.
+func (m *ShardedMap[K, V]) Close() error {
+	m.init()
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mu.Lock()
+		s.closed = true
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// Shards returns the number of shards of the map.
+func (m *ShardedMap[K, V]) Shards() int {
+	m.init()
+	return len(m.shards)
+}
+
+// Lock locks the shard for a sequence of operations.
+func (s *Shard[K, V]) Lock() { s.mu.Lock() }
+
+// Unlock unlocks the shard.
+func (s *Shard[K, V]) Unlock() { s.mu.Unlock() }
+
+func (s *Shard[K, V]) key(k K) K {
+	if s.cfg.keyTransform == nil {
+		return k
+	}
+	return s.cfg.keyTransform(k)
+}
+
+// Load returns the value stored in the shard for a key. The shard must be
+// locked.
+func (s *Shard[K, V]) Load(key K) (value V, ok bool) {
+	value, ok = s.m[s.key(key)]
+	return value, ok
+}
+
+// Store sets the value for a key in the shard. The shard must be locked.
+func (s *Shard[K, V]) Store(key K, value V) {
+	if !s.closed {
+		s.m[s.key(key)] = value
+	}
+}
+
+// Delete deletes the value for a key from the shard. The shard must be
+// locked.
+func (s *Shard[K, V]) Delete(key K) {
+	if !s.closed {
+		delete(s.m, s.key(key))
+	}
+}
+
+// Len returns the number of entries in the shard. The shard must be locked.
+func (s *Shard[K, V]) Len() int {
+	return len(s.m)
+}
@@ -0,0 +1,88 @@
+package syncmapt_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+	"github.com/holdno/syncmapt/syncmapttest"
+)
+
+func TestShardedMap(t *testing.T) {
+	t.Run("Conformance", func(t *testing.T) {
+		syncmapttest.RunConformance(t, func() syncmapt.Interface[string, string] {
+			return syncmapt.NewShardedMap(syncmapt.WithShards[string, string](4))
+		})
+	})
+
+	var zero syncmapt.ShardedMap[int, int]
+	if zero.Shards() < 1 {
+		t.Fatalf("zero ShardedMap has %d shards", zero.Shards())
+	}
+	zero.Store(1, 1)
+	if v, ok := zero.Load(1); !ok || v != 1 {
+		t.Fatalf("Load(1) = %v, %v", v, ok)
+	}
+
+	m := syncmapt.NewShardedMap(
+		syncmapt.WithShards[string, int](3),
+		syncmapt.WithKeyTransform[string, int](strings.ToLower),
+		syncmapt.WithCapacity[string, int](30),
+	)
+	if m.Shards() != 3 {
+		t.Fatalf("Shards() = %d, want 3", m.Shards())
+	}
+	m.Store("A", 1)
+	if actual, loaded := m.LoadOrStore("a", 2); !loaded || actual != 1 {
+		t.Fatalf("LoadOrStore(a) = %v, %v; want 1, true", actual, loaded)
+	}
+	if m.ShardFor("A") != m.ShardFor("a") {
+		t.Fatal("ShardFor ignores the key transform")
+	}
+	if v, loaded := m.LoadAndDelete("A"); !loaded || v != 1 || m.Len() != 0 {
+		t.Fatalf("LoadAndDelete(A) = %v, %v, Len() = %d", v, loaded, m.Len())
+	}
+
+	// Range lets f modify the map without deadlocking.
+	for _, k := range []string{"x", "y", "z"} {
+		m.Store(k, 0)
+	}
+	m.Range(func(k string, v int) bool {
+		m.Store(k, v+1)
+		return true
+	})
+	syncmapttest.RequireEqual(t, m, map[string]int{"x": 1, "y": 1, "z": 1})
+}
+
+func TestShardFor(t *testing.T) {
+	m := syncmapt.NewShardedMap[string, int]()
+	const goroutines, increments = 4, 1000
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < increments; i++ {
+				s := m.ShardFor("counter")
+				s.Lock()
+				v, _ := s.Load("counter")
+				s.Store("counter", v+1)
+				s.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if v, _ := m.Load("counter"); v != goroutines*increments {
+		t.Fatalf("counter = %d, want %d", v, goroutines*increments)
+	}
+
+	s := m.ShardFor("counter")
+	s.Lock()
+	s.Delete("counter")
+	n := s.Len()
+	s.Unlock()
+	if n != 0 || m.Len() != 0 {
+		t.Fatalf("after deleting through the shard: shard Len() = %d, map Len() = %d", n, m.Len())
+	}
+}
@@ -0,0 +1,183 @@
+package syncmapt
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// versions orders the changes to the entries of a Map against its Snapshots.
+//
+// Taking a Snapshot starts a new epoch. A Snapshot sees the versions born in
+// earlier epochs. Lock-free operations publish a version before they can
+// read the epoch, so a version is published unborn and is settled into the
+// current epoch by whichever comes first: the operation that published it or
+// any operation that observes it. A version observed before a Snapshot was
+// taken is therefore always visible to it, and one published after it never
+// is.
+type versions struct {
+	on bool // set by WithSnapshots when the Map is created
+
+	// epoch is the number of Snapshots taken of the map.
+	epoch atomic.Uint64
+	// oldest is the epoch of the oldest reachable Snapshot, or 0 if there is
+	// none. A version born before it can never be replaced in the view of a
+	// Snapshot by an earlier version, so its prev link is no longer needed.
+	oldest atomic.Uint64
+
+	mu   sync.Mutex
+	live map[uint64]int // number of reachable Snapshots, by epoch
+}
+
+// unneeded reports whether every reachable Snapshot sees a version born in
+// epoch born, or one published after it.
+func (vs *versions) unneeded(born uint64) bool {
+	oldest := vs.oldest.Load()
+	return oldest == 0 || oldest > born
+}
+
+// begin starts a new epoch for a Snapshot and returns it. It must be called
+// with the Map's mu held, so that no new key is added while it runs.
+func (vs *versions) begin() uint64 {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	e := vs.epoch.Load() + 1
+	if vs.live == nil {
+		vs.live = make(map[uint64]int)
+	}
+	vs.live[e]++
+	// Register the Snapshot before any version can be born in its epoch, so
+	// that the operation settling such a version sees it and keeps the
+	// version's predecessor.
+	if vs.oldest.Load() == 0 {
+		vs.oldest.Store(e)
+	}
+	vs.epoch.Store(e)
+	return e
+}
+
+// end forgets a Snapshot taken in epoch e that is no longer reachable.
+func (vs *versions) end(e uint64) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	if vs.live[e]--; vs.live[e] > 0 {
+		return
+	}
+	delete(vs.live, e)
+	if vs.oldest.Load() != e {
+		return
+	}
+	var oldest uint64
+	for e := range vs.live {
+		if oldest == 0 || e < oldest {
+			oldest = e
+		}
+	}
+	vs.oldest.Store(oldest)
+}
+
+// bornIn returns the epoch v was born in, settling it into the current epoch
+// if it is still unborn.
+func (v *version[V]) bornIn(vs *versions) uint64 {
+	if born := v.born.Load(); born != 0 {
+		return born - 1
+	}
+	v.born.CompareAndSwap(0, vs.epoch.Load()+1)
+	return v.born.Load() - 1
+}
+
+// settle finishes the publication of v.
+func (v *version[V]) settle(vs *versions) {
+	if vs.unneeded(v.bornIn(vs)) && atomic.LoadPointer(&v.prev) != nil {
+		atomic.StorePointer(&v.prev, nil)
+	}
+}
+
+// obsolete reports whether every reachable Snapshot sees v or a later
+// version of its entry.
+func (v *version[V]) obsolete(vs *versions) bool {
+	born := v.born.Load()
+	return born != 0 && vs.unneeded(born-1)
+}
+
+// trim drops the link from v to its predecessor if no Snapshot needs it.
+func (v *version[V]) trim(vs *versions) {
+	if v.obsolete(vs) && atomic.LoadPointer(&v.prev) != nil {
+		atomic.StorePointer(&v.prev, nil)
+	}
+}
+
+// A snapshot is the state of a Map at the time Snapshot was called.
+type snapshot[K comparable, V any] struct {
+	m     *Map[K, V]
+	read  map[K]*entry[V]
+	epoch uint64
+}
+
+// Snapshot returns a frozen view of the map: a ReadOnlyMap that reflects the
+// contents of the map at the time Snapshot was called, however the map is
+// changed afterwards. The map must have been created WithSnapshots.
+//
+// Snapshot takes constant time and does not copy the map. Instead, while a
+// snapshot is reachable, values that are replaced or deleted remain
+// referenced by the map until the same key is next written after the
+// snapshot has been garbage collected, or until the map is next rebuilt
+// internally. Writers are not blocked by snapshots.
+func (m *Map[K, V]) Snapshot() ReadOnlyMap[K, V] {
+	if !m.ver.on {
+		panic("syncmapt: Snapshot of a Map created without WithSnapshots")
+	}
+	m.mu.Lock()
+	read := m.promotedLocked()
+	s := &snapshot[K, V]{m: m, read: read.m, epoch: m.ver.begin()}
+	m.mu.Unlock()
+
+	runtime.AddCleanup(s, m.ver.end, s.epoch)
+	return s
+}
+
+// resolve returns the value e held when s was taken.
+func (s *snapshot[K, V]) resolve(e *entry[V]) (value V, ok bool) {
+	vs := &s.m.ver
+	p := e.loadPointer()
+	if p == nil || p == expunged {
+		return value, false
+	}
+	// s is reachable, so no version born in its epoch or later has lost its
+	// link to its predecessor.
+	for v := (*version[V])(p); v != nil; v = (*version[V])(atomic.LoadPointer(&v.prev)) {
+		if v.bornIn(vs) < s.epoch {
+			return v.v, !v.dead
+		}
+	}
+	return value, false
+}
+
+func (s *snapshot[K, V]) Load(key K) (value V, ok bool) {
+	e, ok := s.read[s.m.key(key)]
+	if !ok {
+		return value, false
+	}
+	return s.resolve(e)
+}
+
+func (s *snapshot[K, V]) Range(f func(key K, value V) bool) {
+	for k, e := range s.read {
+		v, ok := s.resolve(e)
+		if !ok {
+			continue
+		}
+		if !f(k, v) {
+			break
+		}
+	}
+}
+
+func (s *snapshot[K, V]) Len() int {
+	var l int
+	s.Range(func(_ K, _ V) bool {
+		l++
+		return true
+	})
+	return l
+}
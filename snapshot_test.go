@@ -0,0 +1,156 @@
+package syncmapt_test
+
+import (
+	"maps"
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+	"github.com/holdno/syncmapt/syncmapttest"
+)
+
+func TestSnapshot(t *testing.T) {
+	m := syncmapt.New(syncmapt.WithSnapshots[string, int]())
+	syncmapttest.Seed(m, map[string]int{"a": 1, "b": 2, "c": 3, "d": 4})
+	m.Delete("d")
+
+	s := m.Snapshot()
+	m.Store("a", 10)
+	m.Delete("b")
+	m.Store("d", 40)
+	m.Store("e", 50)
+	m.Move("c", "f")
+	m.LoadOrStore("b", 20)
+
+	want := map[string]int{"a": 1, "b": 2, "c": 3}
+	syncmapttest.RequireEqual(t, s, want)
+	if s.Len() != 3 {
+		t.Fatal("unexpected Len", s.Len())
+	}
+	for _, k := range []string{"d", "e", "f"} {
+		if v, ok := s.Load(k); ok {
+			t.Fatalf("snapshot has %s: %d", k, v)
+		}
+	}
+	syncmapttest.RequireEqual(t, m, map[string]int{"a": 10, "b": 20, "d": 40, "e": 50, "f": 3})
+
+	// Rebuilding the map's internal storage must not disturb the snapshot.
+	m.Range(func(string, int) bool { return true })
+	m.Delete("a")
+	m.Store("g", 7)
+	syncmapttest.RequireEqual(t, s, want)
+	runtime.KeepAlive(s)
+}
+
+func TestSnapshotRequiresOption(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("want panic")
+		}
+	}()
+	new(syncmapt.Map[string, int]).Snapshot()
+}
+
+// TestSnapshotConsistent checks that a snapshot taken while a writer sweeps
+// over the keys reflects a single point of the sweep, and includes every
+// value that was loaded before it was taken.
+func TestSnapshotConsistent(t *testing.T) {
+	const keys = 64
+	m := syncmapt.New(syncmapt.WithSnapshots[int, int]())
+	for k := 0; k < keys; k++ {
+		m.Store(k, 0)
+	}
+
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for round := 1; !stop.Load(); round++ {
+			for k := 0; k < keys; k++ {
+				if k%7 == 3 {
+					m.Delete(k)
+				}
+				m.Store(k, round)
+			}
+		}
+	}()
+
+	for i := 0; i < 2000; i++ {
+		seen, _ := m.Load(keys - 1)
+		s := m.Snapshot()
+		prev, _ := s.Load(0)
+		first := prev
+		for k := 0; k < keys; k++ {
+			v, ok := s.Load(k)
+			if !ok {
+				// Deleted just before being stored again in the same round.
+				if k%7 != 3 {
+					t.Fatalf("snapshot %d: key %d missing", i, k)
+				}
+				continue
+			}
+			if v > prev || first-v > 1 {
+				t.Fatalf("snapshot %d: key %d holds %d after %d (first %d)", i, k, v, prev, first)
+			}
+			prev = v
+		}
+		if last, _ := s.Load(keys - 1); last < seen {
+			t.Fatalf("snapshot %d: key %d holds %d, loaded %d before it was taken", i, keys-1, last, seen)
+		}
+	}
+	stop.Store(true)
+	wg.Wait()
+}
+
+// TestSnapshotModel takes snapshots at random points of a random sequence of
+// operations and checks each against a copy of a plain map taken at the same
+// point.
+func TestSnapshotModel(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	m := syncmapt.New(syncmapt.WithSnapshots[int, int]())
+	model := make(map[int]int)
+
+	type taken struct {
+		s    syncmapt.ReadOnlyMap[int, int]
+		want map[int]int
+	}
+	var snaps []taken
+	for i := 0; i < 5000; i++ {
+		k, v := r.Intn(32), r.Int()
+		switch r.Intn(6) {
+		case 0, 1:
+			m.Store(k, v)
+			model[k] = v
+		case 2:
+			if _, loaded := m.LoadOrStore(k, v); !loaded {
+				model[k] = v
+			}
+		case 3:
+			m.Delete(k)
+			delete(model, k)
+		case 4:
+			if dst := v % 32; m.Move(k, dst) && dst != k {
+				model[dst] = model[k]
+				delete(model, k)
+			}
+		case 5:
+			if r.Intn(20) == 0 {
+				snaps = append(snaps, taken{m.Snapshot(), maps.Clone(model)})
+			}
+			if r.Intn(50) == 0 {
+				m.Range(func(int, int) bool { return true })
+			}
+		}
+	}
+	if len(snaps) == 0 {
+		t.Fatal("no snapshots taken")
+	}
+	for _, s := range snaps {
+		syncmapttest.RequireEqual(t, s.s, s.want)
+	}
+	syncmapttest.RequireEqual(t, m, model)
+}
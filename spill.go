@@ -0,0 +1,264 @@
+package syncmapt
+
+import (
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// WithSpill returns an Option that makes a BytesMap keep at most about
+// memoryLimit bytes of values in memory, writing the coldest of the others to
+// a temporary file in dir, or in os.TempDir if dir is empty. A spilled value
+// is read back and kept in memory again the next time it is loaded. Keys and
+// the location of each spilled value stay in memory.
+//
+// In a map created WithSpill, loads of values held in memory are as fast as
+// in any BytesMap, but writes and loads of spilled values serialize on an
+// internal lock. Errors writing the spill file leave values in memory, and
+// are reported by SpillStats.
+func WithSpill[K comparable](dir string, memoryLimit int64) Option[K, []byte] {
+	return func(c *config[K, []byte]) {
+		c.spillDir = dir
+		c.spillLimit = memoryLimit
+	}
+}
+
+// SpillStats describes the state of the spill file of a BytesMap.
+type SpillStats struct {
+	Resident int64 // bytes of values held in memory
+	Spilled  int64 // bytes of values held in the spill file
+	FileSize int64 // size of the spill file, including space not yet reclaimed
+
+	Spills     int64 // values written to the spill file
+	Promotions int64 // spilled values read back into memory
+
+	Err error // the last error writing the spill file, if any
+}
+
+// SpillStats returns the state of the spill file of a map created WithSpill,
+// or zero SpillStats for other maps.
+func (m *BytesMap[K]) SpillStats() SpillStats {
+	s := m.spill
+	if s == nil {
+		return SpillStats{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return SpillStats{
+		Resident:   s.resident,
+		Spilled:    s.end - s.dead,
+		FileSize:   s.end,
+		Spills:     s.spills,
+		Promotions: s.promotions,
+		Err:        s.err,
+	}
+}
+
+// A spiller moves the values of a BytesMap between memory and its spill file.
+// Every change to the map goes through the spiller with mu held, so that the
+// spiller's accounting and its rewrites of entries cannot race with them.
+//
+// Values in memory are spilled in the order in which they were stored or
+// promoted, except that a value loaded since it was last considered gets a
+// second chance, as in the CLOCK page replacement algorithm.
+type spiller[K comparable] struct {
+	dir   string
+	limit int64
+
+	mu       sync.Mutex
+	f        *os.File // created on the first spill
+	end      int64    // size of f
+	dead     int64    // bytes of f no longer referenced by the map
+	resident int64
+	queue    []K // keys that may hold resident values, oldest first
+	queued   map[K]struct{}
+
+	spills, promotions int64
+	err                error
+}
+
+// compactAt is the number of unreferenced spill file bytes from which the file
+// is rewritten, if they are also more than half of it.
+const compactAt = 1 << 20
+
+// store stores p for key. s.mu must be held.
+func (s *spiller[K]) store(m *BytesMap[K], key K, p packed) {
+	p.hot = new(atomic.Bool)
+	if old, ok := m.m.Load(key); ok {
+		s.forget(old)
+	}
+	m.m.Store(key, p)
+	s.resident += int64(len(p.b))
+	s.enqueue(key)
+	s.evict(m)
+	s.compact(m)
+}
+
+// forget accounts for the removal of p from the map. s.mu must be held.
+func (s *spiller[K]) forget(p packed) {
+	if p.spilled {
+		s.dead += int64(p.n)
+	} else {
+		s.resident -= int64(len(p.b))
+	}
+}
+
+func (s *spiller[K]) enqueue(key K) {
+	if _, ok := s.queued[key]; !ok {
+		s.queued[key] = struct{}{}
+		s.queue = append(s.queue, key)
+	}
+}
+
+// promote reads the spilled value for key back into memory. It reports false
+// if key was deleted in the meantime.
+func (s *spiller[K]) promote(m *BytesMap[K], key K) (packed, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.promoteLocked(m, key)
+}
+
+func (s *spiller[K]) promoteLocked(m *BytesMap[K], key K) (packed, bool) {
+	p, ok := m.m.Load(key)
+	if !ok || !p.spilled {
+		return p, ok
+	}
+	r := s.read(p)
+	r.hot = new(atomic.Bool)
+	r.hot.Store(true)
+	m.m.Store(key, r)
+	s.dead += int64(p.n)
+	s.resident += int64(len(r.b))
+	s.promotions++
+	s.enqueue(key)
+	s.evict(m)
+	s.compact(m)
+	return r, true
+}
+
+// load returns the value for key without promoting it.
+func (s *spiller[K]) load(m *BytesMap[K], key K) (packed, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := m.m.Load(key)
+	if ok && p.spilled {
+		p = s.read(p)
+	}
+	return p, ok
+}
+
+// read returns the spilled value p as a resident one. s.mu must be held.
+func (s *spiller[K]) read(p packed) packed {
+	b := make([]byte, p.n)
+	if _, err := s.f.ReadAt(b, p.off); err != nil {
+		// Unlike a failed write, which leaves the value in memory, a failed
+		// read loses it.
+		panic("syncmapt: reading spilled value: " + err.Error())
+	}
+	return packed{b: b, compressed: p.compressed}
+}
+
+// evict spills resident values until no more than s.limit bytes of them are
+// left. s.mu must be held.
+func (s *spiller[K]) evict(m *BytesMap[K]) {
+	for s.resident > s.limit && len(s.queue) > 0 {
+		key := s.queue[0]
+		var zero K
+		s.queue[0] = zero
+		s.queue = s.queue[1:]
+		delete(s.queued, key)
+
+		p, ok := m.m.Load(key)
+		if !ok || p.spilled {
+			continue
+		}
+		if p.hot.Load() {
+			p.hot.Store(false)
+			s.enqueue(key)
+			continue
+		}
+		off, err := s.write(p.b)
+		if err != nil {
+			s.err = err
+			s.enqueue(key)
+			return
+		}
+		m.m.Store(key, packed{compressed: p.compressed, spilled: true, off: off, n: len(p.b)})
+		s.resident -= int64(len(p.b))
+		s.spills++
+	}
+	if len(s.queue) < cap(s.queue)/4 {
+		// Let the garbage collector reclaim the part of the array that
+		// the queue moved past.
+		s.queue = append([]K(nil), s.queue...)
+	}
+}
+
+// write appends b to the spill file and returns its offset.
+func (s *spiller[K]) write(b []byte) (int64, error) {
+	if s.f == nil {
+		f, err := os.CreateTemp(s.dir, "syncmapt-spill-*")
+		if err != nil {
+			return 0, err
+		}
+		s.f = f
+	}
+	off := s.end
+	if _, err := s.f.WriteAt(b, off); err != nil {
+		return 0, err
+	}
+	s.end += int64(len(b))
+	return off, nil
+}
+
+// compact rewrites the spill file without its unreferenced bytes once they
+// make up most of it. s.mu must be held.
+func (s *spiller[K]) compact(m *BytesMap[K]) {
+	if s.dead < compactAt || s.dead < s.end/2 {
+		return
+	}
+	old, oldEnd, oldDead := s.f, s.end, s.dead
+	s.f, s.end, s.dead = nil, 0, 0
+	moved := make(map[K]packed)
+	var err error
+	m.m.Range(func(k K, p packed) bool {
+		if !p.spilled {
+			return true
+		}
+		b := make([]byte, p.n)
+		if _, err = old.ReadAt(b, p.off); err == nil {
+			p.off, err = s.write(b)
+		}
+		moved[k] = p
+		return err == nil
+	})
+	if err != nil {
+		// Keep using the old file.
+		s.err = err
+		if s.f != nil {
+			s.f.Close()
+			os.Remove(s.f.Name())
+		}
+		s.f, s.end, s.dead = old, oldEnd, oldDead
+		return
+	}
+	for k, p := range moved {
+		m.m.Store(k, p)
+	}
+	old.Close()
+	os.Remove(old.Name())
+}
+
+// close removes the spill file. s.mu must be held.
+func (s *spiller[K]) close() error {
+	if s.f == nil {
+		return nil
+	}
+	f := s.f
+	s.f = nil
+	err := f.Close()
+	if rerr := os.Remove(f.Name()); err == nil {
+		err = rerr
+	}
+	return err
+}
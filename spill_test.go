@@ -0,0 +1,134 @@
+package syncmapt_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+)
+
+func TestBytesMapSpill(t *testing.T) {
+	dir := t.TempDir()
+	m := syncmapt.NewBytesMap(syncmapt.WithSpill[int](dir, 10_000))
+	defer m.Close()
+
+	value := func(i int) []byte { return bytes.Repeat([]byte{byte(i)}, 1000) }
+	for i := 0; i < 100; i++ {
+		m.Store(i, value(i))
+	}
+	s := m.SpillStats()
+	if s.Resident > 10_000 || s.Spilled != 90_000 || s.Err != nil {
+		t.Fatalf("after storing 100 values: %+v", s)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 1 {
+		t.Errorf("spill directory holds %d files, want 1", len(files))
+	}
+
+	for i := 0; i < 100; i++ {
+		if got, ok := m.Load(i); !ok || !bytes.Equal(got, value(i)) {
+			t.Fatalf("Load(%d) = %d bytes, %v", i, len(got), ok)
+		}
+	}
+	if s := m.SpillStats(); s.Promotions < 90 || s.Resident > 10_000 {
+		t.Errorf("after loading every value: %+v", s)
+	}
+
+	n := 0
+	m.Range(func(k int, v []byte) bool {
+		if !bytes.Equal(v, value(k)) {
+			t.Errorf("Range passed the wrong value for %d", k)
+		}
+		n++
+		return true
+	})
+	if n != 100 {
+		t.Errorf("Range visited %d entries, want 100", n)
+	}
+
+	for i := 0; i < 100; i++ {
+		if i%2 == 0 {
+			m.Delete(i)
+		} else if v, ok := m.LoadAndDelete(i); !ok || !bytes.Equal(v, value(i)) {
+			t.Errorf("LoadAndDelete(%d) = %d bytes, %v", i, len(v), ok)
+		}
+	}
+	if s := m.SpillStats(); m.Len() != 0 || s.Resident != 0 || s.Spilled != 0 {
+		t.Errorf("after deleting everything: Len() = %d, %+v", m.Len(), s)
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("spill directory holds %d files after Close", len(files))
+	}
+}
+
+func TestBytesMapSpillCompaction(t *testing.T) {
+	m := syncmapt.NewBytesMap(syncmapt.WithSpill[int](t.TempDir(), 1))
+	defer m.Close()
+	v := make([]byte, 10_000)
+	for round := 0; round < 50; round++ {
+		for i := 0; i < 10; i++ {
+			m.Store(i, v)
+		}
+	}
+	if s := m.SpillStats(); s.FileSize > 4<<20 || s.Spilled > 100_000 {
+		t.Errorf("spill file was not compacted: %+v", s)
+	}
+	for i := 0; i < 10; i++ {
+		if got, ok := m.Load(i); !ok || len(got) != len(v) {
+			t.Errorf("Load(%d) = %d bytes, %v after compaction", i, len(got), ok)
+		}
+	}
+}
+
+func TestBytesMapSpillCompressed(t *testing.T) {
+	codec, _ := syncmapt.NewFlateCodec(flate.BestSpeed)
+	m := syncmapt.NewBytesMap(
+		syncmapt.WithValueCompression[string](codec, 0),
+		syncmapt.WithSpill[string]("", 1),
+	)
+	defer m.Close()
+	v := bytes.Repeat([]byte("compressible "), 100)
+	m.Store("a", v)
+	m.Store("b", v)
+	if got, _ := m.Load("a"); !bytes.Equal(got, v) {
+		t.Errorf("Load(a) = %q", got)
+	}
+	if s := m.SpillStats(); s.Spilled == 0 || s.Spilled >= int64(len(v)) {
+		t.Errorf("SpillStats() = %+v; want a compressed value spilled", s)
+	}
+}
+
+func TestBytesMapSpillConcurrent(t *testing.T) {
+	m := syncmapt.NewBytesMap(syncmapt.WithSpill[string](t.TempDir(), 2_000))
+	defer m.Close()
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				k := fmt.Sprint(i % 50)
+				switch i % 4 {
+				case 0:
+					m.Store(k, []byte(k+k+k+k+k+k+k+k+k+k))
+				case 1:
+					if v, ok := m.Load(k); ok && string(v) != k+k+k+k+k+k+k+k+k+k {
+						t.Errorf("Load(%s) = %q", k, v)
+					}
+				case 2:
+					m.Range(func(string, []byte) bool { return true })
+				case 3:
+					m.Delete(k)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
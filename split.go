@@ -0,0 +1,33 @@
+package syncmapt
+
+import "hash/maphash"
+
+// Split distributes the entries of the map across n new Maps by hashing their
+// keys, and returns the new Maps; m itself is not modified. The new Maps have
+// the same configuration as m. Split panics if n < 1.
+//
+// Split has the consistency of ToMap: for a map created WithSnapshots it
+// splits a Snapshot, and otherwise it visits the entries as Range does, so
+// an entry stored or deleted by another goroutine during the Split may or may
+// not be reflected.
+func (m *Map[K, V]) Split(n int) []*Map[K, V] {
+	if n < 1 {
+		panic("syncmapt: Split with n < 1")
+	}
+	parts := make([][]Pair[K, V], n)
+	seed := maphash.MakeSeed()
+
+	m.rangeSnapshot(func(k K, v V) bool {
+		i := maphash.Comparable(seed, k) % uint64(n)
+		parts[i] = append(parts[i], Pair[K, V]{k, v})
+		return true
+	})
+
+	maps := make([]*Map[K, V], n)
+	for i, entries := range parts {
+		maps[i] = &Map[K, V]{cfg: m.cfg}
+		maps[i].ver.on = m.ver.on
+		maps[i].adopt(entries)
+	}
+	return maps
+}
@@ -0,0 +1,48 @@
+package syncmapt_test
+
+import (
+	"testing"
+
+	"github.com/holdno/syncmapt"
+)
+
+func TestSplit(t *testing.T) {
+	m := new(syncmapt.Map[int, int])
+	const total = 1000
+	for i := 0; i < total; i++ {
+		m.Store(i, i*i)
+	}
+
+	parts := m.Split(4)
+	if len(parts) != 4 {
+		t.Fatal("unexpected", len(parts))
+	}
+	seen := make(map[int]bool)
+	for _, p := range parts {
+		if p.Len() == 0 || p.Len() == total {
+			t.Fatalf("want entries spread across parts, got a part with %d", p.Len())
+		}
+		p.Range(func(k, v int) bool {
+			if seen[k] {
+				t.Fatalf("key %d in more than one part", k)
+			}
+			if v != k*k {
+				t.Fatalf("key %d has value %d", k, v)
+			}
+			seen[k] = true
+			return true
+		})
+	}
+	if len(seen) != total {
+		t.Fatalf("want %d keys across parts, got %d", total, len(seen))
+	}
+	if m.Len() != total {
+		t.Fatal("want source unchanged")
+	}
+
+	// Parts are independent, working Maps.
+	parts[0].Store(-1, 1)
+	if _, ok := m.Load(-1); ok {
+		t.Fatal("want parts independent of the source")
+	}
+}
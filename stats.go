@@ -0,0 +1,73 @@
+package syncmapt
+
+import "sync/atomic"
+
+// WithStats returns an Option that makes a Map count its operations, as
+// reported by Stats. Counting makes every operation somewhat slower, since
+// concurrent operations update shared counters.
+func WithStats[K comparable, V any]() Option[K, V] {
+	return func(c *config[K, V]) {
+		c.stats = true
+	}
+}
+
+// Stats counts the operations of a Map since it was created.
+type Stats struct {
+	// Loads is the number of calls to Load, and Hits the number of them that
+	// found a value.
+	Loads, Hits int64
+	// Stores is the number of values stored, Deletes the number of values
+	// deleted, and Expirations the number of values deleted because they
+	// expired. Clear and ReplaceAll are not counted.
+	Stores, Deletes, Expirations int64
+	// Evictions is the number of values a BoundedMap evicted to make room
+	// for new ones.
+	Evictions int64
+}
+
+// Misses returns the number of Loads that found no value.
+func (s Stats) Misses() int64 {
+	return s.Loads - s.Hits
+}
+
+// HitRatio returns the fraction of Loads that found a value, or 0 if there
+// were none.
+func (s Stats) HitRatio() float64 {
+	if s.Loads == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Loads)
+}
+
+// opStats holds the counters of a Map created WithStats.
+type opStats struct {
+	loads, hits, stores, deletes, expirations atomic.Int64
+}
+
+// counting reports whether m was created WithStats.
+func (m *Map[K, V]) counting() bool {
+	return m.cfg != nil && m.cfg.stats
+}
+
+// countLoad counts a Load, which found a value if hit is set.
+func (m *Map[K, V]) countLoad(hit bool) {
+	if m.counting() {
+		m.stats.loads.Add(1)
+		if hit {
+			m.stats.hits.Add(1)
+		}
+	}
+}
+
+// Stats returns the operation counts of a Map created WithStats, and zero
+// Stats for other Maps. The counts are read one at a time, so they may be
+// slightly inconsistent with each other while the Map is in use.
+func (m *Map[K, V]) Stats() Stats {
+	return Stats{
+		Loads:       m.stats.loads.Load(),
+		Hits:        m.stats.hits.Load(),
+		Stores:      m.stats.stores.Load(),
+		Deletes:     m.stats.deletes.Load(),
+		Expirations: m.stats.expirations.Load(),
+	}
+}
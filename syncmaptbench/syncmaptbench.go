@@ -0,0 +1,143 @@
+// Package syncmaptbench runs a standard set of workloads against concurrent
+// map implementations, so that they can be compared on the same footing.
+//
+// A typical use benchmarks each candidate from a _test.go file:
+//
+//	func BenchmarkMap(b *testing.B) {
+//		syncmaptbench.Run(b, func() syncmapt.Interface[int, int] {
+//			return new(syncmapt.Map[int, int])
+//		})
+//	}
+//
+// and compares the results with benchstat.
+package syncmaptbench
+
+import (
+	"math/rand"
+	"runtime"
+	"sync/atomic"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+)
+
+// A Workload describes a mix of operations on a map of int keys.
+type Workload struct {
+	Name string
+
+	// Keys is the number of distinct keys in use. The map is filled with
+	// all of them before the benchmark starts.
+	Keys int
+
+	// Loads, Stores and Deletes are the relative frequencies of the three
+	// kinds of operation. Deleted keys are stored again by later Stores.
+	Loads, Stores, Deletes int
+
+	// Skew is the exponent of the Zipf distribution keys are drawn from. It
+	// must be 0, for uniformly distributed keys, or greater than 1: the
+	// larger it is, the more operations hit the same few keys.
+	Skew float64
+}
+
+// Workloads is the standard workload matrix run by Run and Benchmark.
+var Workloads = []Workload{
+	{Name: "ReadHeavy", Keys: 1 << 12, Loads: 98, Stores: 1, Deletes: 1},
+	{Name: "WriteHeavy", Keys: 1 << 12, Loads: 10, Stores: 80, Deletes: 10},
+	{Name: "Mixed", Keys: 1 << 12, Loads: 50, Stores: 45, Deletes: 5},
+	{Name: "SkewedReads", Keys: 1 << 16, Loads: 90, Stores: 9, Deletes: 1, Skew: 1.2},
+	{Name: "SkewedWrites", Keys: 1 << 16, Loads: 20, Stores: 70, Deletes: 10, Skew: 1.2},
+}
+
+// Run runs every workload in Workloads as a parallel sub-benchmark of b on a
+// fresh map returned by newMap, reporting allocations.
+func Run(b *testing.B, newMap func() syncmapt.Interface[int, int]) {
+	for _, w := range Workloads {
+		b.Run(w.Name, func(b *testing.B) {
+			RunWorkload(b, w, newMap())
+		})
+	}
+}
+
+// RunWorkload runs w against m from b.RunParallel, reporting allocations.
+func RunWorkload(b *testing.B, w Workload, m syncmapt.Interface[int, int]) {
+	for k := 0; k < w.Keys; k++ {
+		m.Store(k, k)
+	}
+	// Each goroutine replays its own stream of precomputed operations, so
+	// that drawing them is not measured.
+	streams := make([][]op, runtime.GOMAXPROCS(0))
+	for i := range streams {
+		streams[i] = w.ops(rand.New(rand.NewSource(int64(i))), 1<<12)
+	}
+	var next atomic.Int64
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		ops := streams[int(next.Add(1)-1)%len(streams)]
+		for i := 0; pb.Next(); i++ {
+			op := ops[i&(len(ops)-1)]
+			switch op.kind {
+			case opLoad:
+				m.Load(op.key)
+			case opStore:
+				m.Store(op.key, i)
+			case opDelete:
+				m.Delete(op.key)
+			}
+		}
+	})
+}
+
+// A Result is the outcome of running a Workload.
+type Result struct {
+	Workload Workload
+	testing.BenchmarkResult
+}
+
+// Benchmark runs every workload in Workloads on a fresh map returned by
+// newMap and returns the results, for use outside of go test.
+func Benchmark(newMap func() syncmapt.Interface[int, int]) []Result {
+	results := make([]Result, len(Workloads))
+	for i, w := range Workloads {
+		results[i] = Result{w, testing.Benchmark(func(b *testing.B) {
+			RunWorkload(b, w, newMap())
+		})}
+	}
+	return results
+}
+
+type opKind uint8
+
+const (
+	opLoad opKind = iota
+	opStore
+	opDelete
+)
+
+type op struct {
+	kind opKind
+	key  int
+}
+
+// ops returns n operations drawn from w. n must be a power of two.
+func (w Workload) ops(r *rand.Rand, n int) []op {
+	key := func() int { return r.Intn(w.Keys) }
+	if w.Skew > 0 {
+		z := rand.NewZipf(r, w.Skew, 1, uint64(w.Keys-1))
+		key = func() int { return int(z.Uint64()) }
+	}
+	total := w.Loads + w.Stores + w.Deletes
+	ops := make([]op, n)
+	for i := range ops {
+		ops[i].key = key()
+		switch x := r.Intn(total); {
+		case x < w.Loads:
+			ops[i].kind = opLoad
+		case x < w.Loads+w.Stores:
+			ops[i].kind = opStore
+		default:
+			ops[i].kind = opDelete
+		}
+	}
+	return ops
+}
@@ -0,0 +1,147 @@
+package syncmaptbench
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+)
+
+func TestOps(t *testing.T) {
+	for _, w := range Workloads {
+		ops := w.ops(rand.New(rand.NewSource(1)), 1<<14)
+		var counts [3]int
+		hot := 0
+		for _, op := range ops {
+			if op.key < 0 || op.key >= w.Keys {
+				t.Fatalf("%s: key %d out of range", w.Name, op.key)
+			}
+			if op.key < 8 {
+				hot++
+			}
+			counts[op.kind]++
+		}
+		total := w.Loads + w.Stores + w.Deletes
+		for kind, want := range []int{w.Loads, w.Stores, w.Deletes} {
+			got := float64(counts[kind]) / float64(len(ops))
+			if d := got - float64(want)/float64(total); d > 0.02 || d < -0.02 {
+				t.Errorf("%s: op %d makes up %.3f of the ops, want %d/%d", w.Name, kind, got, want, total)
+			}
+		}
+		if skewed := hot > len(ops)/4; skewed != (w.Skew > 0) {
+			t.Errorf("%s: %d of %d ops hit the 8 hottest keys", w.Name, hot, len(ops))
+		}
+	}
+}
+
+func BenchmarkMap(b *testing.B) {
+	Run(b, func() syncmapt.Interface[int, int] { return new(syncmapt.Map[int, int]) })
+}
+
+func BenchmarkShardedMap(b *testing.B) {
+	Run(b, func() syncmapt.Interface[int, int] { return new(syncmapt.ShardedMap[int, int]) })
+}
+
+func BenchmarkMutexMap(b *testing.B) {
+	Run(b, func() syncmapt.Interface[int, int] { return &mutexMap{m: make(map[int]int)} })
+}
+
+func BenchmarkSyncMap(b *testing.B) {
+	Run(b, func() syncmapt.Interface[int, int] { return new(syncMap) })
+}
+
+// syncMap is a sync.Map behind typed methods, as a baseline for the cost of
+// boxing values in interfaces: each Store allocates for the boxed value, and
+// each Load asserts its type.
+type syncMap struct{ m sync.Map }
+
+func (m *syncMap) Load(key int) (int, bool) {
+	v, ok := m.m.Load(key)
+	if !ok {
+		return 0, false
+	}
+	return v.(int), true
+}
+
+func (m *syncMap) Store(key, value int) { m.m.Store(key, value) }
+
+func (m *syncMap) LoadOrStore(key, value int) (int, bool) {
+	v, loaded := m.m.LoadOrStore(key, value)
+	return v.(int), loaded
+}
+
+func (m *syncMap) LoadAndDelete(key int) (int, bool) {
+	v, loaded := m.m.LoadAndDelete(key)
+	if !loaded {
+		return 0, false
+	}
+	return v.(int), true
+}
+
+func (m *syncMap) Delete(key int) { m.m.Delete(key) }
+
+func (m *syncMap) Range(f func(key, value int) bool) {
+	m.m.Range(func(k, v any) bool { return f(k.(int), v.(int)) })
+}
+
+func (m *syncMap) Len() int {
+	n := 0
+	m.m.Range(func(any, any) bool { n++; return true })
+	return n
+}
+
+// mutexMap is a plain map behind a sync.RWMutex, as a baseline.
+type mutexMap struct {
+	mu sync.RWMutex
+	m  map[int]int
+}
+
+func (m *mutexMap) Load(key int) (int, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	v, ok := m.m[key]
+	return v, ok
+}
+
+func (m *mutexMap) Store(key, value int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.m[key] = value
+}
+
+func (m *mutexMap) LoadOrStore(key, value int) (int, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if v, ok := m.m[key]; ok {
+		return v, true
+	}
+	m.m[key] = value
+	return value, false
+}
+
+func (m *mutexMap) LoadAndDelete(key int) (int, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.m[key]
+	delete(m.m, key)
+	return v, ok
+}
+
+func (m *mutexMap) Delete(key int) { m.LoadAndDelete(key) }
+
+func (m *mutexMap) Range(f func(key, value int) bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for k, v := range m.m {
+		if !f(k, v) {
+			return
+		}
+	}
+}
+
+func (m *mutexMap) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.m)
+}
@@ -0,0 +1,55 @@
+// Package syncmaptexpvar publishes the statistics of syncmapt maps with the
+// expvar package, so that they appear as JSON on /debug/vars:
+//
+//	m := syncmapt.New(syncmapt.WithStats[string, *Session]())
+//	syncmaptexpvar.Publish("sessions", m)
+//
+// The published variable is an object with the map's length and, for a map
+// created WithStats, its operation counts and hit ratio:
+//
+//	{"len": 2, "loads": 10, "hits": 8, "misses": 2, "hit_ratio": 0.8,
+//	 "stores": 3, "deletes": 1, "expirations": 0, "evictions": 0}
+package syncmaptexpvar
+
+import (
+	"expvar"
+
+	"github.com/holdno/syncmapt"
+)
+
+// A Source is a map whose statistics can be published, such as a
+// *syncmapt.Map, *syncmapt.BoundedMap or *syncmapt.ExpiringMap.
+type Source interface {
+	Len() int
+	Stats() syncmapt.Stats
+}
+
+// Var returns an expvar.Var reporting the current statistics of m each time
+// it is read. It is not published; see Publish.
+func Var(m Source) expvar.Var {
+	return expvar.Func(func() any {
+		return Snapshot(m)
+	})
+}
+
+// Publish publishes the statistics of m under name. Like expvar.Publish, it
+// panics if name is already registered.
+func Publish(name string, m Source) {
+	expvar.Publish(name, Var(m))
+}
+
+// Snapshot returns the statistics of m in the form they are published in.
+func Snapshot(m Source) map[string]any {
+	s := m.Stats()
+	return map[string]any{
+		"len":         m.Len(),
+		"loads":       s.Loads,
+		"hits":        s.Hits,
+		"misses":      s.Misses(),
+		"hit_ratio":   s.HitRatio(),
+		"stores":      s.Stores,
+		"deletes":     s.Deletes,
+		"expirations": s.Expirations,
+		"evictions":   s.Evictions,
+	}
+}
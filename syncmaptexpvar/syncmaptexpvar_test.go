@@ -0,0 +1,40 @@
+package syncmaptexpvar_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+	"github.com/holdno/syncmapt/syncmaptexpvar"
+)
+
+func TestPublish(t *testing.T) {
+	m := syncmapt.New(syncmapt.WithStats[string, int]())
+	syncmaptexpvar.Publish("syncmaptexpvar_test", m)
+
+	m.Store("a", 1)
+	m.Store("b", 2)
+	m.Store("a", 3)
+	m.Delete("b")
+	m.Load("a")
+	m.Load("b")
+	m.Load("c")
+
+	var got map[string]float64
+	if err := json.Unmarshal([]byte(expvar.Get("syncmaptexpvar_test").String()), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]float64{
+		"len": 1, "loads": 3, "hits": 1, "misses": 2, "hit_ratio": 1.0 / 3,
+		"stores": 3, "deletes": 1, "expirations": 0, "evictions": 0,
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %v, want %v", k, got[k], v)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("published %v, want the keys of %v", got, want)
+	}
+}
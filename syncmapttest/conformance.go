@@ -0,0 +1,231 @@
+package syncmapttest
+
+import (
+	"math/rand"
+	"reflect"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+	"testing/quick"
+
+	"github.com/holdno/syncmapt"
+)
+
+// RunConformance checks that the maps returned by newMap behave like
+// syncmapt.Map: that random sequences of calls return the same results as
+// on a plain map, and that concurrent calls neither lose updates nor make
+// Range visit a key twice. Each check runs as a subtest of t on a fresh map.
+func RunConformance(t *testing.T, newMap func() syncmapt.Interface[string, string]) {
+	t.Run("MatchesModel", func(t *testing.T) {
+		t.Parallel()
+		model := func(calls []mapCall) ([]mapResult, map[string]string, int) {
+			return applyCalls(modelMap{}, calls)
+		}
+		impl := func(calls []mapCall) ([]mapResult, map[string]string, int) {
+			return applyCalls(newMap(), calls)
+		}
+		if err := quick.CheckEqual(model, impl, nil); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("ConcurrentDisjoint", func(t *testing.T) {
+		t.Parallel()
+		concurrentDisjoint(t, newMap())
+	})
+	t.Run("ConcurrentRange", func(t *testing.T) {
+		t.Parallel()
+		concurrentRange(t, newMap())
+	})
+}
+
+// mapCall is a quick.Generator for calls on a syncmapt.Interface.
+type mapCall struct {
+	op   Op
+	k, v string
+}
+
+var mapOps = [...]Op{OpLoad, OpStore, OpLoadOrStore, OpLoadAndDelete, OpDelete}
+
+type mapResult struct {
+	value string
+	ok    bool
+}
+
+func (c mapCall) apply(m syncmapt.Interface[string, string]) mapResult {
+	var r mapResult
+	switch c.op {
+	case OpLoad:
+		r.value, r.ok = m.Load(c.k)
+	case OpStore:
+		m.Store(c.k, c.v)
+	case OpLoadOrStore:
+		r.value, r.ok = m.LoadOrStore(c.k, c.v)
+	case OpLoadAndDelete:
+		r.value, r.ok = m.LoadAndDelete(c.k)
+	case OpDelete:
+		m.Delete(c.k)
+	default:
+		panic("invalid Op")
+	}
+	return r
+}
+
+// randValue returns one of a small set of short strings, so that random
+// calls often hit the same keys.
+func randValue(r *rand.Rand) string {
+	b := make([]byte, r.Intn(3))
+	for i := range b {
+		b[i] = 'a' + byte(r.Intn(8))
+	}
+	return string(b)
+}
+
+func (mapCall) Generate(r *rand.Rand, size int) reflect.Value {
+	c := mapCall{op: mapOps[r.Intn(len(mapOps))], k: randValue(r)}
+	switch c.op {
+	case OpStore, OpLoadOrStore:
+		c.v = randValue(r)
+	}
+	return reflect.ValueOf(c)
+}
+
+func applyCalls(m syncmapt.Interface[string, string], calls []mapCall) (results []mapResult, final map[string]string, length int) {
+	for _, c := range calls {
+		results = append(results, c.apply(m))
+	}
+	return results, Snapshot[string, string](m), m.Len()
+}
+
+// modelMap is the reference the results of calls are compared against.
+type modelMap map[string]string
+
+func (m modelMap) Load(key string) (string, bool) {
+	v, ok := m[key]
+	return v, ok
+}
+
+func (m modelMap) Store(key, value string) { m[key] = value }
+
+func (m modelMap) LoadOrStore(key, value string) (string, bool) {
+	if v, ok := m[key]; ok {
+		return v, true
+	}
+	m[key] = value
+	return value, false
+}
+
+func (m modelMap) LoadAndDelete(key string) (string, bool) {
+	v, ok := m[key]
+	delete(m, key)
+	return v, ok
+}
+
+func (m modelMap) Delete(key string) { delete(m, key) }
+
+func (m modelMap) Range(f func(key, value string) bool) {
+	for k, v := range m {
+		if !f(k, v) {
+			return
+		}
+	}
+}
+
+func (m modelMap) Len() int { return len(m) }
+
+// concurrentDisjoint runs random calls from several goroutines, each on its
+// own keys, and checks that the map ends up holding what each of them
+// expects.
+func concurrentDisjoint(t *testing.T, m syncmapt.Interface[string, string]) {
+	const goroutines, calls = 8, 2000
+	models := make([]modelMap, goroutines)
+	var wg sync.WaitGroup
+	for g := range models {
+		models[g] = modelMap{}
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(int64(g)))
+			prefix := strconv.Itoa(g) + "/"
+			for i := 0; i < calls; i++ {
+				c := mapCall{}.Generate(r, 0).Interface().(mapCall)
+				c.k = prefix + c.k
+				if got, want := c.apply(m), c.apply(models[g]); got != want {
+					t.Errorf("%s(%q) = %v, want %v", c.op, c.k, got, want)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	want := map[string]string{}
+	for _, model := range models {
+		for k, v := range model {
+			want[k] = v
+		}
+	}
+	RequireEqual(t, m, want)
+	if m.Len() != len(want) {
+		t.Errorf("Len() = %d, want %d", m.Len(), len(want))
+	}
+}
+
+// concurrentRange checks that Range visits every key exactly once, and only
+// ever sees stored values, while other goroutines load and store.
+func concurrentRange(t *testing.T, m syncmapt.Interface[string, string]) {
+	const mapSize = 1 << 10
+	for n := 1; n <= mapSize; n++ {
+		m.Store(strconv.Itoa(n), strconv.Itoa(n))
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	defer func() {
+		close(done)
+		wg.Wait()
+	}()
+	for g := runtime.GOMAXPROCS(0); g > 0; g-- {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(int64(g)))
+			for i := 0; ; i++ {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				for n := 1; n < mapSize; n++ {
+					if r.Intn(mapSize) == 0 {
+						m.Store(strconv.Itoa(n), strconv.Itoa(n*i*g))
+					} else {
+						m.Load(strconv.Itoa(n))
+					}
+				}
+			}
+		}(g)
+	}
+
+	iters := 1 << 8
+	if testing.Short() {
+		iters = 16
+	}
+	for ; iters > 0; iters-- {
+		seen := make(map[string]bool, mapSize)
+		m.Range(func(k, v string) bool {
+			n, _ := strconv.Atoi(k)
+			if x, err := strconv.Atoi(v); err != nil || x%n != 0 {
+				t.Fatalf("while storing multiples of %v, Range saw value %q", k, v)
+			}
+			if seen[k] {
+				t.Fatalf("Range visited key %v twice", k)
+			}
+			seen[k] = true
+			return true
+		})
+		if len(seen) != mapSize {
+			t.Fatalf("Range visited %v elements of %v-element map", len(seen), mapSize)
+		}
+	}
+}
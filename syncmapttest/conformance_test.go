@@ -0,0 +1,16 @@
+package syncmapttest_test
+
+import (
+	"testing"
+
+	"github.com/holdno/syncmapt"
+	"github.com/holdno/syncmapt/syncmapttest"
+)
+
+func TestRunConformance(t *testing.T) {
+	t.Run("Recorder", func(t *testing.T) {
+		syncmapttest.RunConformance(t, func() syncmapt.Interface[string, string] {
+			return syncmapttest.NewRecorder[string, string](nil)
+		})
+	})
+}
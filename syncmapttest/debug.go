@@ -0,0 +1,300 @@
+package syncmapttest
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"reflect"
+	"runtime/debug"
+	"sync"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+)
+
+// A MisuseKind classifies a Misuse.
+type MisuseKind string
+
+const (
+	// MutatedAfterStore reports that a value reachable from a stored value
+	// changed after it was stored, which races with concurrent readers.
+	MutatedAfterStore MisuseKind = "value mutated after Store"
+	// UsedAfterClose reports a method call on a map that was closed.
+	UsedAfterClose MisuseKind = "method called after Close"
+)
+
+// A Misuse describes incorrect use of a map detected by a Debug wrapper.
+type Misuse struct {
+	Kind   MisuseKind
+	Method string // method during which the misuse was detected
+	Key    any    // the key involved, if any
+
+	Stack  []byte // stack of the call that detected the misuse
+	Origin []byte // stack of the Store or Close call the misuse relates to
+}
+
+func (m Misuse) Error() string {
+	return fmt.Sprintf("syncmapt misuse: %s (%s, key %v)\n\ndetected at:\n%s\norigin:\n%s",
+		m.Kind, m.Method, m.Key, m.Stack, m.Origin)
+}
+
+// ReportTo returns a report function for NewDebug that fails t for every
+// Misuse.
+func ReportTo(t testing.TB) func(Misuse) {
+	return func(m Misuse) {
+		t.Helper()
+		t.Error(m.Error())
+	}
+}
+
+// Debug is a syncmapt.Interface that forwards to an underlying map while
+// detecting common misuse:
+//
+//   - a value that is changed after being stored, detected when the value is
+//     next observed through Load, LoadOrStore, LoadAndDelete or Range;
+//   - any method called after Close.
+//
+// To keep the checks free of false positives, Debug serializes the operations
+// that observe or change values, allowing only calls to Len to run
+// concurrently, and Range calls f on a snapshot taken before the first call
+// rather than on the live map. It is meant for tests and debugging, not
+// for production use.
+type Debug[K comparable, V any] struct {
+	m      syncmapt.Interface[K, V]
+	report func(Misuse)
+
+	mu     sync.RWMutex
+	stored map[K]storeRecord
+	closed []byte // stack of the Close call, or nil if still open
+}
+
+type storeRecord struct {
+	fingerprint uint64
+	stack       []byte
+}
+
+var _ syncmapt.Interface[string, any] = (*Debug[string, any])(nil)
+
+// NewDebug returns a Debug wrapper around m that calls report for every
+// misuse it detects. If report is nil, a detected Misuse panics.
+func NewDebug[K comparable, V any](m syncmapt.Interface[K, V], report func(Misuse)) *Debug[K, V] {
+	if report == nil {
+		report = func(m Misuse) { panic(m) }
+	}
+	return &Debug[K, V]{m: m, report: report, stored: make(map[K]storeRecord)}
+}
+
+// checkOpen reports a misuse if d is closed. d.mu must be held.
+func (d *Debug[K, V]) checkOpen(method string, key any) {
+	if d.closed != nil {
+		d.report(Misuse{Kind: UsedAfterClose, Method: method, Key: key, Stack: debug.Stack(), Origin: d.closed})
+	}
+}
+
+// check reports a misuse if value differs from what was stored for key.
+// d.mu must be held exclusively.
+func (d *Debug[K, V]) check(method string, key K, value V) {
+	rec, ok := d.stored[key]
+	if ok && rec.fingerprint != fingerprint(value) {
+		d.report(Misuse{Kind: MutatedAfterStore, Method: method, Key: key, Stack: debug.Stack(), Origin: rec.stack})
+		// Report each mutation once.
+		d.stored[key] = storeRecord{fingerprint(value), rec.stack}
+	}
+}
+
+// remember records value as the value stored for key. d.mu must be held
+// exclusively.
+func (d *Debug[K, V]) remember(key K, value V) {
+	d.stored[key] = storeRecord{fingerprint(value), debug.Stack()}
+}
+
+func (d *Debug[K, V]) Load(key K) (value V, ok bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.checkOpen("Load", key)
+	value, ok = d.m.Load(key)
+	if ok {
+		d.check("Load", key, value)
+	}
+	return value, ok
+}
+
+func (d *Debug[K, V]) Store(key K, value V) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.checkOpen("Store", key)
+	d.m.Store(key, value)
+	d.remember(key, value)
+}
+
+func (d *Debug[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.checkOpen("LoadOrStore", key)
+	actual, loaded = d.m.LoadOrStore(key, value)
+	if loaded {
+		d.check("LoadOrStore", key, actual)
+	} else {
+		d.remember(key, value)
+	}
+	return actual, loaded
+}
+
+func (d *Debug[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.checkOpen("LoadAndDelete", key)
+	value, loaded = d.m.LoadAndDelete(key)
+	if loaded {
+		d.check("LoadAndDelete", key, value)
+	}
+	delete(d.stored, key)
+	return value, loaded
+}
+
+func (d *Debug[K, V]) Delete(key K) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.checkOpen("Delete", key)
+	d.m.Delete(key)
+	delete(d.stored, key)
+}
+
+func (d *Debug[K, V]) Range(f func(key K, value V) bool) {
+	for _, e := range d.snapshot() {
+		if !f(e.Key, e.Value) {
+			break
+		}
+	}
+}
+
+// snapshot returns the contents of the underlying map, checking each value.
+func (d *Debug[K, V]) snapshot() []syncmapt.Pair[K, V] {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.checkOpen("Range", nil)
+	var pairs []syncmapt.Pair[K, V]
+	d.m.Range(func(k K, v V) bool {
+		d.check("Range", k, v)
+		pairs = append(pairs, syncmapt.Pair[K, V]{Key: k, Value: v})
+		return true
+	})
+	return pairs
+}
+
+func (d *Debug[K, V]) Len() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	d.checkOpen("Len", nil)
+	return d.m.Len()
+}
+
+// Close marks d as closed, so that any later method call is reported as a
+// misuse, and closes the underlying map if it implements io.Closer.
+// Closing d twice is also reported.
+func (d *Debug[K, V]) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.checkOpen("Close", nil)
+	if d.closed != nil {
+		return nil
+	}
+	d.closed = debug.Stack()
+	if c, ok := d.m.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// fingerprint returns a hash of everything reachable from v.
+func fingerprint(v any) uint64 {
+	h := fnv.New64a()
+	w := fingerprinter{visited: make(map[uintptr]bool)}
+	w.walk(reflect.ValueOf(v))
+	h.Write(w.buf)
+	return h.Sum64()
+}
+
+type fingerprinter struct {
+	buf     []byte
+	visited map[uintptr]bool
+}
+
+func (w *fingerprinter) uint(x uint64) {
+	for i := 0; i < 8; i++ {
+		w.buf = append(w.buf, byte(x>>(8*i)))
+	}
+}
+
+func (w *fingerprinter) walk(v reflect.Value) {
+	if !v.IsValid() {
+		w.buf = append(w.buf, 0)
+		return
+	}
+	w.buf = append(w.buf, byte(v.Kind()))
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			w.uint(1)
+		} else {
+			w.uint(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		w.uint(uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		w.uint(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		w.uint(math.Float64bits(v.Float()))
+	case reflect.Complex64, reflect.Complex128:
+		w.uint(math.Float64bits(real(v.Complex())))
+		w.uint(math.Float64bits(imag(v.Complex())))
+	case reflect.String:
+		w.uint(uint64(v.Len()))
+		w.buf = append(w.buf, v.String()...)
+	case reflect.Pointer:
+		if v.IsNil() {
+			w.uint(0)
+			return
+		}
+		p := v.Pointer()
+		w.uint(uint64(p))
+		if w.visited[p] {
+			return
+		}
+		w.visited[p] = true
+		w.walk(v.Elem())
+	case reflect.Interface:
+		w.walk(v.Elem())
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			w.walk(v.Field(i))
+		}
+	case reflect.Slice, reflect.Array:
+		w.uint(uint64(v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			w.walk(v.Index(i))
+		}
+	case reflect.Map:
+		// Map iteration order is random: combine the entries commutatively.
+		var sum uint64
+		iter := v.MapRange()
+		for iter.Next() {
+			e := fingerprinter{visited: w.visited}
+			e.walk(iter.Key())
+			e.walk(iter.Value())
+			h := fnv.New64a()
+			h.Write(e.buf)
+			sum += h.Sum64()
+		}
+		w.uint(uint64(v.Len()))
+		w.uint(sum)
+	default:
+		// Chan, Func and UnsafePointer are compared by identity.
+		if v.IsNil() {
+			w.uint(0)
+		} else {
+			w.uint(uint64(v.Pointer()))
+		}
+	}
+}
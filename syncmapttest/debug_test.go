@@ -0,0 +1,86 @@
+package syncmapttest_test
+
+import (
+	"testing"
+
+	"github.com/holdno/syncmapt"
+	"github.com/holdno/syncmapt/syncmapttest"
+)
+
+func TestDebugMutatedAfterStore(t *testing.T) {
+	type session struct {
+		User  string
+		Roles []string
+	}
+
+	var misuses []syncmapttest.Misuse
+	d := syncmapttest.NewDebug[string, *session](new(syncmapt.Map[string, *session]), func(m syncmapttest.Misuse) {
+		misuses = append(misuses, m)
+	})
+
+	s := &session{User: "alice", Roles: []string{"reader"}}
+	d.Store("s1", s)
+	if _, ok := d.Load("s1"); !ok || len(misuses) != 0 {
+		t.Fatal("unexpected misuse before mutation:", misuses)
+	}
+
+	s.Roles[0] = "admin"
+	d.Load("s1")
+	if len(misuses) != 1 || misuses[0].Kind != syncmapttest.MutatedAfterStore || misuses[0].Key != "s1" {
+		t.Fatalf("want one MutatedAfterStore misuse for s1, got %v", misuses)
+	}
+	if len(misuses[0].Origin) == 0 {
+		t.Fatal("want the Store stack trace as origin")
+	}
+
+	// Storing a fresh value is fine.
+	d.Store("s1", &session{User: "alice", Roles: []string{"admin"}})
+	d.Range(func(string, *session) bool { return true })
+	if len(misuses) != 1 {
+		t.Fatal("unexpected misuse after re-Store:", misuses[1:])
+	}
+}
+
+func TestDebugUsedAfterClose(t *testing.T) {
+	var misuses []syncmapttest.Misuse
+	d := syncmapttest.NewDebug[string, int](new(syncmapt.Map[string, int]), func(m syncmapttest.Misuse) {
+		misuses = append(misuses, m)
+	})
+
+	d.Store("a", 1)
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+	d.Load("a")
+	d.Len()
+
+	if len(misuses) != 2 {
+		t.Fatalf("want 2 misuses, got %v", misuses)
+	}
+	for _, m := range misuses {
+		if m.Kind != syncmapttest.UsedAfterClose {
+			t.Fatal("unexpected", m.Kind)
+		}
+	}
+}
+
+func TestDebugPanicInRange(t *testing.T) {
+	v := []int{1}
+	d := syncmapttest.NewDebug[string, []int](new(syncmapt.Map[string, []int]), nil)
+	d.Store("a", v)
+	v[0] = 2
+	func() {
+		defer func() {
+			if _, ok := recover().(syncmapttest.Misuse); !ok {
+				t.Fatal("Range of a mutated value did not panic with a Misuse")
+			}
+		}()
+		d.Range(func(string, []int) bool { return true })
+	}()
+
+	// The panic released the lock.
+	d.Store("a", []int{3})
+	if v, _ := d.Load("a"); v[0] != 3 {
+		t.Fatalf("Load(a) = %v, want [3]", v)
+	}
+}
@@ -0,0 +1,170 @@
+package syncmapttest
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/holdno/syncmapt"
+)
+
+// An Op names a method of syncmapt.Interface.
+type Op string
+
+const (
+	OpLoad          Op = "Load"
+	OpStore         Op = "Store"
+	OpLoadOrStore   Op = "LoadOrStore"
+	OpLoadAndDelete Op = "LoadAndDelete"
+	OpDelete        Op = "Delete"
+	OpRange         Op = "Range"
+	OpLen           Op = "Len"
+)
+
+// A Call is one recorded operation on a Recorder.
+type Call[K comparable, V any] struct {
+	Op  Op
+	Key K // zero for Range and Len
+
+	// Value is the value passed to Store or LoadOrStore, or the value
+	// returned by Load and LoadAndDelete.
+	Value V
+	// OK is the boolean result of Load, LoadOrStore and LoadAndDelete.
+	OK bool
+
+	// Goroutine is the ID of the calling goroutine, or 0 on runtimes that do
+	// not report goroutine IDs in stack traces, such as TinyGo.
+	Goroutine uint64
+	Time      time.Time
+}
+
+// A Recorder is a syncmapt.Interface that records every call made on it
+// before forwarding it to an underlying map.
+//
+// A Recorder is safe for concurrent use.
+type Recorder[K comparable, V any] struct {
+	m syncmapt.Interface[K, V]
+
+	mu    sync.Mutex
+	calls []Call[K, V]
+}
+
+var _ syncmapt.Interface[string, any] = (*Recorder[string, any])(nil)
+
+// NewRecorder returns a Recorder forwarding to m. If m is nil, the Recorder
+// forwards to a new, empty syncmapt.Map.
+func NewRecorder[K comparable, V any](m syncmapt.Interface[K, V]) *Recorder[K, V] {
+	if m == nil {
+		m = new(syncmapt.Map[K, V])
+	}
+	return &Recorder[K, V]{m: m}
+}
+
+func (r *Recorder[K, V]) record(c Call[K, V]) {
+	c.Goroutine = goroutineID()
+	c.Time = time.Now()
+	r.mu.Lock()
+	r.calls = append(r.calls, c)
+	r.mu.Unlock()
+}
+
+func (r *Recorder[K, V]) Load(key K) (value V, ok bool) {
+	value, ok = r.m.Load(key)
+	r.record(Call[K, V]{Op: OpLoad, Key: key, Value: value, OK: ok})
+	return value, ok
+}
+
+func (r *Recorder[K, V]) Store(key K, value V) {
+	r.m.Store(key, value)
+	r.record(Call[K, V]{Op: OpStore, Key: key, Value: value})
+}
+
+func (r *Recorder[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	actual, loaded = r.m.LoadOrStore(key, value)
+	r.record(Call[K, V]{Op: OpLoadOrStore, Key: key, Value: value, OK: loaded})
+	return actual, loaded
+}
+
+func (r *Recorder[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	value, loaded = r.m.LoadAndDelete(key)
+	r.record(Call[K, V]{Op: OpLoadAndDelete, Key: key, Value: value, OK: loaded})
+	return value, loaded
+}
+
+func (r *Recorder[K, V]) Delete(key K) {
+	r.m.Delete(key)
+	r.record(Call[K, V]{Op: OpDelete, Key: key})
+}
+
+func (r *Recorder[K, V]) Range(f func(key K, value V) bool) {
+	r.record(Call[K, V]{Op: OpRange})
+	r.m.Range(f)
+}
+
+func (r *Recorder[K, V]) Len() int {
+	r.record(Call[K, V]{Op: OpLen})
+	return r.m.Len()
+}
+
+// Calls returns the calls recorded so far, in the order they completed.
+func (r *Recorder[K, V]) Calls() []Call[K, V] {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call[K, V](nil), r.calls...)
+}
+
+// CallsFor returns the recorded calls of op on key, in the order they
+// completed.
+func (r *Recorder[K, V]) CallsFor(op Op, key K) []Call[K, V] {
+	var calls []Call[K, V]
+	for _, c := range r.Calls() {
+		if c.Op == op && c.Key == key {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// Called reports whether op was called on key.
+func (r *Recorder[K, V]) Called(op Op, key K) bool {
+	return len(r.CallsFor(op, key)) > 0
+}
+
+// Reset discards the recorded calls.
+func (r *Recorder[K, V]) Reset() {
+	r.mu.Lock()
+	r.calls = nil
+	r.mu.Unlock()
+}
+
+// AssertCalled reports a test error unless op was called on key.
+func (r *Recorder[K, V]) AssertCalled(t testing.TB, op Op, key K) {
+	t.Helper()
+	if !r.Called(op, key) {
+		t.Errorf("%s was not called for key %v", op, key)
+	}
+}
+
+// AssertNotCalled reports a test error if op was called on key.
+func (r *Recorder[K, V]) AssertNotCalled(t testing.TB, op Op, key K) {
+	t.Helper()
+	if n := len(r.CallsFor(op, key)); n > 0 {
+		t.Errorf("%s was called %d times for key %v", op, n, key)
+	}
+}
+
+// goroutineID returns the ID of the calling goroutine, parsed from the first
+// line of its stack trace ("goroutine 123 [running]:").
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
@@ -0,0 +1,61 @@
+package syncmapttest_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/holdno/syncmapt/syncmapttest"
+)
+
+func TestRecorder(t *testing.T) {
+	r := syncmapttest.NewRecorder[string, int](nil)
+
+	r.Store("a", 1)
+	if v, ok := r.Load("a"); !ok || v != 1 {
+		t.Fatalf("Load = %v, %v; want 1, true", v, ok)
+	}
+	r.Delete("a")
+
+	r.AssertCalled(t, syncmapttest.OpStore, "a")
+	r.AssertCalled(t, syncmapttest.OpDelete, "a")
+	r.AssertNotCalled(t, syncmapttest.OpDelete, "b")
+
+	calls := r.Calls()
+	if len(calls) != 3 {
+		t.Fatal("unexpected", len(calls))
+	}
+	if c := calls[1]; c.Op != syncmapttest.OpLoad || c.Value != 1 || !c.OK {
+		t.Fatalf("unexpected Load call %+v", c)
+	}
+	if calls[0].Goroutine == 0 || calls[0].Time.IsZero() {
+		t.Fatalf("want goroutine and time recorded, got %+v", calls[0])
+	}
+
+	r.Reset()
+	if len(r.Calls()) != 0 {
+		t.Fatal("want no calls after Reset")
+	}
+}
+
+func TestRecorderConcurrent(t *testing.T) {
+	r := syncmapttest.NewRecorder[int, int](nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r.Store(i, i)
+		}(i)
+	}
+	wg.Wait()
+
+	goroutines := make(map[uint64]bool)
+	for _, c := range r.Calls() {
+		goroutines[c.Goroutine] = true
+	}
+	if len(goroutines) != 8 {
+		t.Fatal("want a distinct goroutine per call, got", len(goroutines))
+	}
+	syncmapttest.RequireEqual(t, r, map[int]int{0: 0, 1: 1, 2: 2, 3: 3, 4: 4, 5: 5, 6: 6, 7: 7})
+}
@@ -0,0 +1,87 @@
+// Package syncmapttest provides helpers for tests that use syncmapt maps.
+package syncmapttest
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+)
+
+// A Ranger is a map whose entries can be visited with Range. Every map type
+// in syncmapt is a Ranger.
+type Ranger[K comparable, V any] interface {
+	Range(f func(key K, value V) bool)
+}
+
+// A Storer is a map that entries can be stored into.
+type Storer[K comparable, V any] interface {
+	Store(key K, value V)
+}
+
+// New returns a new syncmapt.Map holding entries.
+func New[K comparable, V any](entries map[K]V) *syncmapt.Map[K, V] {
+	m := new(syncmapt.Map[K, V])
+	Seed[K, V](m, entries)
+	return m
+}
+
+// Seed stores every entry of entries into m.
+func Seed[K comparable, V any](m Storer[K, V], entries map[K]V) {
+	for k, v := range entries {
+		m.Store(k, v)
+	}
+}
+
+// Snapshot returns the contents of m, as observed by a single call to Range,
+// as a plain map.
+func Snapshot[K comparable, V any](m Ranger[K, V]) map[K]V {
+	got := make(map[K]V)
+	m.Range(func(k K, v V) bool {
+		got[k] = v
+		return true
+	})
+	return got
+}
+
+// RequireEqual fails the test immediately unless m holds exactly the entries
+// in want. Values are compared with reflect.DeepEqual. On mismatch, the
+// failure message lists every missing, unexpected and differing key.
+func RequireEqual[K comparable, V any](t testing.TB, m Ranger[K, V], want map[K]V) {
+	t.Helper()
+	if d := Diff(Snapshot(m), want); d != "" {
+		t.Fatalf("map contents mismatch (-got +want):\n%s", d)
+	}
+}
+
+// Diff returns a human-readable description of the differences between got
+// and want, or "" if they hold the same entries. Lines are sorted by key.
+func Diff[K comparable, V any](got, want map[K]V) string {
+	type line struct{ key, text string }
+	var lines []line
+	for k, g := range got {
+		w, ok := want[k]
+		switch {
+		case !ok:
+			lines = append(lines, line{fmt.Sprint(k), fmt.Sprintf("-%v: %v", k, g)})
+		case !reflect.DeepEqual(g, w):
+			lines = append(lines, line{fmt.Sprint(k), fmt.Sprintf("-%v: %v\n+%v: %v", k, g, k, w)})
+		}
+	}
+	for k, w := range want {
+		if _, ok := got[k]; !ok {
+			lines = append(lines, line{fmt.Sprint(k), fmt.Sprintf("+%v: %v", k, w)})
+		}
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i].key < lines[j].key })
+
+	var b strings.Builder
+	for _, l := range lines {
+		b.WriteString(l.text)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
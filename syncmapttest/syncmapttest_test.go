@@ -0,0 +1,32 @@
+package syncmapttest_test
+
+import (
+	"testing"
+
+	"github.com/holdno/syncmapt/syncmapttest"
+)
+
+func TestRequireEqual(t *testing.T) {
+	m := syncmapttest.New(map[string]int{"a": 1, "b": 2})
+	syncmapttest.RequireEqual(t, m, map[string]int{"a": 1, "b": 2})
+
+	m.Store("c", 3)
+	m.Delete("a")
+	syncmapttest.RequireEqual(t, m, map[string]int{"b": 2, "c": 3})
+}
+
+func TestDiff(t *testing.T) {
+	got := map[string]int{"a": 1, "b": 2, "c": 3}
+	want := map[string]int{"b": 2, "c": 4, "d": 5}
+
+	const diff = "-a: 1\n" +
+		"-c: 3\n+c: 4\n" +
+		"+d: 5\n"
+	if d := syncmapttest.Diff(got, want); d != diff {
+		t.Fatalf("Diff =\n%s\nwant\n%s", d, diff)
+	}
+
+	if d := syncmapttest.Diff(want, want); d != "" {
+		t.Fatal("want empty diff for equal maps, got", d)
+	}
+}
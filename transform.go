@@ -0,0 +1,81 @@
+package syncmapt
+
+// This file contains helpers that derive a result from the entries of a map,
+// visited as by ToMap: for a map created WithSnapshots they read a Snapshot,
+// the contents of a single point in time; otherwise they have the consistency
+// of Range, and may observe a state the map was never in if it is modified
+// concurrently. The functions passed to them may call methods of the map.
+
+// Filter returns a new Map with the configuration of m, holding the entries
+// of m for which keep returns true. Without WithSnapshots, an entry stored or
+// deleted concurrently may or may not be considered.
+func (m *Map[K, V]) Filter(keep func(key K, value V) bool) *Map[K, V] {
+	var kept []Pair[K, V]
+	m.rangeSnapshot(func(k K, v V) bool {
+		if keep(k, v) {
+			kept = append(kept, Pair[K, V]{k, v})
+		}
+		return true
+	})
+	f := &Map[K, V]{cfg: m.cfg}
+	f.ver.on = m.ver.on
+	f.adopt(kept)
+	return f
+}
+
+// MapValues returns a new Map holding the keys of m with the values f returns
+// for them. The new Map applies the key transform of m, if any; it has no
+// other Options. Without WithSnapshots on m, the keys are those Range would
+// visit, so the result may mix entries from before and after a concurrent
+// update.
+func MapValues[K comparable, V, U any](m *Map[K, V], f func(key K, value V) U) *Map[K, U] {
+	var pairs []Pair[K, U]
+	m.rangeSnapshot(func(k K, v V) bool {
+		pairs = append(pairs, Pair[K, U]{k, f(k, v)})
+		return true
+	})
+	u := new(Map[K, U])
+	if m.cfg != nil && m.cfg.keyTransform != nil {
+		u.cfg = newConfig([]Option[K, U]{WithKeyTransform[K, U](m.cfg.keyTransform)})
+	}
+	u.adopt(pairs)
+	return u
+}
+
+// Reduce returns the result of calling f for each entry of m, each time with
+// the result of the previous call, starting from init. The entries are
+// visited in an indeterminate order, so f should not depend on it. Only for a
+// map created WithSnapshots is the result that of a single state of m;
+// otherwise concurrent updates may or may not be reflected, as with Range.
+func Reduce[K comparable, V, A any](m *Map[K, V], init A, f func(acc A, key K, value V) A) A {
+	acc := init
+	m.rangeSnapshot(func(k K, v V) bool {
+		acc = f(acc, k, v)
+		return true
+	})
+	return acc
+}
+
+// Equal reports whether a and b hold the same keys, with values that are
+// equal according to eq. The maps are read one after the other, a Snapshot of
+// each for maps created WithSnapshots. A map without WithSnapshots is read as
+// by Range, so while either map is modified concurrently, Equal may report
+// the result for a state that never existed.
+func Equal[K comparable, V any](a, b *Map[K, V], eq func(x, y V) bool) bool {
+	if a == b {
+		return true
+	}
+	contents := make(map[K]V)
+	a.rangeSnapshot(func(k K, v V) bool {
+		contents[k] = v
+		return true
+	})
+	n, equal := 0, true
+	b.rangeSnapshot(func(k K, y V) bool {
+		x, ok := contents[k]
+		n++
+		equal = ok && eq(x, y)
+		return equal
+	})
+	return equal && n == len(contents)
+}
@@ -0,0 +1,103 @@
+package syncmapt_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/holdno/syncmapt"
+)
+
+func TestFilter(t *testing.T) {
+	for _, snapshots := range []bool{false, true} {
+		opts := []syncmapt.Option[string, time.Duration]{
+			syncmapt.WithKeyTransform[string, time.Duration](strings.ToLower),
+		}
+		if snapshots {
+			opts = append(opts, syncmapt.WithSnapshots[string, time.Duration]())
+		}
+		sessions := syncmapt.New(opts...)
+		sessions.Store("Alice", 15*time.Minute)
+		sessions.Store("Bob", time.Minute)
+		sessions.Store("Carol", time.Hour)
+
+		idle := sessions.Filter(func(_ string, d time.Duration) bool {
+			if snapshots {
+				sessions.Delete("Carol") // does not affect the filtered Snapshot
+			}
+			return d > 10*time.Minute
+		})
+		if idle.Len() != 2 {
+			t.Fatalf("snapshots=%v: Filter kept %d entries, want 2", snapshots, idle.Len())
+		}
+		if _, ok := idle.Load("ALICE"); !ok {
+			t.Fatalf("snapshots=%v: Filter result does not keep the key transform", snapshots)
+		}
+		idle.Store("Dave", 0)
+		sessions.Delete("Carol")
+		if idle.Len() != 3 || sessions.Len() != 2 {
+			t.Fatalf("snapshots=%v: Filter result shares storage with the map", snapshots)
+		}
+	}
+}
+
+func TestMapValues(t *testing.T) {
+	type user struct {
+		name  string
+		email string
+	}
+	users := syncmapt.New(syncmapt.WithKeyTransform[string, user](strings.ToLower))
+	users.Store("ALICE", user{"Alice", "alice@example.com"})
+	users.Store("bob", user{"Bob", "bob@example.com"})
+
+	emails := syncmapt.MapValues(users, func(_ string, u user) string { return u.email })
+	if emails.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", emails.Len())
+	}
+	if e, ok := emails.Load("Alice"); !ok || e != "alice@example.com" {
+		t.Fatalf("Load(Alice) = %q, %v", e, ok)
+	}
+	if syncmapt.MapValues(new(syncmapt.Map[int, int]), func(int, int) bool { return true }).Len() != 0 {
+		t.Fatal("MapValues of an empty map is not empty")
+	}
+}
+
+func TestReduce(t *testing.T) {
+	m := new(syncmapt.Map[string, int])
+	for i, k := range []string{"a", "b", "c", "d"} {
+		m.Store(k, i+1)
+	}
+	if sum := syncmapt.Reduce(m, 0, func(acc int, _ string, v int) int { return acc + v }); sum != 10 {
+		t.Fatalf("sum = %d, want 10", sum)
+	}
+	longest := syncmapt.Reduce(m, "", func(acc, k string, v int) string {
+		if v > 2 {
+			acc += k
+		}
+		return acc
+	})
+	if len(longest) != 2 || !strings.Contains(longest, "c") || !strings.Contains(longest, "d") {
+		t.Fatalf("concatenated keys = %q, want c and d", longest)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	eq := func(x, y int) bool { return x == y }
+	a := syncmapt.NewFromMap(map[string]int{"a": 1, "b": 2})
+	b := syncmapt.New(syncmapt.WithSnapshots[string, int]())
+	b.Store("b", 2)
+	if syncmapt.Equal(a, b, eq) || syncmapt.Equal(b, a, eq) {
+		t.Fatal("maps with different keys are equal")
+	}
+	b.Store("a", 1)
+	if !syncmapt.Equal(a, b, eq) || !syncmapt.Equal(b, a, eq) || !syncmapt.Equal(a, a, eq) {
+		t.Fatal("maps with the same entries are not equal")
+	}
+	b.Store("a", 3)
+	if syncmapt.Equal(a, b, eq) {
+		t.Fatal("maps with different values are equal")
+	}
+	if !syncmapt.Equal(a, b, func(x, y int) bool { return x%2 == y%2 }) {
+		t.Fatal("Equal does not compare values with eq")
+	}
+}
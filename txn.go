@@ -0,0 +1,152 @@
+package syncmapt
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// A Txn is a transaction over a fixed set of keys of a Map, passed to the
+// function run by Map.Txn. It reads the keys and buffers writes to them,
+// which Map.Txn applies together when the function succeeds.
+//
+// A Txn may only be used with the keys given to Map.Txn, and only until the
+// function it was passed to returns.
+type Txn[K comparable, V any] struct {
+	m      *Map[K, V]
+	keys   map[K]bool
+	writes map[K]txnWrite[V]
+	order  []K // the keys of writes, in the order of their first write
+	done   bool
+}
+
+// A txnWrite is a write buffered by a Txn: a value to store, or a delete.
+type txnWrite[V any] struct {
+	value V
+	del   bool
+}
+
+// Txn locks keys as by LockKey, calls f with a Txn over them, and if f
+// returns nil, applies the stores and deletes f made through the Txn as one
+// atomic change: a concurrent operation on the map, including a lock-free
+// Load, observes either none of them or all of them. If f returns an error,
+// nothing is applied and Txn returns the error. If the Map is closed, Txn
+// returns ErrClosed without calling f.
+//
+// The keys are locked in a fixed order, so transactions over overlapping
+// keys never deadlock and run one after the other. As with LockKey, methods
+// of the map other than LockKey, WithLock and Txn are not blocked by the
+// locks: a value stored by another method after f has read it is
+// overwritten by the Txn.
+//
+//	err := m.Txn([]string{from, to}, func(tx *syncmapt.Txn[string, int]) error {
+//		a, _ := tx.Load(from)
+//		if a < amount {
+//			return errInsufficientFunds
+//		}
+//		b, _ := tx.Load(to)
+//		tx.Store(from, a-amount)
+//		tx.Store(to, b+amount)
+//		return nil
+//	})
+func (m *Map[K, V]) Txn(keys []K, f func(tx *Txn[K, V]) error) error {
+	if m.Closed() {
+		return ErrClosed
+	}
+	tx := &Txn[K, V]{m: m, keys: make(map[K]bool, len(keys))}
+	locked := make([]K, len(keys))
+	for i, k := range keys {
+		locked[i] = m.key(k)
+		tx.keys[locked[i]] = true
+	}
+	unlock := m.lockKeys(locked)
+	defer unlock()
+
+	err := f(tx)
+	tx.done = true
+	if err != nil || len(tx.order) == 0 {
+		return err
+	}
+	m.commit(tx)
+	return nil
+}
+
+// commit applies the writes of tx.
+func (m *Map[K, V]) commit(tx *Txn[K, V]) {
+	entries := make([]*entry[V], len(tx.order))
+	prev := make([]unsafe.Pointer, len(tx.order))
+	olds := make([]*V, len(tx.order))
+
+	m.mu.Lock()
+	// Mark every entry as moving before publishing any new value, so that an
+	// operation that observes one new value waits for all the others.
+	for i, k := range tx.order {
+		e := m.entryLocked(k)
+		p := atomic.LoadPointer(&e.p)
+		for !atomic.CompareAndSwapPointer(&e.p, p, moving) {
+			p = atomic.LoadPointer(&e.p)
+		}
+		entries[i], prev[i] = e, p
+		olds[i], _ = valueOf[V](&m.ver, p)
+	}
+	for i, k := range tx.order {
+		w := tx.writes[k]
+		nv := tombstone[V](&m.ver)
+		if !w.del {
+			nv = newValue(&m.ver, w.value)
+		}
+		entries[i].release(&m.ver, prev[i], nv)
+	}
+	m.mu.Unlock()
+
+	for i, k := range tx.order {
+		switch w := tx.writes[k]; {
+		case !w.del:
+			m.stored(k, w.value)
+		case olds[i] != nil:
+			m.deleted(k, *olds[i])
+		}
+	}
+}
+
+// check panics if tx cannot be used with key, which must already have been
+// transformed.
+func (tx *Txn[K, V]) check(key K) {
+	if tx.done {
+		panic("syncmapt: Txn used after its function returned")
+	}
+	if !tx.keys[key] {
+		panic("syncmapt: Txn used with a key it was not created with")
+	}
+}
+
+// Load returns the value for key as seen by the transaction: the last value
+// it stored for key, or what the map holds if it has not written key.
+func (tx *Txn[K, V]) Load(key K) (value V, ok bool) {
+	key = tx.m.key(key)
+	tx.check(key)
+	if w, written := tx.writes[key]; written {
+		return w.value, !w.del
+	}
+	return tx.m.load(key)
+}
+
+// Store sets the value for key when the transaction is applied.
+func (tx *Txn[K, V]) Store(key K, value V) {
+	tx.write(tx.m.key(key), txnWrite[V]{value: value})
+}
+
+// Delete deletes the value for key when the transaction is applied.
+func (tx *Txn[K, V]) Delete(key K) {
+	tx.write(tx.m.key(key), txnWrite[V]{del: true})
+}
+
+func (tx *Txn[K, V]) write(key K, w txnWrite[V]) {
+	tx.check(key)
+	if tx.writes == nil {
+		tx.writes = make(map[K]txnWrite[V])
+	}
+	if _, ok := tx.writes[key]; !ok {
+		tx.order = append(tx.order, key)
+	}
+	tx.writes[key] = w
+}
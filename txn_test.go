@@ -0,0 +1,144 @@
+package syncmapt_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+)
+
+func TestTxn(t *testing.T) {
+	m := syncmapt.NewFromMap(map[string]int{"from": 10, "to": 0})
+	errShort := errors.New("insufficient funds")
+	transfer := func(amount int) error {
+		return m.Txn([]string{"from", "to"}, func(tx *syncmapt.Txn[string, int]) error {
+			a, _ := tx.Load("from")
+			if a < amount {
+				return errShort
+			}
+			b, _ := tx.Load("to")
+			tx.Store("from", a-amount)
+			tx.Store("to", b+amount)
+			if v, _ := tx.Load("to"); v != b+amount {
+				t.Errorf("Load after Store in a Txn = %d, want %d", v, b+amount)
+			}
+			return nil
+		})
+	}
+	if err := transfer(4); err != nil {
+		t.Fatal(err)
+	}
+	if err := transfer(7); err != errShort {
+		t.Fatalf("transfer of 7 out of 6 = %v, want %v", err, errShort)
+	}
+	if a, _ := m.Load("from"); a != 6 {
+		t.Fatalf("from = %d after a failed transfer, want 6", a)
+	}
+
+	err := m.Txn([]string{"from", "gone"}, func(tx *syncmapt.Txn[string, int]) error {
+		tx.Delete("from")
+		if _, ok := tx.Load("from"); ok {
+			t.Error("Load after Delete in a Txn found a value")
+		}
+		tx.Store("gone", 1)
+		tx.Delete("gone")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Len() != 1 {
+		t.Fatalf("Len() = %d after deleting from and gone, want 1", m.Len())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("using an undeclared key in a Txn did not panic")
+		}
+	}()
+	m.Txn([]string{"to"}, func(tx *syncmapt.Txn[string, int]) error {
+		tx.Load("from")
+		return nil
+	})
+}
+
+func TestTxnAtomic(t *testing.T) {
+	for _, snapshots := range []bool{false, true} {
+		var opts []syncmapt.Option[string, int]
+		if snapshots {
+			opts = append(opts, syncmapt.WithSnapshots[string, int]())
+		}
+		m := syncmapt.NewFromMap(map[string]int{"a": 0, "b": 0}, opts...)
+
+		var stop atomic.Bool
+		var wg sync.WaitGroup
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for !stop.Load() {
+					// A value read after another is never older than it.
+					first, second := "a", "b"
+					if g%2 == 1 {
+						first, second = second, first
+					}
+					x, _ := m.Load(first)
+					y, _ := m.Load(second)
+					if y < x {
+						t.Errorf("read %s = %d, then %s = %d", first, x, second, y)
+						return
+					}
+					if snapshots {
+						s := m.Snapshot()
+						x, _ := s.Load("a")
+						y, _ := s.Load("b")
+						if x != y {
+							t.Errorf("Snapshot holds a = %d, b = %d", x, y)
+							return
+						}
+					}
+				}
+			}()
+		}
+		for i := 0; i < 2000; i++ {
+			m.Txn([]string{"a", "b"}, func(tx *syncmapt.Txn[string, int]) error {
+				a, _ := tx.Load("a")
+				tx.Store("a", a+1)
+				tx.Store("b", a+1)
+				return nil
+			})
+		}
+		stop.Store(true)
+		wg.Wait()
+	}
+}
+
+func TestTxnOverlapping(t *testing.T) {
+	m := new(syncmapt.Map[int, int])
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Transactions over overlapping keys, declared in different orders.
+			keys := []int{g % 3, (g + 1) % 3}
+			for i := 0; i < 200; i++ {
+				m.Txn(keys, func(tx *syncmapt.Txn[int, int]) error {
+					for _, k := range keys {
+						v, _ := tx.Load(k)
+						tx.Store(k, v+1)
+					}
+					return nil
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	total := 0
+	m.Range(func(_, v int) bool { total += v; return true })
+	if total != 8*200*2 {
+		t.Fatalf("total = %d, want %d: an increment was lost", total, 8*200*2)
+	}
+}
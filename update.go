@@ -0,0 +1,74 @@
+package syncmapt
+
+// Compute atomically replaces the value for key with the result of f, or
+// deletes it. f is called with the current value, if loaded is true, or the
+// zero value, and returns the new value, or delete set to delete the key. The
+// results are the value stored for key afterwards, with ok false if there is
+// none.
+//
+// Compute is a compare-and-swap loop: if the value for key changes between
+// the call to f and the publication of its result, f is called again with the
+// new value. f must therefore not have side effects, must not modify old,
+// which other goroutines may be reading, and may be called without any
+// change being made. It is called without any internal lock held.
+func (m *Map[K, V]) Compute(key K, f func(old V, loaded bool) (new V, delete bool)) (value V, ok bool) {
+	if m.Closed() {
+		return m.Load(key)
+	}
+	return m.compute(m.key(key), f)
+}
+
+// update is Compute for an f that never deletes. The key must already have
+// been transformed.
+func (m *Map[K, V]) update(key K, f func(old V, loaded bool) V) V {
+	v, _ := m.compute(key, func(old V, loaded bool) (V, bool) {
+		return f(old, loaded), false
+	})
+	return v
+}
+
+// compute is Compute for a key that has already been transformed.
+func (m *Map[K, V]) compute(key K, f func(old V, loaded bool) (V, bool)) (value V, ok bool) {
+	for {
+		read, _ := m.read.Load().(readOnly[K, V])
+		e, ok := read.m[key]
+		if !ok || e.loadPointer() == expunged {
+			// An expunged entry must be unexpunged under the lock before it
+			// can be stored to; the read map would keep returning it as is.
+			m.mu.Lock()
+			e = m.entryLocked(key)
+			m.mu.Unlock()
+		}
+		for {
+			p := e.loadPointer()
+			if p == expunged {
+				// The entry was dropped from the dirty map after we found it;
+				// look it up again.
+				break
+			}
+			old, loaded := valueOf[V](&m.ver, p)
+			var nv V
+			var del bool
+			if loaded {
+				nv, del = f(*old, true)
+			} else {
+				var zero V
+				nv, del = f(zero, false)
+			}
+			if del {
+				if !loaded {
+					return value, false
+				}
+				if e.publish(&m.ver, p, tombstone[V](&m.ver)) {
+					m.deleted(key, *old)
+					return value, false
+				}
+				continue
+			}
+			if e.publish(&m.ver, p, newValue(&m.ver, nv)) {
+				m.stored(key, nv)
+				return nv, true
+			}
+		}
+	}
+}
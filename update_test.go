@@ -0,0 +1,63 @@
+package syncmapt_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+	"github.com/holdno/syncmapt/syncmapttest"
+)
+
+func TestCompute(t *testing.T) {
+	m := new(syncmapt.Map[string, int])
+	incr := func(old int, loaded bool) (int, bool) { return old + 1, false }
+
+	const goroutines, increments = 8, 1000
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < increments; i++ {
+				m.Compute("n", incr)
+			}
+		}()
+	}
+	wg.Wait()
+	if v, _ := m.Load("n"); v != goroutines*increments {
+		t.Fatalf("counter = %d, want %d", v, goroutines*increments)
+	}
+
+	// Returning delete removes the key; for a missing key it does nothing.
+	del := func(int, bool) (int, bool) { return 0, true }
+	if v, ok := m.Compute("n", del); ok || v != 0 {
+		t.Fatalf("Compute deleting = %v, %v; want 0, false", v, ok)
+	}
+	if v, ok := m.Compute("missing", del); ok || v != 0 {
+		t.Fatalf("Compute deleting a missing key = %v, %v; want 0, false", v, ok)
+	}
+	if v, ok := m.Compute("new", func(old int, loaded bool) (int, bool) {
+		if loaded {
+			t.Fatal("Compute loaded a missing key")
+		}
+		return 7, false
+	}); !ok || v != 7 {
+		t.Fatalf("Compute storing = %v, %v; want 7, true", v, ok)
+	}
+	syncmapttest.RequireEqual(t, m, map[string]int{"new": 7})
+}
+
+func TestComputeExpunged(t *testing.T) {
+	m := new(syncmapt.Map[string, int])
+	m.Store("a", 1)
+	m.Load("a") // promote the dirty map, so that a is in the read map
+	m.Delete("a")
+	m.Store("b", 2) // copy the read map to a new dirty map, expunging a
+
+	if v, ok := m.Compute("a", func(_ int, loaded bool) (int, bool) {
+		return 3, false
+	}); !ok || v != 3 {
+		t.Fatalf("Compute of an expunged key = %v, %v; want 3, true", v, ok)
+	}
+	syncmapttest.RequireEqual(t, m, map[string]int{"a": 3, "b": 2})
+}
@@ -0,0 +1,44 @@
+package syncmapt
+
+// View returns a live, read-only view of the entries of m for which pred
+// returns true. The view is not a copy: every call on it reads the current
+// contents of m and evaluates pred on the entries it visits, so pred must be
+// cheap and safe for concurrent use.
+//
+// Len on the view visits every entry of m.
+func (m *Map[K, V]) View(pred func(key K, value V) bool) ReadOnlyMap[K, V] {
+	return &view[K, V]{m: m, pred: pred}
+}
+
+type view[K comparable, V any] struct {
+	m    *Map[K, V]
+	pred func(K, V) bool
+}
+
+func (v *view[K, V]) Load(key K) (value V, ok bool) {
+	key = v.m.key(key)
+	value, ok = v.m.load(key)
+	if !ok || !v.pred(key, value) {
+		var zero V
+		return zero, false
+	}
+	return value, true
+}
+
+func (v *view[K, V]) Range(f func(key K, value V) bool) {
+	v.m.Range(func(key K, value V) bool {
+		if !v.pred(key, value) {
+			return true
+		}
+		return f(key, value)
+	})
+}
+
+func (v *view[K, V]) Len() int {
+	n := 0
+	v.Range(func(K, V) bool {
+		n++
+		return true
+	})
+	return n
+}
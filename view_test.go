@@ -0,0 +1,30 @@
+package syncmapt_test
+
+import (
+	"testing"
+
+	"github.com/holdno/syncmapt/syncmapttest"
+)
+
+func TestView(t *testing.T) {
+	type backend struct{ healthy bool }
+	m := syncmapttest.New(map[string]backend{"a": {true}, "b": {false}, "c": {true}})
+
+	healthy := m.View(func(_ string, b backend) bool { return b.healthy })
+	syncmapttest.RequireEqual(t, healthy, map[string]backend{"a": {true}, "c": {true}})
+	if _, ok := healthy.Load("b"); ok {
+		t.Fatal("want unhealthy backend hidden")
+	}
+
+	// The view is live.
+	m.Store("b", backend{true})
+	m.Store("c", backend{false})
+	m.Store("d", backend{true})
+	syncmapttest.RequireEqual(t, healthy, map[string]backend{"a": {true}, "b": {true}, "d": {true}})
+	if healthy.Len() != 3 {
+		t.Fatal("unexpected", healthy.Len())
+	}
+	if _, ok := healthy.Load("b"); !ok {
+		t.Fatal("want recovered backend visible")
+	}
+}
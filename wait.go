@@ -0,0 +1,100 @@
+package syncmapt
+
+import "context"
+
+// A waiter is shared by all goroutines waiting for the same key. Its channel
+// is closed by the next store to that key.
+type waiter struct {
+	ch chan struct{}
+	n  int // number of goroutines waiting on ch
+}
+
+// WaitForKey returns the value stored in the map for key, blocking until the
+// key is stored if it is not present yet.
+//
+// If ctx is done before the key is stored, WaitForKey returns ctx.Err(). If
+// the Map is closed, it returns ErrClosed.
+func (m *Map[K, V]) WaitForKey(ctx context.Context, key K) (V, error) {
+	return m.WaitForValue(ctx, key, func(V) bool { return true })
+}
+
+// WaitForValue returns the value stored in the map for key once it satisfies
+// ready, blocking until such a value is stored. ready is called with every
+// value observed for key, possibly concurrently with other calls to ready.
+//
+// If ctx is done before a ready value is stored, WaitForValue returns
+// ctx.Err(). If the Map is closed, it returns ErrClosed.
+func (m *Map[K, V]) WaitForValue(ctx context.Context, key K, ready func(V) bool) (V, error) {
+	key = m.key(key)
+	if v, ok := m.load(key); ok && ready(v) {
+		return v, nil
+	}
+	for {
+		w := m.addWaiter(key)
+		// Check again now that any concurrent store or Close is guaranteed
+		// to see the waiter.
+		if v, ok := m.load(key); ok && ready(v) {
+			m.removeWaiter(key, w)
+			return v, nil
+		}
+		if m.Closed() {
+			m.removeWaiter(key, w)
+			var zero V
+			return zero, ErrClosed
+		}
+		select {
+		case <-w.ch:
+			// A value was stored for key, but it may not be ready, or may
+			// already have been replaced or deleted; check again.
+		case <-ctx.Done():
+			m.removeWaiter(key, w)
+			var zero V
+			return zero, ctx.Err()
+		}
+	}
+}
+
+func (m *Map[K, V]) addWaiter(key K) *waiter {
+	m.waitMu.Lock()
+	w, ok := m.waiters[key]
+	if !ok {
+		if m.waiters == nil {
+			m.waiters = make(map[K]*waiter)
+		}
+		w = &waiter{ch: make(chan struct{})}
+		m.waiters[key] = w
+		m.nwaiters.Add(1)
+	}
+	w.n++
+	m.waitMu.Unlock()
+	return w
+}
+
+// removeWaiter unregisters a goroutine that stopped waiting on w without
+// being woken.
+func (m *Map[K, V]) removeWaiter(key K, w *waiter) {
+	m.waitMu.Lock()
+	if m.waiters[key] == w {
+		w.n--
+		if w.n == 0 {
+			delete(m.waiters, key)
+			m.nwaiters.Add(-1)
+		}
+	}
+	m.waitMu.Unlock()
+}
+
+// wake wakes the goroutines waiting for key. It must be called after a value
+// for key has been stored.
+func (m *Map[K, V]) wake(key K) {
+	if m.nwaiters.Load() == 0 {
+		return
+	}
+	m.waitMu.Lock()
+	if w, ok := m.waiters[key]; ok {
+		close(w.ch)
+		delete(m.waiters, key)
+		m.nwaiters.Add(-1)
+	}
+	m.waitMu.Unlock()
+}
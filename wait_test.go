@@ -0,0 +1,125 @@
+package syncmapt_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/holdno/syncmapt"
+)
+
+func TestWaitForKey(t *testing.T) {
+	m := new(syncmapt.Map[string, int])
+
+	const waiters = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, waiters)
+	for i := 0; i < waiters; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := m.WaitForKey(context.Background(), "ready")
+			if err == nil && v != 42 {
+				err = errors.New("unexpected value")
+			}
+			errs <- err
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	m.Store("ready", 42)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A present key returns immediately.
+	if v, err := m.WaitForKey(context.Background(), "ready"); err != nil || v != 42 {
+		t.Fatalf("WaitForKey = %v, %v; want 42, nil", v, err)
+	}
+}
+
+func TestWaitForKeyLoadOrStore(t *testing.T) {
+	m := new(syncmapt.Map[string, int])
+
+	done := make(chan int)
+	go func() {
+		v, _ := m.WaitForKey(context.Background(), "k")
+		done <- v
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	m.LoadOrStore("k", 7)
+	if v := <-done; v != 7 {
+		t.Fatal("unexpected", v)
+	}
+}
+
+func TestWaitForKeyContext(t *testing.T) {
+	m := new(syncmapt.Map[string, int])
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := m.WaitForKey(ctx, "never"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal("want DeadlineExceeded, got", err)
+	}
+
+	// The canceled waiter must not be woken or leak into later stores.
+	m.Store("never", 1)
+}
+
+func TestWaitForValue(t *testing.T) {
+	type status string
+	m := new(syncmapt.Map[string, status])
+	m.Store("svc", "starting")
+
+	done := make(chan status)
+	go func() {
+		v, err := m.WaitForValue(context.Background(), "svc", func(s status) bool {
+			return s == "ready"
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		done <- v
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	m.Store("svc", "migrating")
+	select {
+	case v := <-done:
+		t.Fatal("woke on value not satisfying predicate:", v)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	m.Store("svc", "ready")
+	if v := <-done; v != "ready" {
+		t.Fatal("unexpected", v)
+	}
+}
+
+func TestWaitForKeyClose(t *testing.T) {
+	m := new(syncmapt.Map[string, int])
+
+	errc := make(chan error)
+	go func() {
+		_, err := m.WaitForKey(context.Background(), "k")
+		errc <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	m.Close()
+	if err := <-errc; !errors.Is(err, syncmapt.ErrClosed) {
+		t.Fatal("want ErrClosed, got", err)
+	}
+
+	if _, err := m.WaitForKey(context.Background(), "k"); !errors.Is(err, syncmapt.ErrClosed) {
+		t.Fatal("want ErrClosed after Close, got", err)
+	}
+}
@@ -0,0 +1,451 @@
+package syncmapt
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// WithSyncedLog returns an Option that makes a WALMap sync its log to disk
+// after every write, so that acknowledged writes survive a power loss and not
+// only a crash of the process. Every write then waits for the disk.
+func WithSyncedLog[K comparable, V any]() Option[K, V] {
+	return func(c *config[K, V]) {
+		c.syncLog = true
+	}
+}
+
+// A WALMap is a Map persisted in a directory: a snapshot of its contents,
+// written by Checkpoint, and a write-ahead log of every Store and Delete
+// since. OpenWAL restores the contents by loading the snapshot and replaying
+// the log, so a process that crashes loses no write that returned.
+//
+// Lookups take no lock, as with Map. Writes are serialized by an internal
+// lock, so that the log records them in the order they are applied, and each
+// appends a record to the log with one write to the file. Keys and values
+// are encoded as by MarshalBinary.
+//
+// The write methods have no error result, so that a WALMap can be used as an
+// Interface. The first error writing the log is kept and returned by Err,
+// Sync, Checkpoint and Close; the map keeps working in memory after it.
+//
+// Of the Options, WithSyncedLog syncs every write to disk, WithEncryption
+// encrypts the snapshot and every record of the log, and WithKeyTransform
+// canonicalizes keys. An encrypted WALMap asks its KeyProvider for a key
+// when it starts a log, in OpenWAL and in every Checkpoint.
+type WALMap[K comparable, V any] struct {
+	m   *Map[K, V]
+	cfg *config[K, V]
+	dir string
+
+	mu   sync.Mutex // serializes writes and guards the fields below
+	log  *os.File
+	seal *walSeal // seals the records of an encrypted log
+	err  error
+
+	appendKey   func([]byte, K) ([]byte, error)
+	appendValue func([]byte, V) ([]byte, error)
+}
+
+var _ Interface[string, any] = (*WALMap[string, any])(nil)
+
+// A walSeal seals and opens the records of an encrypted log in order.
+type walSeal struct {
+	aead   cipher.AEAD
+	header []byte
+	prefix []byte
+	seq    uint32 // sequence number of the next record
+}
+
+// full reports whether every sequence number of the log has been used.
+func (s *walSeal) full() bool {
+	return s.seq == 1<<32-1
+}
+
+func (s *walSeal) seal(body []byte) []byte {
+	sealed := s.aead.Seal(nil, sealNonce(s.prefix, s.seq, false), body, s.header)
+	s.seq++
+	return sealed
+}
+
+func (s *walSeal) open(sealed []byte) ([]byte, error) {
+	body, err := s.aead.Open(nil, sealNonce(s.prefix, s.seq, false), sealed, s.header)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	s.seq++
+	return body, nil
+}
+
+// The files of a WALMap in its directory.
+const (
+	walSnapshotFile = "snapshot"
+	walLogFile      = "wal"
+)
+
+// The operations recorded in the log. A record is framed with the length of
+// its body and the CRC-32C of the body, in big-endian byte order:
+//
+//	len(body) | body | CRC-32C
+//
+// where the body is the operation byte and the length-prefixed key, followed
+// for a store by the length-prefixed value.
+//
+// With WithEncryption, the log starts with the header of an encrypted stream,
+// as written by NewEncryptingWriter, and each body is sealed with AES-GCM under
+// the key it names. The nonce of a record is the random prefix of the header
+// followed by the sequence number of the record in the log, so no nonce is
+// used twice with a key and records cannot be reordered or dropped from the
+// middle of the log undetected. Every Checkpoint starts a new log, with a new
+// header.
+const (
+	walStore byte = 1 + iota
+	walDelete
+)
+
+// OpenWAL opens the WALMap persisted in dir, creating dir if needed, and
+// restores its contents. A record cut short at the end of the log, as by a
+// crash while it was written, is discarded. A damaged record followed by
+// valid ones, or other corruption of the snapshot or the log, fails with an
+// error wrapping ErrCorrupt and leaves the files unchanged.
+func OpenWAL[K comparable, V any](dir string, opts ...Option[K, V]) (*WALMap[K, V], error) {
+	w := &WALMap[K, V]{
+		m:           New(opts...),
+		cfg:         newConfig(opts),
+		dir:         dir,
+		appendKey:   binaryAppender[K](),
+		appendValue: binaryAppender[V](),
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if err := w.m.LoadFile(filepath.Join(dir, walSnapshotFile)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("syncmapt: loading snapshot: %w", err)
+	}
+
+	log, err := os.OpenFile(filepath.Join(dir, walLogFile), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	w.log = log
+	data, err := io.ReadAll(log)
+	if err == nil {
+		var n int
+		if n, err = w.replay(data); err == nil {
+			// Drop a torn record at the end, and append after the last good
+			// one.
+			if err = log.Truncate(int64(n)); err == nil {
+				_, err = log.Seek(int64(n), io.SeekStart)
+			}
+			if err == nil && n == 0 {
+				err = w.startLogLocked()
+			}
+		}
+	}
+	if err != nil {
+		log.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// startLogLocked starts the empty log, writing the header of an encrypted
+// log with a new key and nonce prefix.
+func (w *WALMap[K, V]) startLogLocked() error {
+	keys := w.m.encryptionKeys()
+	if keys == nil {
+		return nil
+	}
+	aead, header, prefix, err := newSealHeader(keys)
+	if err != nil {
+		return err
+	}
+	if _, err := w.log.Write(header); err != nil {
+		return err
+	}
+	w.seal = &walSeal{aead: aead, header: header, prefix: prefix}
+	return nil
+}
+
+// replay applies the records of the log data to w.m and returns the length of
+// the complete records.
+func (w *WALMap[K, V]) replay(data []byte) (int, error) {
+	decodeKey := binaryDecoder[K]()
+	decodeValue := binaryDecoder[V]()
+	off := 0
+	if keys := w.m.encryptionKeys(); keys != nil && len(data) > 0 {
+		aead, header, prefix, err := readSealHeader(bytes.NewReader(data), keys)
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, nil // a header cut short: the log is empty
+		}
+		if err != nil {
+			return 0, fmt.Errorf("syncmapt: log header: %w", err)
+		}
+		w.seal = &walSeal{aead: aead, header: header, prefix: prefix}
+		off = len(header)
+	}
+	for off < len(data) {
+		body, size, ok := walFrame(data[off:])
+		if !ok {
+			// A crash can only cut short the last record: if a valid record
+			// follows a bad one, the log is corrupt.
+			for p := off + 1; p < len(data); p++ {
+				if _, _, ok := walFrame(data[p:]); ok {
+					return 0, fmt.Errorf("syncmapt: log record at offset %d: %w", off, ErrCorrupt)
+				}
+			}
+			return off, nil
+		}
+		if w.seal != nil {
+			var err error
+			if body, err = w.seal.open(body); err != nil {
+				return 0, fmt.Errorf("syncmapt: log record at offset %d: %w", off, err)
+			}
+		}
+		if err := w.apply(body, decodeKey, decodeValue); err != nil {
+			return 0, fmt.Errorf("syncmapt: log record at offset %d: %w", off, errors.Join(ErrCorrupt, err))
+		}
+		off += size
+	}
+	return len(data), nil
+}
+
+// walFrame returns the body of the log record at the start of data and the
+// size of its frame. The ok result is false if the frame is incomplete, has a
+// bad checksum, or has an empty body, which no record has.
+func walFrame(data []byte) (body []byte, size int, ok bool) {
+	n, k := binary.Uvarint(data)
+	if k <= 0 || n == 0 || len(data)-k < 4 || n > uint64(len(data)-k-4) {
+		return nil, 0, false
+	}
+	end := k + int(n)
+	body = data[k:end]
+	if crc32.Checksum(body, castagnoli) != binary.BigEndian.Uint32(data[end:end+4]) {
+		return nil, 0, false
+	}
+	return body, end + 4, true
+}
+
+// apply applies the operation recorded in the body of a log record to w.m.
+func (w *WALMap[K, V]) apply(body []byte, decodeKey func([]byte, *K) error, decodeValue func([]byte, *V) error) error {
+	if len(body) == 0 {
+		return errBinaryFormat
+	}
+	op := body[0]
+	field, rest, err := nextField(body[1:])
+	if err != nil {
+		return err
+	}
+	var key K
+	if err := decodeKey(field, &key); err != nil {
+		return err
+	}
+	switch op {
+	case walStore:
+		if field, rest, err = nextField(rest); err != nil {
+			return err
+		}
+		var value V
+		if err := decodeValue(field, &value); err != nil {
+			return err
+		}
+		if len(rest) != 0 {
+			return errBinaryFormat
+		}
+		w.m.Store(key, value)
+	case walDelete:
+		if len(rest) != 0 {
+			return errBinaryFormat
+		}
+		w.m.Delete(key)
+	default:
+		return errBinaryFormat
+	}
+	return nil
+}
+
+// appendLocked appends a record of op on key, with value for a store, to the
+// log. Errors are kept in w.err. Nothing is logged once the map is closed.
+func (w *WALMap[K, V]) appendLocked(op byte, key K, value V) {
+	if w.err != nil || w.m.Closed() {
+		return
+	}
+	if w.seal != nil && w.seal.full() {
+		// Start a new log, with a new nonce prefix.
+		if err := w.checkpointLocked(); err != nil {
+			w.err = err
+			return
+		}
+	}
+	body, err := appendRecordField([]byte{op}, key, w.appendKey)
+	if err == nil && op == walStore {
+		body, err = appendRecordField(body, value, w.appendValue)
+	}
+	if err == nil && w.seal != nil {
+		body = w.seal.seal(body)
+	}
+	if err != nil {
+		w.err = err
+		return
+	}
+	rec := binary.AppendUvarint(make([]byte, 0, len(body)+binary.MaxVarintLen64+4), uint64(len(body)))
+	rec = append(rec, body...)
+	rec = binary.BigEndian.AppendUint32(rec, crc32.Checksum(body, castagnoli))
+	if _, err = w.log.Write(rec); err == nil && w.cfg.syncLog {
+		err = w.log.Sync()
+	}
+	w.err = err
+}
+
+// appendRecordField appends v, encoded by enc and preceded by its length, to
+// b.
+func appendRecordField[T any](b []byte, v T, enc func([]byte, T) ([]byte, error)) ([]byte, error) {
+	field, err := enc(nil, v)
+	if err != nil {
+		return nil, err
+	}
+	b = binary.AppendUvarint(b, uint64(len(field)))
+	return append(b, field...), nil
+}
+
+// Load returns the value stored in the map for a key, or the zero value if no
+// value is present. The ok result indicates whether value was found in the
+// map.
+func (w *WALMap[K, V]) Load(key K) (value V, ok bool) {
+	return w.m.Load(key)
+}
+
+// Store sets the value for a key and logs it.
+func (w *WALMap[K, V]) Store(key K, value V) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.m.Store(key, value)
+	w.appendLocked(walStore, key, value)
+}
+
+// LoadOrStore returns the existing value for the key if present. Otherwise,
+// it stores and returns the given value, and logs the store. The loaded
+// result is true if the value was loaded, false if stored.
+func (w *WALMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	if actual, loaded = w.m.Load(key); loaded {
+		return actual, true
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if actual, loaded = w.m.LoadOrStore(key, value); !loaded {
+		w.appendLocked(walStore, key, value)
+	}
+	return actual, loaded
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if
+// any, and logs the delete. The loaded result reports whether the key was
+// present.
+func (w *WALMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if value, loaded = w.m.LoadAndDelete(key); loaded {
+		w.appendLocked(walDelete, key, value)
+	}
+	return value, loaded
+}
+
+// Delete deletes the value for a key and logs it.
+func (w *WALMap[K, V]) Delete(key K) {
+	w.LoadAndDelete(key)
+}
+
+// Range calls f sequentially for each key and value present in the map. If f
+// returns false, range stops the iteration. Range has the semantics of
+// Map.Range.
+func (w *WALMap[K, V]) Range(f func(key K, value V) bool) {
+	w.m.Range(f)
+}
+
+// Len returns the number of entries in the map.
+func (w *WALMap[K, V]) Len() int {
+	return w.m.Len()
+}
+
+// Err returns the first error that occurred writing the log, if any.
+func (w *WALMap[K, V]) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Sync syncs the log to disk, making every write so far survive a power
+// loss, and returns any error writing the log.
+func (w *WALMap[K, V]) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.m.Closed() {
+		return ErrClosed
+	}
+	if w.err == nil {
+		w.err = w.log.Sync()
+	}
+	return w.err
+}
+
+// Checkpoint saves the contents of the map as its snapshot and empties the
+// log, which then only records the writes made after the Checkpoint. Writes
+// wait for the Checkpoint to finish. If the process crashes during a
+// Checkpoint, OpenWAL restores the same contents from the previous snapshot
+// or the new one.
+func (w *WALMap[K, V]) Checkpoint() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.m.Closed() {
+		return ErrClosed
+	}
+	if w.err != nil {
+		return w.err
+	}
+	return w.checkpointLocked()
+}
+
+func (w *WALMap[K, V]) checkpointLocked() error {
+	// SaveFile returns once the new snapshot and its name in the directory
+	// are on disk, so the log is only emptied when no write depends on it.
+	// The snapshot holds every logged write, so a crash before the log is
+	// emptied only replays them again, which is harmless.
+	if err := w.m.SaveFile(filepath.Join(w.dir, walSnapshotFile)); err != nil {
+		return err
+	}
+	if err := w.log.Truncate(0); err != nil {
+		w.err = err
+		return err
+	}
+	if _, w.err = w.log.Seek(0, io.SeekStart); w.err == nil {
+		w.seal = nil
+		if w.err = w.startLogLocked(); w.err == nil {
+			w.err = w.log.Sync()
+		}
+	}
+	return w.err
+}
+
+// Close closes the log and the map as Map.Close does: afterwards the map can
+// still be read, but stores and deletions do nothing and are not logged, and
+// Sync and Checkpoint return ErrClosed. Close returns any error writing or
+// closing the log.
+func (w *WALMap[K, V]) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.m.Closed() {
+		return w.err
+	}
+	w.m.Close()
+	if err := w.log.Close(); w.err == nil {
+		w.err = err
+	}
+	return w.err
+}
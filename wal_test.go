@@ -0,0 +1,204 @@
+package syncmapt_test
+
+import (
+	"bytes"
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/holdno/syncmapt"
+	"github.com/holdno/syncmapt/syncmapttest"
+)
+
+func openWAL(t *testing.T, dir string) *syncmapt.WALMap[string, int] {
+	t.Helper()
+	w, err := syncmapt.OpenWAL[string, int](dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func TestWALMapConformance(t *testing.T) {
+	syncmapttest.RunConformance(t, func() syncmapt.Interface[string, string] {
+		w, err := syncmapt.OpenWAL[string, string](t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { w.Close() })
+		return w
+	})
+}
+
+func TestWALMapReplay(t *testing.T) {
+	dir := t.TempDir()
+	w := openWAL(t, dir)
+	w.Store("a", 1)
+	w.Store("b", 2)
+	w.LoadOrStore("c", 3)
+	w.Delete("a")
+	w.Store("b", 20)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Sync(); !errors.Is(err, syncmapt.ErrClosed) {
+		t.Fatalf("Sync after Close = %v, want ErrClosed", err)
+	}
+
+	w = openWAL(t, dir)
+	defer w.Close()
+	want := map[string]int{"b": 20, "c": 3}
+	if got := syncmapt.Collect(w.Range).ToMap(); !maps.Equal(got, want) {
+		t.Fatalf("reopened WALMap holds %v, want %v", got, want)
+	}
+}
+
+func TestWALMapCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	w := openWAL(t, dir)
+	for i, k := range []string{"a", "b", "c"} {
+		w.Store(k, i)
+	}
+	if err := w.Checkpoint(); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Stat(filepath.Join(dir, "wal")); err != nil || fi.Size() != 0 {
+		t.Fatalf("log after Checkpoint: %v, %v; want empty", fi, err)
+	}
+	w.Delete("b")
+	w.Close()
+
+	w = openWAL(t, dir)
+	defer w.Close()
+	want := map[string]int{"a": 0, "c": 2}
+	if got := syncmapt.Collect(w.Range).ToMap(); !maps.Equal(got, want) {
+		t.Fatalf("reopened WALMap holds %v, want %v", got, want)
+	}
+}
+
+func TestWALMapTornLog(t *testing.T) {
+	dir := t.TempDir()
+	w := openWAL(t, dir)
+	w.Store("a", 1)
+	w.Store("b", 2)
+	w.Close()
+	path := filepath.Join(dir, "wal")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A crash in the middle of the last write loses only that write, and
+	// later writes are appended after the last complete record.
+	if err := os.WriteFile(path, data[:len(data)-3], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	w = openWAL(t, dir)
+	w.Store("c", 3)
+	w.Close()
+	w = openWAL(t, dir)
+	want := map[string]int{"a": 1, "c": 3}
+	if got := syncmapt.Collect(w.Range).ToMap(); !maps.Equal(got, want) {
+		t.Fatalf("reopened WALMap holds %v, want %v", got, want)
+	}
+	w.Close()
+
+	// Corruption before the end of the log is an error.
+	data[2] ^= 1
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := syncmapt.OpenWAL[string, int](dir); !errors.Is(err, syncmapt.ErrCorrupt) {
+		t.Fatalf("OpenWAL of a corrupt log = %v, want ErrCorrupt", err)
+	}
+}
+
+func TestWALMapEncrypted(t *testing.T) {
+	dir := t.TempDir()
+	key := syncmapt.StaticKey(bytes.Repeat([]byte{1}, 32))
+	w, err := syncmapt.OpenWAL(dir, syncmapt.WithEncryption[string, string](key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Store("a", "secret-a")
+	if err := w.Checkpoint(); err != nil {
+		t.Fatal(err)
+	}
+	w.Store("b", "secret-b")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"snapshot", "wal"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || bytes.Contains(data, []byte("secret")) {
+			t.Fatalf("%s holds the values in the clear (err %v)", name, err)
+		}
+	}
+
+	w, err = syncmapt.OpenWAL(dir, syncmapt.WithEncryption[string, string](key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Records appended after reopening continue the sequence of the log.
+	w.Store("c", "secret-c")
+	w.Close()
+	if data, _ := os.ReadFile(filepath.Join(dir, "wal")); bytes.Count(data, []byte("SMTE")) != 1 {
+		t.Fatal("the log does not have exactly one encryption header")
+	}
+	w, err = syncmapt.OpenWAL(dir, syncmapt.WithEncryption[string, string](key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"a": "secret-a", "b": "secret-b", "c": "secret-c"}
+	if got := syncmapt.Collect(w.Range).ToMap(); !maps.Equal(got, want) {
+		t.Fatalf("reopened WALMap holds %v, want %v", got, want)
+	}
+	w.Close()
+
+	wrong := syncmapt.StaticKey(bytes.Repeat([]byte{2}, 32))
+	if _, err := syncmapt.OpenWAL(dir, syncmapt.WithEncryption[string, string](wrong)); !errors.Is(err, syncmapt.ErrDecrypt) {
+		t.Fatalf("OpenWAL with the wrong key = %v, want ErrDecrypt", err)
+	}
+
+	// The log alone, without the snapshot, is also checked.
+	if err := os.Remove(filepath.Join(dir, "snapshot")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := syncmapt.OpenWAL(dir, syncmapt.WithEncryption[string, string](wrong)); !errors.Is(err, syncmapt.ErrDecrypt) {
+		t.Fatalf("OpenWAL of the log with the wrong key = %v, want ErrDecrypt", err)
+	}
+}
+
+func TestWALMapCorruptMiddleRecord(t *testing.T) {
+	dir := t.TempDir()
+	w := openWAL(t, dir)
+	w.Store("a", 1)
+	w.Store("b", 2)
+	w.Store("c", 3)
+	w.Close()
+	path := filepath.Join(dir, "wal")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := len(data) / 3 // every record has the same size
+
+	for name, corrupt := range map[string]func([]byte){
+		"length":   func(b []byte) { b[size] = 0x7f }, // runs past the end of the log
+		"checksum": func(b []byte) { b[2*size-1] ^= 1 },
+	} {
+		bad := bytes.Clone(data)
+		corrupt(bad)
+		if err := os.WriteFile(path, bad, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := syncmapt.OpenWAL[string, int](dir); !errors.Is(err, syncmapt.ErrCorrupt) {
+			t.Errorf("OpenWAL with a bad %s in the middle record = %v, want ErrCorrupt", name, err)
+		}
+		if got, _ := os.ReadFile(path); !bytes.Equal(got, bad) {
+			t.Errorf("OpenWAL with a bad %s in the middle record changed the log", name)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package syncmapt
+
+import (
+	"context"
+	"strconv"
+	"sync"
+)
+
+// An EventKind identifies what happened to a key of a watched map.
+type EventKind int
+
+const (
+	// EventStore means a value was stored for the key.
+	EventStore EventKind = iota + 1
+	// EventDelete means the value for the key was deleted.
+	EventDelete
+	// EventExpire means the value for the key expired and was deleted.
+	EventExpire
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventStore:
+		return "store"
+	case EventDelete:
+		return "delete"
+	case EventExpire:
+		return "expire"
+	}
+	return "EventKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// An Event reports a change to a key of a watched map. For an EventStore,
+// Value is the value stored for Key; otherwise it is the zero value.
+type Event[K comparable, V any] struct {
+	Kind  EventKind
+	Key   K
+	Value V
+}
+
+// Watch returns a channel that delivers the changes to the value stored for
+// key, starting with an EventStore for the current value, if any. The
+// channel is closed once ctx is done.
+//
+// Events are level-triggered: if key changes several times before the
+// receiver catches up, it receives a single event for its latest state, so a
+// slow receiver never makes writers wait or buffers more than one event per
+// key. An EventDelete is only sent for a value that was reported stored.
+func (m *Map[K, V]) Watch(ctx context.Context, key K) <-chan Event[K, V] {
+	key = m.key(key)
+	return watch(ctx, m, &key, m.watchLoad)
+}
+
+// WatchAll is like Watch, but delivers the changes to every key of the map,
+// starting with an EventStore for each current entry.
+func (m *Map[K, V]) WatchAll(ctx context.Context) <-chan Event[K, V] {
+	return watch(ctx, m, nil, m.watchLoad)
+}
+
+func (m *Map[K, V]) watchLoad(key K) (V, bool) {
+	return m.load(key)
+}
+
+// A watcher is the observer behind a Watch or WatchAll channel. The
+// goroutines changing the map only record which keys changed, in pending and
+// queue; the watcher's own goroutine loads their current values and sends
+// the events.
+type watcher[K comparable, V any] struct {
+	only *K // the watched key, or nil to watch all keys
+	load func(K) (V, bool)
+	keys func(yield func(K) bool) // the current keys, for WatchAll
+
+	mu       sync.Mutex
+	pending  map[K]bool // the keys in queue, with whether they expired
+	queue    []K
+	replaced bool          // set when the whole contents were replaced
+	wake     chan struct{} // signaled when queue or replaced changes
+}
+
+// watch starts a watcher of m that loads values with load.
+func watch[K comparable, V, X any](ctx context.Context, m *Map[K, X], only *K, load func(K) (V, bool)) <-chan Event[K, V] {
+	w := &watcher[K, V]{
+		only:     only,
+		load:     load,
+		pending:  make(map[K]bool),
+		wake:     make(chan struct{}, 1),
+		replaced: true, // to report the current contents
+		keys: func(yield func(K) bool) {
+			m.Range(func(k K, _ X) bool { return yield(k) })
+		},
+	}
+	// Register first, so that no change made while the current contents are
+	// reported is missed.
+	m.addObserver(w)
+	w.wake <- struct{}{}
+
+	ch := make(chan Event[K, V])
+	go func() {
+		defer close(ch)
+		defer m.removeObserver(w)
+		w.run(ctx, ch)
+	}()
+	return ch
+}
+
+func (w *watcher[K, V]) keyChanged(key K) { w.changed(key, false) }
+func (w *watcher[K, V]) keyExpired(key K) { w.changed(key, true) }
+
+func (w *watcher[K, V]) changed(key K, expired bool) {
+	if w.only != nil && *w.only != key {
+		return
+	}
+	w.mu.Lock()
+	if _, ok := w.pending[key]; !ok {
+		w.queue = append(w.queue, key)
+	}
+	w.pending[key] = expired
+	w.mu.Unlock()
+	w.signal()
+}
+
+func (w *watcher[K, V]) reset() {
+	w.mu.Lock()
+	w.replaced = true
+	w.mu.Unlock()
+	w.signal()
+}
+
+func (w *watcher[K, V]) signal() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (w *watcher[K, V]) run(ctx context.Context, ch chan<- Event[K, V]) {
+	seen := make(map[K]struct{}) // the keys last reported stored
+	for {
+		select {
+		case <-w.wake:
+		case <-ctx.Done():
+			return
+		}
+		for {
+			w.mu.Lock()
+			if w.replaced {
+				// Recheck every key that may have changed: the ones reported
+				// stored and the current ones.
+				w.replaced = false
+				w.mu.Unlock()
+				for k := range seen {
+					w.changed(k, false)
+				}
+				if w.only != nil {
+					w.changed(*w.only, false)
+				} else {
+					w.keys(func(k K) bool {
+						w.changed(k, false)
+						return true
+					})
+				}
+				continue
+			}
+			if len(w.queue) == 0 {
+				w.mu.Unlock()
+				break
+			}
+			key := w.queue[0]
+			var zero K
+			w.queue[0] = zero
+			w.queue = w.queue[1:]
+			expired := w.pending[key]
+			delete(w.pending, key)
+			w.mu.Unlock()
+
+			e := Event[K, V]{Kind: EventStore, Key: key}
+			var ok bool
+			if e.Value, ok = w.load(key); ok {
+				seen[key] = struct{}{}
+			} else if _, ok := seen[key]; ok {
+				delete(seen, key)
+				e.Kind = EventDelete
+				if expired {
+					e.Kind = EventExpire
+				}
+			} else {
+				continue
+			}
+			select {
+			case ch <- e:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
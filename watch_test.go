@@ -0,0 +1,121 @@
+package syncmapt_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/holdno/syncmapt"
+)
+
+func receive[K comparable, V any](t *testing.T, ch <-chan syncmapt.Event[K, V]) syncmapt.Event[K, V] {
+	t.Helper()
+	select {
+	case e, ok := <-ch:
+		if !ok {
+			t.Fatal("watch channel closed")
+		}
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event")
+	}
+	panic("unreachable")
+}
+
+func expectEvent[K comparable, V comparable](t *testing.T, ch <-chan syncmapt.Event[K, V], want syncmapt.Event[K, V]) {
+	t.Helper()
+	if e := receive(t, ch); e != want {
+		t.Fatalf("event = %+v, want %+v", e, want)
+	}
+}
+
+func expectNoEvent[K comparable, V any](t *testing.T, ch <-chan syncmapt.Event[K, V]) {
+	t.Helper()
+	select {
+	case e := <-ch:
+		t.Fatalf("unexpected event %+v", e)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestWatchAll(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := new(syncmapt.Map[string, int])
+	m.Store("a", 1)
+
+	ch := m.WatchAll(ctx)
+	expectEvent(t, ch, syncmapt.Event[string, int]{Kind: syncmapt.EventStore, Key: "a", Value: 1})
+
+	m.Store("b", 2)
+	expectEvent(t, ch, syncmapt.Event[string, int]{Kind: syncmapt.EventStore, Key: "b", Value: 2})
+	m.Delete("a")
+	expectEvent(t, ch, syncmapt.Event[string, int]{Kind: syncmapt.EventDelete, Key: "a"})
+	m.Delete("missing")
+	expectNoEvent(t, ch)
+
+	m.ReplaceAll(map[string]int{"c": 3})
+	got := map[string]syncmapt.Event[string, int]{}
+	for range 2 {
+		e := receive(t, ch)
+		got[e.Key] = e
+	}
+	if got["b"].Kind != syncmapt.EventDelete || got["c"] != (syncmapt.Event[string, int]{Kind: syncmapt.EventStore, Key: "c", Value: 3}) {
+		t.Fatalf("events after ReplaceAll = %+v", got)
+	}
+
+	cancel()
+	for range ch {
+	}
+}
+
+func TestWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := new(syncmapt.Map[string, int])
+
+	ch := m.Watch(ctx, "leader")
+	m.Store("other", 1)
+	expectNoEvent(t, ch)
+
+	// Changes made while the receiver is not keeping up are coalesced.
+	for i := 1; i <= 100; i++ {
+		m.Store("leader", i)
+	}
+	for {
+		e := receive(t, ch)
+		if e.Kind != syncmapt.EventStore {
+			t.Fatalf("event = %+v, want a store", e)
+		}
+		if e.Value == 100 {
+			break
+		}
+	}
+	expectNoEvent(t, ch)
+
+	m.Clear()
+	expectEvent(t, ch, syncmapt.Event[string, int]{Kind: syncmapt.EventDelete, Key: "leader"})
+}
+
+func TestExpiringMapWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clock := newFakeClock()
+	m := syncmapt.NewExpiringMap(syncmapt.WithClock[string, int](clock))
+	defer m.Close()
+
+	ch := m.WatchAll(ctx)
+	m.StoreWithTTL("a", 1, time.Second)
+	expectEvent(t, ch, syncmapt.Event[string, int]{Kind: syncmapt.EventStore, Key: "a", Value: 1})
+	m.StoreWithTTL("b", 2, time.Second)
+	expectEvent(t, ch, syncmapt.Event[string, int]{Kind: syncmapt.EventStore, Key: "b", Value: 2})
+	m.Delete("b")
+	expectEvent(t, ch, syncmapt.Event[string, int]{Kind: syncmapt.EventDelete, Key: "b"})
+
+	clock.Advance(time.Second)
+	expectNoEvent(t, ch)
+	if n := m.DeleteExpired(); n != 1 {
+		t.Fatalf("DeleteExpired() = %d, want 1", n)
+	}
+	expectEvent(t, ch, syncmapt.Event[string, int]{Kind: syncmapt.EventExpire, Key: "a"})
+}